@@ -3,7 +3,9 @@ package main
 import (
 	"backend/pkg/compile"
 	"backend/pkg/config"
+	"backend/pkg/lock"
 	"backend/pkg/parse"
+	"backend/pkg/result"
 	"backend/pkg/workflow"
 	"flag"
 	"fmt"
@@ -21,6 +23,7 @@ var (
 	taskType   string
 	jsonPath   string
 	patchPath  string
+	resultPath string
 	doCompile  bool
 	doGenerate bool
 	doCompress bool
@@ -56,7 +59,7 @@ func init() {
 	}
 	log.SetLevel(log.DebugLevel)
 
-	flag.StringVar(&taskType, "type", "", "task type: kernel-build / patch-apply / clean")
+	flag.StringVar(&taskType, "type", "", "task type: kernel-build / patch-apply / fix-verify / bisect / clean / slots")
 	flag.StringVar(&taskType, "t", "", "shorthand for --type")
 
 	flag.StringVar(&jsonPath, "file", "", "task file path")
@@ -65,6 +68,9 @@ func init() {
 	flag.StringVar(&patchPath, "patch", "", "patch file path")
 	flag.StringVar(&patchPath, "p", "", "shorthand for --patch")
 
+	flag.StringVar(&resultPath, "result", "", "result file listing the files produced by this run")
+	flag.StringVar(&resultPath, "r", "", "shorthand for --result")
+
 	flag.BoolVar(&doCompile, "compile", false, "compile kernel")
 	flag.BoolVar(&doCompile, "c", false, "shorthand for --compile")
 
@@ -114,10 +120,30 @@ func contain(s string) bool {
 func main() {
 	flag.Parse()
 
+	if resultPath != "" {
+		if err := result.Open(resultPath); err != nil {
+			log.Errorf("Failed to create result file: %v", err)
+			os.Exit(1)
+		}
+	}
+
 	for k, v := range configs {
 		compile.ModifyConfig(k, v)
 	}
 
+	// 任务提交的构建目录同时只能被一个进程使用；fix-verify 与 bisect 在每个提交上分别加锁。
+	// os.Exit 不执行 defer，进程退出时内核同样释放锁
+	switch taskType {
+	case "kernel-build", "clean", "patch-apply":
+		data := parse.Parse(jsonPath)
+		l, err := lock.Commit(data.Crashes[0].KernelSourceCommit)
+		if err != nil {
+			log.Errorf("Failed to lock commit: %v", err)
+			os.Exit(1)
+		}
+		defer l.Release()
+	}
+
 	switch taskType {
 	case "kernel-build":
 		if doCompile {
@@ -180,5 +206,23 @@ func main() {
 			log.Errorf("Failed to apply patch: %v", err)
 			os.Exit(1)
 		}
+	case "fix-verify":
+		err := workflow.FixVerify(jsonPath)
+		if err != nil {
+			log.Errorf("Failed to verify fix: %v", err)
+			os.Exit(1)
+		}
+	case "bisect":
+		err := workflow.Bisect(jsonPath)
+		if err != nil {
+			log.Errorf("Failed to bisect: %v", err)
+			os.Exit(1)
+		}
+	case "slots":
+		err := workflow.Slots()
+		if err != nil {
+			log.Errorf("Failed to list vm slots: %v", err)
+			os.Exit(1)
+		}
 	}
 }
@@ -0,0 +1,126 @@
+package arch
+
+// 漏洞报告 crashes[].architecture 对应的内核编译与虚拟机运行方式。
+// 与宿主机架构相同且 /dev/kvm 可用时使用 KVM，其他情况由 QEMU 以 TCG 模拟运行
+
+import (
+	"backend/pkg/parse"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"sort"
+)
+
+type Arch struct {
+	Name         string   // syzbot 与 syzkaller 使用的架构名，与 GOARCH 一致
+	KernelArch   string   // make 的 ARCH=，也是源码树 arch/ 下的目录
+	CrossCompile string   // 在其他架构的宿主机上用 gcc 编译时的 CROSS_COMPILE= 前缀
+	BootImage    string   // 编译产生的启动镜像，相对于源码树
+	QEMU         string   // qemu 可执行程序
+	Machine      []string // qemu 机器类型参数
+	KVMCPU       string   // 使用 KVM 时的 CPU 型号
+	TCGCPU       string   // TCG 模拟时的 CPU 型号
+	Drive        string   // 磁盘的 -drive 接口
+	RootDevice   string   // 客户机根文件系统所在的设备
+	Console      string   // 客户机串口控制台
+	GuestImage   string   // build-vmcore/image 中该架构的客户机磁盘镜像
+	CrashKernel  string   // 客户机中 kdump 使用的内核
+	PanicDevice  string   // 客户机 panic 时通知 QEMU 的 pvpanic 设备
+}
+
+var arches = map[string]Arch{
+	"amd64": {
+		Name:         "amd64",
+		KernelArch:   "x86",
+		CrossCompile: "x86_64-linux-gnu-",
+		BootImage:    "arch/x86_64/boot/bzImage",
+		QEMU:         "qemu-system-x86_64",
+		KVMCPU:       "host,-x2apic",
+		TCGCPU:       "max",
+		Drive:        "ide",
+		RootDevice:   "/dev/sda",
+		Console:      "ttyS0",
+		GuestImage:   "debian.img",
+		CrashKernel:  "/boot/crash-bzImage",
+		PanicDevice:  "pvpanic",
+	},
+	"arm64": {
+		Name:         "arm64",
+		KernelArch:   "arm64",
+		CrossCompile: "aarch64-linux-gnu-",
+		BootImage:    "arch/arm64/boot/Image",
+		QEMU:         "qemu-system-aarch64",
+		Machine:      []string{"-machine", "virt"},
+		KVMCPU:       "host",
+		TCGCPU:       "max",
+		Drive:        "virtio",
+		RootDevice:   "/dev/vda",
+		Console:      "ttyAMA0",
+		GuestImage:   "debian-arm64.img",
+		CrashKernel:  "/boot/crash-Image",
+		PanicDevice:  "pvpanic-pci",
+	},
+	"riscv64": {
+		Name:         "riscv64",
+		KernelArch:   "riscv",
+		CrossCompile: "riscv64-linux-gnu-",
+		BootImage:    "arch/riscv/boot/Image",
+		QEMU:         "qemu-system-riscv64",
+		Machine:      []string{"-machine", "virt"},
+		KVMCPU:       "host",
+		TCGCPU:       "rv64",
+		Drive:        "virtio",
+		RootDevice:   "/dev/vda",
+		Console:      "ttyS0",
+		GuestImage:   "debian-riscv64.img",
+		CrashKernel:  "/boot/crash-Image",
+		PanicDevice:  "pvpanic-pci",
+	},
+}
+
+// Get 按架构名查找，空名称为 amd64
+func Get(name string) (Arch, error) {
+	if name == "" {
+		name = "amd64"
+	}
+	a, ok := arches[name]
+	if !ok {
+		return Arch{}, fmt.Errorf("unsupported architecture %q, expect one of %v", name, Names())
+	}
+	return a, nil
+}
+
+// Of 漏洞报告的架构
+func Of(report *parse.CrashReport) (Arch, error) {
+	return Get(report.Crashes[0].Architecture)
+}
+
+// Names 支持的架构名
+func Names() []string {
+	names := make([]string, 0, len(arches))
+	for name := range arches {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Native 是否与宿主机架构相同，/dev/kvm 可用时可以使用 KVM
+func (a Arch) Native() bool {
+	return a.Name == runtime.GOARCH
+}
+
+// Cross 编译时是否需要交叉编译工具链
+func (a Arch) Cross() bool {
+	return !a.Native()
+}
+
+// BootImageName 启动镜像复制到虚拟机工作目录后的文件名
+func (a Arch) BootImageName() string {
+	return filepath.Base(a.BootImage)
+}
+
+// KernelAppend 客户机内核的启动参数
+func (a Arch) KernelAppend() string {
+	return fmt.Sprintf("root=%s console=%s,115200n8 rw crashkernel=256M", a.RootDevice, a.Console)
+}
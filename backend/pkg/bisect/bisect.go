@@ -0,0 +1,234 @@
+package bisect
+
+// bisect 任务：在只含提交历史的 linux 裸仓库（tree:0 部分克隆）中以 --no-checkout 方式执行 git bisect，
+// 只由 git 选出候选提交，候选提交的源码与其他任务一样按提交下载后编译。
+// 每一步的结论、跳过原因与串口日志保存在 build/bisect/<bad>，由 worker 读取上报
+
+import (
+	"backend/pkg/kvm"
+	"backend/pkg/progress"
+	"backend/pkg/result"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	RepoURL    = "https://github.com/torvalds/linux.git"
+	repoDir    = "repo/linux.git"
+	resultFile = "result.json"
+)
+
+// 每一步的结论，与 git bisect 的子命令一致
+const (
+	Good = "good"
+	Bad  = "bad"
+	Skip = "skip"
+)
+
+type Step struct {
+	Commit         string `json:"commit"`
+	Verdict        string `json:"verdict"`
+	Reason         string `json:"reason,omitempty"`
+	Crashed        bool   `json:"crashed"`
+	VmcoreCaptured bool   `json:"vmcore_captured"`
+}
+
+// Result 二分的过程与结论。跳过的提交使 git 无法确定第一个坏提交时，Suspects 列出所有可能的提交
+type Result struct {
+	Good         string   `json:"good"`
+	Bad          string   `json:"bad"`
+	Steps        []Step   `json:"steps"`
+	Culprit      string   `json:"culprit,omitempty"`
+	CulpritTitle string   `json:"culprit_title,omitempty"`
+	Suspects     []string `json:"suspects,omitempty"`
+}
+
+var (
+	firstBadPattern = regexp.MustCompile(`(?m)^([0-9a-f]{40}) is the first bad commit`)
+	suspectPattern  = regexp.MustCompile(`(?m)^[0-9a-f]{40}$`)
+)
+
+// Dir 保存二分结果与各步串口日志的目录
+func Dir(bad string) string {
+	return filepath.Join("build", "bisect", bad)
+}
+
+func git(out io.Writer, args ...string) error {
+	cmd := exec.Command("git", append([]string{"--git-dir", repoDir}, args...)...)
+	cmd.Stdout = out
+	cmd.Stderr = out
+	return cmd.Run()
+}
+
+// gitOutput 执行 git 并返回输出，输出同时写入标准输出
+func gitOutput(args ...string) (string, error) {
+	var buf bytes.Buffer
+	err := git(io.MultiWriter(&buf, progress.Stdout), args...)
+	return buf.String(), err
+}
+
+// FetchHistory 取得包含 good 与 bad 的提交历史：第一次使用时部分克隆，缺少提交时再拉取
+func FetchHistory(good, bad string) error {
+	if _, err := os.Stat(repoDir); os.IsNotExist(err) {
+		log.Infoln("cloning commit history of", RepoURL, "into", repoDir)
+		cmd := exec.Command("git", "clone", "--bare", "--filter=tree:0", RepoURL, repoDir)
+		cmd.Stdout = progress.Stdout
+		cmd.Stderr = progress.Stdout
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("failed to clone %s: %v", RepoURL, err)
+		}
+	}
+
+	fetched := false
+	for _, commit := range []string{good, bad} {
+		if hasCommit(commit) {
+			continue
+		}
+		if !fetched {
+			log.Infoln("commit", commit, "not in", repoDir, "fetching")
+			if err := git(progress.Stdout, "fetch", "origin", "+refs/heads/*:refs/heads/*"); err != nil {
+				return fmt.Errorf("failed to fetch %s: %v", RepoURL, err)
+			}
+			fetched = true
+		}
+		if !hasCommit(commit) {
+			return fmt.Errorf("commit %s not found in %s", commit, RepoURL)
+		}
+	}
+	return nil
+}
+
+func hasCommit(commit string) bool {
+	return git(io.Discard, "cat-file", "-e", commit+"^{commit}") == nil
+}
+
+// Resolve 把缩写的提交展开为完整哈希，下载的源码目录以完整哈希命名
+func Resolve(commit string) (string, error) {
+	out, err := gitOutput("rev-parse", "--verify", commit+"^{commit}")
+	if err != nil {
+		return "", fmt.Errorf("unknown commit %s", commit)
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// Start 开始二分，返回第一个候选提交
+func Start(result *Result) (string, error) {
+	_ = git(io.Discard, "bisect", "reset")
+	out, err := gitOutput("bisect", "start", "--no-checkout", result.Bad, result.Good)
+	return next(result, out, err)
+}
+
+// Mark 记录一步的结论，返回下一个候选提交；找到第一个坏提交或只剩跳过的提交时返回空字符串
+func Mark(result *Result, step Step) (string, error) {
+	out, err := gitOutput("bisect", step.Verdict, step.Commit)
+	return next(result, out, err)
+}
+
+// Reset 结束二分；--no-checkout 模式下只清除 BISECT_HEAD 等状态
+func Reset() {
+	if err := git(progress.Stdout, "bisect", "reset"); err != nil {
+		log.Warnln("failed to reset bisect:", err)
+	}
+}
+
+func next(result *Result, out string, err error) (string, error) {
+	if m := firstBadPattern.FindStringSubmatch(out); m != nil {
+		result.Culprit = m[1]
+		if title, err := gitOutput("log", "-1", "--format=%s", m[1]); err == nil {
+			result.CulpritTitle = strings.TrimSpace(title)
+		}
+		return "", nil
+	}
+	// 只剩跳过的提交时 git 以 2 退出并列出所有可能的第一个坏提交
+	if _, rest, ok := strings.Cut(out, "The first bad commit could be any of:"); ok {
+		result.Suspects = suspectPattern.FindAllString(rest, -1)
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("git bisect failed: %v", err)
+	}
+
+	head, err := gitOutput("rev-parse", "BISECT_HEAD")
+	if err != nil {
+		return "", errors.New("git bisect did not choose a commit to test")
+	}
+	return strings.TrimSpace(head), nil
+}
+
+// Judge 得出一步的结论：无法下载、编译或启动的提交跳过，其余的提交崩溃为 bad，没有崩溃为 good
+func Judge(step *Step, err error) {
+	switch {
+	case err != nil:
+		step.Verdict = Skip
+		step.Reason = err.Error()
+	case step.Crashed:
+		step.Verdict = Bad
+	default:
+		step.Verdict = Good
+	}
+}
+
+// CheckEnd 确认二分的一端得出了预期的结论 want，否则二分没有意义
+func CheckEnd(step Step, want string) error {
+	if step.Verdict == Skip {
+		return fmt.Errorf("%s commit %s cannot be tested: %s", want, step.Commit, step.Reason)
+	}
+	if step.Verdict != want {
+		return fmt.Errorf("%s commit %s tested %s", want, step.Commit, step.Verdict)
+	}
+	return nil
+}
+
+// Crashed 根据结果文件中本次运行取得的 vmcore 与串口日志判断内核是否崩溃，任何内核报告都算作崩溃
+func Crashed(commit string) (crashed, vmcore bool) {
+	files := result.Of(commit)
+	vmcore = files.Vmcore != ""
+	if files.Console != "" {
+		if data, err := os.ReadFile(files.Console); err == nil && kvm.Crashed(data) {
+			crashed = true
+		}
+	}
+	return crashed || vmcore, vmcore
+}
+
+// SaveConsole 把本次运行的串口日志复制到二分目录，候选提交的构建目录随后会被删除。
+// get.sh 会把虚拟机工作目录中的串口日志移动到内核构建目录，工作目录随槽位释放被删除
+func SaveConsole(dir, commit string) {
+	path := result.Of(commit).Console
+	if path == "" {
+		return
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		log.Warnln("failed to read console log:", err)
+		return
+	}
+	if err := os.WriteFile(filepath.Join(dir, commit+".log"), data, 0644); err != nil {
+		log.Warnln("failed to save console log:", err)
+	}
+}
+
+// WriteResult 写入二分目录的 result.json，每一步之后都会更新
+func WriteResult(dir string, r *Result) {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		log.Errorln(err)
+		return
+	}
+	path := filepath.Join(dir, resultFile)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		log.Errorln("failed to write bisect result:", err)
+		return
+	}
+	result.SetBisect(path)
+}
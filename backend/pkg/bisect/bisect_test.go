@@ -0,0 +1,149 @@
+package bisect
+
+import (
+	"backend/pkg/result"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestJudge(t *testing.T) {
+	tests := []struct {
+		name    string
+		crashed bool
+		err     error
+		verdict string
+		reason  string
+	}{
+		{name: "crashed", crashed: true, verdict: Bad},
+		{name: "no crash", verdict: Good},
+		{name: "download failed", err: errors.New("failed to download linux-0f1e2d3c.tar.gz: 404 Not Found"), verdict: Skip, reason: "failed to download linux-0f1e2d3c.tar.gz: 404 Not Found"},
+		{name: "build failed", err: errors.New("make: *** [Makefile:1234: vmlinux] Error 2"), verdict: Skip, reason: "make: *** [Makefile:1234: vmlinux] Error 2"},
+		// 无法启动虚拟机时不知道是否崩溃，即使串口日志中有报告也跳过
+		{name: "boot failed", crashed: true, err: errors.New("ssh: handshake failed: EOF"), verdict: Skip, reason: "ssh: handshake failed: EOF"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step := Step{Commit: "0f1e2d3c", Crashed: tt.crashed}
+			Judge(&step, tt.err)
+			if step.Verdict != tt.verdict || step.Reason != tt.reason {
+				t.Errorf("verdict %q, reason %q, want %q, %q", step.Verdict, step.Reason, tt.verdict, tt.reason)
+			}
+		})
+	}
+}
+
+func TestCheckEnd(t *testing.T) {
+	tests := []struct {
+		step Step
+		want string
+		err  string
+	}{
+		{step: Step{Commit: "bad1", Verdict: Bad}, want: Bad},
+		{step: Step{Commit: "good1", Verdict: Good}, want: Good},
+		{step: Step{Commit: "bad1", Verdict: Good}, want: Bad, err: "bad commit bad1 tested good"},
+		{step: Step{Commit: "good1", Verdict: Bad}, want: Good, err: "good commit good1 tested bad"},
+		{step: Step{Commit: "good1", Verdict: Skip, Reason: "exit status 2"}, want: Good, err: "good commit good1 cannot be tested: exit status 2"},
+	}
+	for _, tt := range tests {
+		err := CheckEnd(tt.step, tt.want)
+		if (err == nil) != (tt.err == "") || (err != nil && err.Error() != tt.err) {
+			t.Errorf("CheckEnd(%+v, %s) = %v, want %q", tt.step, tt.want, err, tt.err)
+		}
+	}
+}
+
+// inTempDir 在临时目录中运行，构建目录与提交历史仓库的路径都相对于当前目录
+func inTempDir(t *testing.T) {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+}
+
+func TestNext(t *testing.T) {
+	inTempDir(t)
+
+	const culprit = "7a3f1c2b9d8e4f5061728394a5b6c7d8e9f0a1b2"
+	result := &Result{}
+	out := culprit + ` is the first bad commit
+commit ` + culprit + `
+Author: A U Thor <author@example.com>
+Date:   Mon Mar 4 10:12:45 2024 +0100
+
+    net: tun: free the netdev after the last reference
+`
+	candidate, err := next(result, out, nil)
+	if err != nil || candidate != "" || result.Culprit != culprit {
+		t.Errorf("first bad commit: next %q, error %v, culprit %q", candidate, err, result.Culprit)
+	}
+
+	// 只剩跳过的提交时 git 以 2 退出
+	result = &Result{}
+	out = `There are only 'skip'ped commits left to test.
+The first bad commit could be any of:
+1b2c3d4e5f60718293a4b5c6d7e8f9012a3b4c5d
+` + culprit + `
+We cannot bisect more!
+`
+	candidate, err = next(result, out, errors.New("exit status 2"))
+	want := []string{"1b2c3d4e5f60718293a4b5c6d7e8f9012a3b4c5d", culprit}
+	if err != nil || candidate != "" || result.Culprit != "" || !reflect.DeepEqual(result.Suspects, want) {
+		t.Errorf("skipped commits: next %q, error %v, suspects %v", candidate, err, result.Suspects)
+	}
+
+	result = &Result{}
+	if _, err := next(result, "fatal: bad revision 'deadbeef'\n", errors.New("exit status 128")); err == nil {
+		t.Error("failed git bisect returned no error")
+	}
+}
+
+func TestCrashed(t *testing.T) {
+	inTempDir(t)
+
+	// write 在 commit 的内核构建目录中写入文件，get.sh 把 vmcore 与串口日志移动到这里
+	write := func(commit, name, content string) string {
+		t.Helper()
+		tree := filepath.Join("build", commit, "linux-"+commit)
+		if err := os.MkdirAll(tree, 0755); err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join(tree, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	const clean = "[    1.000000] Linux version 6.8.0\n[   30.000000] systemd[1]: Reached target multi-user.target\n"
+
+	// 结果文件中没有记录的文件是之前的执行留下的
+	write("0f1e2d3c", "vmcore", "ELF")
+	write("0f1e2d3c", "0f1e2d3c.log", "[   40.120111] BUG: KASAN: slab-use-after-free in tun_chr_close+0x2c/0x40\n")
+	if crashed, vmcore := Crashed("0f1e2d3c"); crashed || vmcore {
+		t.Errorf("files of an earlier run: crashed %v, vmcore %v", crashed, vmcore)
+	}
+
+	result.SetConsole("1a2b3c4d", write("1a2b3c4d", "1a2b3c4d.log", clean))
+	if crashed, vmcore := Crashed("1a2b3c4d"); crashed || vmcore {
+		t.Errorf("clean console: crashed %v, vmcore %v", crashed, vmcore)
+	}
+
+	result.SetConsole("2b3c4d5e", write("2b3c4d5e", "2b3c4d5e.log", "[   40.120111] WARNING: CPU: 0 PID: 812 at net/core/dev.c:10874 netdev_run_todo+0x5e1/0x6a0\n"))
+	if crashed, vmcore := Crashed("2b3c4d5e"); !crashed || vmcore {
+		t.Errorf("report on the console: crashed %v, vmcore %v", crashed, vmcore)
+	}
+
+	// kdump 保存了 vmcore 时即使串口日志没有报告也算作崩溃
+	result.SetConsole("3c4d5e6f", write("3c4d5e6f", "3c4d5e6f.log", clean))
+	result.SetVmcore("3c4d5e6f", write("3c4d5e6f", "vmcore", "ELF"))
+	if crashed, vmcore := Crashed("3c4d5e6f"); !crashed || !vmcore {
+		t.Errorf("vmcore captured: crashed %v, vmcore %v", crashed, vmcore)
+	}
+}
@@ -0,0 +1,131 @@
+package compile
+
+import (
+	"backend/pkg/progress"
+	"bufio"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// kbuild 目标行，如 "obj-y += fork.o"、"obj-$(CONFIG_KASAN) += kasan/"、"foo-objs := a.o b.o"
+var kbuildLine = regexp.MustCompile(`^\s*([A-Za-z0-9_]+)-(y|m|objs|\$\((CONFIG_[A-Za-z0-9_]+)\))\s*[:+]?=\s*(.*)$`)
+
+// make 静默模式下每编译一个对象输出的行，如 "  CC      kernel/fork.o"、"  CC [M]  net/foo.o"
+var buildLine = regexp.MustCompile(`^\s+(CC|AS)(\s+\[M\])?\s+\S+\.o$`)
+
+// 不参与内核镜像编译的目录
+var skipDirs = map[string]bool{
+	".git":          true,
+	"Documentation": true,
+	"tools":         true,
+	"samples":       true,
+	"scripts":       true,
+}
+
+// readConfig 读取 .config 中启用（=y 或 =m）的选项
+func readConfig(configPath string) map[string]bool {
+	enabled := make(map[string]bool)
+	f, err := os.Open(configPath)
+	if err != nil {
+		return enabled
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), "=")
+		if ok && (value == "y" || value == "m") {
+			enabled[key] = true
+		}
+	}
+	return enabled
+}
+
+// estimateObjects 根据各 Makefile/Kbuild 中启用的目标与 .config 估计需要编译的对象数，
+// 只用于进度显示：不跟踪目录是否被启用，复合对象（foo-y := a.o b.o）按其组成部分计数
+func estimateObjects(kernelDir, kernelArch string) int64 {
+	enabled := readConfig(filepath.Join(kernelDir, ".config"))
+	archDir := filepath.Join(kernelDir, "arch")
+
+	var total int64
+	filepath.WalkDir(kernelDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if skipDirs[d.Name()] || (filepath.Dir(path) == archDir && d.Name() != kernelArch) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.Name() == "Makefile" || d.Name() == "Kbuild" {
+			total += countObjects(path, enabled)
+		}
+		return nil
+	})
+	return total
+}
+
+func countObjects(makefile string, enabled map[string]bool) int64 {
+	content, err := os.ReadFile(makefile)
+	if err != nil {
+		return 0
+	}
+	text := strings.ReplaceAll(string(content), "\\\n", " ")
+
+	composites := make(map[string]bool)
+	var objects []string
+	for _, line := range strings.Split(text, "\n") {
+		m := kbuildLine.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		if m[3] != "" && !enabled[m[3]] {
+			continue
+		}
+		if m[1] != "obj" && m[1] != "lib" {
+			composites[m[1]] = true
+		}
+		for _, field := range strings.Fields(m[4]) {
+			if strings.HasPrefix(field, "#") {
+				break
+			}
+			if strings.HasSuffix(field, ".o") {
+				objects = append(objects, strings.TrimSuffix(field, ".o"))
+			}
+		}
+	}
+
+	var count int64
+	for _, object := range objects {
+		if !composites[object] {
+			count++
+		}
+	}
+	return count
+}
+
+// makeProgress 返回统计 make 输出的 stdout 与 stderr Writer：编译行计入进度，编译器警告作为进度警告上报；
+// total 为预计编译的对象数，增量编译无法预计时为 0
+func makeProgress(step string, total int64) (stdout, stderr *progress.LineWriter) {
+	counter := progress.NewCounter(step, total)
+	warnings := progress.NewWarnings(step, 50)
+
+	watch := func(line string) {
+		if buildLine.MatchString(line) {
+			counter.Add(1)
+			// 估计偏少时放大总量，避免在编译结束前显示 100%
+			if total := counter.Total(); total > 0 && counter.Current() >= total {
+				counter.SetTotal(total + total/20 + 1)
+			}
+			return
+		}
+		if strings.Contains(line, ": warning: ") {
+			warnings.Warn(line)
+		}
+	}
+	return progress.Lines(watch), progress.Lines(watch)
+}
@@ -2,8 +2,11 @@ package compile
 
 import (
 	"archive/tar"
+	"backend/pkg/arch"
 	"backend/pkg/config"
 	"backend/pkg/parse"
+	"backend/pkg/progress"
+	"backend/pkg/result"
 	"bufio"
 	"bytes"
 	"compress/gzip"
@@ -60,6 +63,9 @@ const KernelURL = "https://github.com/torvalds/linux/archive/"
 // global toolchain for certain bug construct. Use InitToolChain to decide toolchain automatically
 var GlobalToolChain *ToolChain = nil
 
+// target architecture of the kernel, decided by InitToolChain from the crash report
+var GlobalArch, _ = arch.Get("")
+
 // if deploy locally, please change below to adapt your environment
 var toolChains = map[string]string{
 	"gcc-10.2.0": "toolchain/gcc/gcc-10.2.0",
@@ -96,6 +102,9 @@ var requiredConfigs = map[string]string{
 	"CONFIG_KALLSYMS":                 "y",
 	"CONFIG_KALLSYMS_ALL":             "y",
 	"CONFIG_GDB_SCRIPTS":              "y",
+	"CONFIG_PVPANIC":                  "y",
+	"CONFIG_PVPANIC_MMIO":             "y",
+	"CONFIG_PVPANIC_PCI":              "y",
 }
 
 func ModifyConfig(config, status string) {
@@ -113,6 +122,10 @@ func buildEnv() []string {
 	env = append(env, fmt.Sprintf("CC=%s", GlobalToolChain.CC))
 	env = append(env, fmt.Sprintf("HOSTCC=%s", GlobalToolChain.CC))
 	env = append(env, fmt.Sprintf("AR=%s", fmt.Sprintf("%s/gcc-ar", GlobalToolChain.Path)))
+	env = append(env, fmt.Sprintf("ARCH=%s", GlobalArch.KernelArch))
+	if GlobalArch.Cross() {
+		env = append(env, fmt.Sprintf("CROSS_COMPILE=%s", GlobalArch.CrossCompile))
+	}
 	return env
 }
 
@@ -213,7 +226,8 @@ func DownloadKernel(report *parse.CrashReport) error {
 			}
 		}()
 
-		_, err = io.Copy(io.Writer(outFile), resp.Body)
+		// 先报告下载进度，解压时再按读取的压缩包字节数重新报告
+		_, err = io.Copy(io.MultiWriter(outFile, progress.NewCounter("DownloadKernel", resp.ContentLength)), resp.Body)
 		if err != nil {
 			return err
 		}
@@ -232,7 +246,11 @@ func DownloadKernel(report *parse.CrashReport) error {
 		}
 	}()
 
-	gzReader, err := gzip.NewReader(f)
+	var archiveSize int64
+	if info, err := f.Stat(); err == nil {
+		archiveSize = info.Size()
+	}
+	gzReader, err := gzip.NewReader(io.TeeReader(f, progress.NewCounter("DownloadKernel", archiveSize)))
 	if err != nil {
 		return err
 	}
@@ -302,12 +320,25 @@ func DownloadKernel(report *parse.CrashReport) error {
 	return nil
 }
 
-// download bug reproducer file (c file)
+// download bug reproducers: c reproducer into bug.c and syz reproducer into bug.syz, whichever the report has.
+// reproducers and syzkaller binaries of previous tasks on the same tree are removed first
 func DownloadBug(report *parse.CrashReport) error {
 	if GlobalToolChain == nil {
 		return errors.New("toolchain not initialized")
 	}
 
+	crash := report.Crashes[0]
+	if crash.CReproducer == "" && crash.SyzReproducer == "" {
+		return errors.New("report has neither a c nor a syz reproducer")
+	}
+
+	buildDir := kernelPath(report)
+	for _, name := range []string{CReproducerFile, SyzReproducerFile, syzBinDir} {
+		if err := os.RemoveAll(filepath.Join(buildDir, name)); err != nil {
+			return err
+		}
+	}
+
 	proxyURL, _ := url.Parse(fmt.Sprintf("http://127.0.0.1:%s", config.GlobalConfig.Port))
 	transport := &http.Transport{
 		Proxy: http.ProxyURL(proxyURL),
@@ -316,10 +347,25 @@ func DownloadBug(report *parse.CrashReport) error {
 		Transport: transport,
 	}
 
-	reproducerURL := parse.SyzkallerURL + report.Crashes[0].CReproducer
-	log.Infof("downloading c reproducer from %s", reproducerURL)
-	buildDir := fmt.Sprintf("build/%s/%s", report.Crashes[0].KernelSourceCommit, fmt.Sprintf("linux-%s", report.Crashes[0].KernelSourceCommit))
-	reproducerFile := fmt.Sprintf("%s/bug.c", buildDir)
+	if crash.CReproducer != "" {
+		if err := downloadReproducer(client, crash.CReproducer, filepath.Join(buildDir, CReproducerFile)); err != nil {
+			return err
+		}
+		log.Infoln("download c reproducer success")
+	}
+	if crash.SyzReproducer != "" {
+		if err := downloadReproducer(client, crash.SyzReproducer, filepath.Join(buildDir, SyzReproducerFile)); err != nil {
+			return err
+		}
+		log.Infoln("download syz reproducer success")
+	}
+
+	return nil
+}
+
+func downloadReproducer(client *http.Client, path, file string) error {
+	reproducerURL := parse.SyzkallerURL + path
+	log.Infof("downloading reproducer from %s", reproducerURL)
 
 	resp, err := client.Get(reproducerURL)
 	if err != nil {
@@ -330,8 +376,11 @@ func DownloadBug(report *parse.CrashReport) error {
 			log.Errorln(err)
 		}
 	}()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to download %s: %s", reproducerURL, resp.Status)
+	}
 
-	outFile, err := os.Create(reproducerFile)
+	outFile, err := os.Create(file)
 	if err != nil {
 		return err
 	}
@@ -342,14 +391,7 @@ func DownloadBug(report *parse.CrashReport) error {
 	}()
 
 	_, err = io.Copy(io.Writer(outFile), resp.Body)
-	if err != nil {
-		return err
-	}
-
-	fmt.Println("")
-	log.Infoln("download c reproducer success")
-
-	return nil
+	return err
 }
 
 // download config form syzkaller
@@ -408,6 +450,7 @@ func DownloadConfig(report *parse.CrashReport) error {
 		return err
 	}
 
+	result.SetConfig(report.Crashes[0].KernelSourceCommit, configFile)
 	return nil
 }
 
@@ -461,6 +504,7 @@ func checkFix(configPath string) error {
 				actual := configMap[key]
 				if actual != expected {
 					log.Infof("[✘] error config: %s (expected: %s, actual: %s)\n", key, expected, actual)
+					progress.Warn("DownloadConfig", "kernel config %s changed from %q to %q for kdump", key, actual, expected)
 					if expected == "n" {
 						lines[i] = fmt.Sprintf("# %s is not set", key)
 					} else {
@@ -477,6 +521,7 @@ func checkFix(configPath string) error {
 	for key, expected := range requiredConfigs {
 		if !found[key] {
 			log.Infof("[✘] missing config: %s (expected: %s)\n", key, expected)
+			progress.Warn("DownloadConfig", "kernel config %s missing, set to %q for kdump", key, expected)
 			if expected == "n" {
 				lines = append(lines, fmt.Sprintf("# %s is not set", key))
 			} else {
@@ -499,10 +544,11 @@ func checkFix(configPath string) error {
 
 		cmd := exec.Command("make",
 			fmt.Sprintf("CC=%s", GlobalToolChain.CC),
-			fmt.Sprintf("HOSTCC=%s", GlobalToolChain.CC),
+			fmt.Sprintf("HOSTCC=%s", hostCC()),
 			"olddefconfig")
+		cmd.Env = buildEnv()
 		cmd.Dir = filepath.Dir(configPath)
-		cmd.Stdout = os.Stdout
+		cmd.Stdout = progress.Stdout
 		cmd.Stderr = os.Stderr
 
 		if err := cmd.Run(); err != nil {
@@ -584,12 +630,19 @@ func findToolchain(compiler string, version string) (string, error) {
 
 func setToolchain(report *parse.CrashReport) ToolChain {
 	compiler, version := configCompiler(report)
-	key, err := findToolchain(compiler, version)
+	// cross gcc toolchains are named with the target prefix, e.g. aarch64-linux-gnu-gcc-10.2.0; clang is a cross compiler itself
+	prefix := ""
+	if compiler == "gcc" && GlobalArch.Cross() {
+		prefix = GlobalArch.CrossCompile
+	}
+	key, err := findToolchain(prefix+compiler, version)
 	if err != nil {
 		log.Infoln("Global Compiler Not Found:", err)
-		log.Infoln("Use Default Compiler:", compiler)
+		log.Infoln("Use Default Compiler:", prefix+compiler)
 		if compiler == "gcc" {
-			return defaultGCC
+			tc := defaultGCC
+			tc.CC = filepath.Join(tc.Path, prefix+"gcc")
+			return tc
 		}
 		if compiler == "clang" {
 			return defaultClang
@@ -604,13 +657,27 @@ func setToolchain(report *parse.CrashReport) ToolChain {
 		Type:    compiler,
 		Version: version,
 		Path:    filepath.Join(rootPath, toolChains[key]) + "/bin",
-		CC:      filepath.Join(rootPath, toolChains[key]) + "/bin/gcc",
+		CC:      filepath.Join(rootPath, toolChains[key]) + "/bin/" + prefix + "gcc",
 		LIB:     filepath.Join(rootPath, toolChains[key]) + "/lib64",
 	}
 }
 
+// hostCC compiler for programs run on the build host while building a kernel for another architecture
+func hostCC() string {
+	if GlobalArch.Cross() {
+		return "gcc"
+	}
+	return GlobalToolChain.CC
+}
+
 // must be called once before any compile package function
 func InitToolChain(report *parse.CrashReport) {
+	a, err := arch.Of(report)
+	if err != nil {
+		a, _ = arch.Get("")
+		log.Errorln(err, "- falling back to", a.Name)
+	}
+	GlobalArch = a
 	tc := setToolchain(report)
 	GlobalToolChain = &tc
 }
@@ -619,7 +686,7 @@ func MakeKernel(report *parse.CrashReport) error {
 	path := kernelPath(report)
 
 	logger := log.New()
-	logger.SetOutput(os.Stdout)
+	logger.SetOutput(progress.Stdout)
 	logger.SetFormatter(&log.TextFormatter{
 		FullTimestamp: true,
 	})
@@ -656,8 +723,9 @@ func MakeKernel(report *parse.CrashReport) error {
 
 	stdout := &bytes.Buffer{}
 	stderr := &bytes.Buffer{}
-	compileCmd.Stdout = io.MultiWriter(stdout, logger.Writer())
-	compileCmd.Stderr = io.MultiWriter(stderr, logger.Writer())
+	progressOut, progressErr := makeProgress("MakeKernel", estimateObjects(path, GlobalArch.KernelArch))
+	compileCmd.Stdout = io.MultiWriter(stdout, logger.Writer(), progressOut)
+	compileCmd.Stderr = io.MultiWriter(stderr, logger.Writer(), progressErr)
 
 	err := compileCmd.Run()
 	if err != nil {
@@ -666,9 +734,9 @@ func MakeKernel(report *parse.CrashReport) error {
 
 	log.Infoln("compilation succeeded")
 
-	bzImagePath := filepath.Join(path, "arch/x86_64/boot/bzImage")
-	if _, err := os.Stat(bzImagePath); os.IsNotExist(err) {
-		return fmt.Errorf("bzImage file not found: %s", bzImagePath)
+	bootImagePath := filepath.Join(path, GlobalArch.BootImage)
+	if _, err := os.Stat(bootImagePath); os.IsNotExist(err) {
+		return fmt.Errorf("boot image file not found: %s", bootImagePath)
 	}
 
 	time.Sleep(time.Second * 2)
@@ -731,99 +799,3 @@ func ClearDownload(report *parse.CrashReport) error {
 	log.Infoln("removed file:", tarFilePath)
 	return nil
 }
-
-func GeneratePatch(report *parse.CrashReport, patch string) error {
-	if err := parse.WriteCustomPatch(report, "patch.diff", patch); err != nil {
-		return fmt.Errorf("failed to write patch file: %v", err)
-	}
-	log.Infoln("patch file generated successfully")
-	return nil
-}
-
-func ApplyPatch(report *parse.CrashReport) error {
-	applyCmd := exec.Command("git", "apply", "patch.diff")
-	applyCmd.Dir = kernelPath(report)
-	applyCmd.Stdout = os.Stdout
-	applyCmd.Stderr = os.Stderr
-	if err := applyCmd.Run(); err != nil {
-		return fmt.Errorf("failed to apply patch: %v", err)
-	}
-	log.Infoln("patch applied successfully")
-	return nil
-}
-
-func RebuildKernel(report *parse.CrashReport, patch string) error {
-	path := kernelPath(report)
-
-	logger := log.New()
-	logger.SetOutput(os.Stdout)
-	logger.SetFormatter(&log.TextFormatter{
-		FullTimestamp: true,
-	})
-
-	if GlobalToolChain == nil {
-		return errors.New("toolchain not initialized")
-	}
-
-	if _, err := os.Stat(path); os.IsNotExist(err) {
-		return err
-	}
-
-	env := buildEnv()
-
-	numCPU := runtime.NumCPU() - 2
-	makeJobs := fmt.Sprintf("-j%d", numCPU)
-
-	configPath := filepath.Join(path, ".config")
-	if !fileExists(configPath) {
-		return errors.New("config file not found")
-	}
-
-	time.Sleep(time.Second * 2)
-	log.Infoln("starting kernel compilation in", path)
-
-	compileCmd := exec.Command("bear", "--output", "rebuild_compile_commands.json", "--", "make", makeJobs)
-	compileCmd.Env = env
-	compileCmd.Dir = path
-
-	stdout := &bytes.Buffer{}
-	stderr := &bytes.Buffer{}
-	compileCmd.Stdout = io.MultiWriter(stdout, logger.Writer())
-	compileCmd.Stderr = io.MultiWriter(stderr, logger.Writer())
-
-	err := compileCmd.Run()
-	if err != nil {
-		return fmt.Errorf("error compiling kernel: %s", err)
-	}
-
-	log.Infoln("compilation succeeded")
-
-	bzImagePath := filepath.Join(path, "arch/x86_64/boot/bzImage")
-	if _, err := os.Stat(bzImagePath); os.IsNotExist(err) {
-		return fmt.Errorf("bzImage file not found: %s", bzImagePath)
-	}
-
-	time.Sleep(time.Second * 2)
-	log.Infoln("starting linux header install", path)
-
-	headerCmd := exec.Command("make", "headers_install", "INSTALL_HDR_PATH=./linux-header")
-	headerCmd.Env = env
-	headerCmd.Dir = path
-	headerCmd.Stdout = io.MultiWriter(stdout, logger.Writer())
-	headerCmd.Stderr = io.MultiWriter(stderr, logger.Writer())
-
-	err = headerCmd.Run()
-	if err != nil {
-		return fmt.Errorf("error installing header: %s", err)
-	}
-
-	empty, err := dirExistsAndNotEmpty(filepath.Join(path, "linux-header"))
-	if err != nil {
-		return err
-	}
-	if !empty {
-		return errors.New("linux header not generated")
-	}
-
-	return nil
-}
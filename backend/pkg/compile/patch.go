@@ -0,0 +1,293 @@
+package compile
+
+// patch-apply 任务：在基准源码树上按顺序检查并应用补丁系列后增量编译。各补丁、git apply 的输出、
+// 每个补丁的应用结果与编译日志保存在 build/<commit>/patch 目录，作为任务产物上传；
+// 源码树外的 applied 目录记录已应用的补丁，下一次任务开始前据此逆序撤销，使源码树回到基准提交
+
+import (
+	"backend/pkg/parse"
+	"backend/pkg/progress"
+	"backend/pkg/result"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	applyLogFile   = "apply.log"
+	rebuildLogFile = "rebuild.log"
+	resultFile     = "result.json"
+)
+
+// 补丁的应用结果，与服务器 TaskResult.patches 的元素一致
+const (
+	PatchApplied = "applied"
+	PatchFailed  = "failed"
+	PatchSkipped = "skipped"
+)
+
+type PatchApplyResult struct {
+	Index   int    `json:"index"`
+	Subject string `json:"subject"`
+	Status  string `json:"status"`
+	Error   string `json:"error,omitempty"`
+}
+
+// PatchDir 保存补丁及其应用、编译结果的目录
+func PatchDir(report *parse.CrashReport) string {
+	return filepath.Join(filepath.Dir(kernelPath(report)), "patch")
+}
+
+// appliedDir 保存当前应用在源码树上的补丁，文件名按应用顺序编号
+func appliedDir(report *parse.CrashReport) string {
+	return filepath.Join(filepath.Dir(kernelPath(report)), "applied")
+}
+
+func patchFileName(index int) string {
+	return fmt.Sprintf("%04d.patch", index)
+}
+
+// gitApply 在源码树中执行 git apply。源码树由压缩包解压而来，不是 git 仓库；
+// 禁止向上查找仓库，否则补丁路径会相对于外层仓库的根目录解析，文件被静默跳过
+func gitApply(dir string, out io.Writer, args ...string) error {
+	cmd := exec.Command("git", append([]string{"apply", "-v"}, args...)...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_CEILING_DIRECTORIES="+filepath.Dir(dir))
+	cmd.Stdout = out
+	cmd.Stderr = out
+	return cmd.Run()
+}
+
+// openPatchLog 以追加方式打开补丁目录中的日志，输出同时写入标准输出
+func openPatchLog(report *parse.CrashReport, name string) (*os.File, io.Writer, error) {
+	f, err := os.OpenFile(filepath.Join(PatchDir(report), name), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, nil, err
+	}
+	return f, io.MultiWriter(f, progress.Stdout), nil
+}
+
+// RestoreBaseTree 逆序撤销之前的 patch-apply 任务应用的补丁；无法撤销时删除源码树，由 DownloadKernel 重新解压
+func RestoreBaseTree(report *parse.CrashReport) error {
+	dir := appliedDir(report)
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	path := kernelPath(report)
+	exists, err := dirExistsAndNotEmpty(path)
+	if err != nil {
+		return err
+	}
+	if exists {
+		slices.Reverse(entries)
+		for _, entry := range entries {
+			if err := gitApply(path, progress.Stdout, "-R", filepath.Join(dir, entry.Name())); err != nil {
+				log.Warnf("failed to revert previous patch %s, removing %s: %v", entry.Name(), path, err)
+				if err := os.RemoveAll(path); err != nil {
+					return fmt.Errorf("failed to remove patched kernel tree: %v", err)
+				}
+				return os.RemoveAll(dir)
+			}
+		}
+		log.Infoln("previous patches reverted in", path)
+	}
+	return os.RemoveAll(dir)
+}
+
+// GeneratePatch 清空补丁目录并按顺序写入补丁系列
+func GeneratePatch(report *parse.CrashReport, series []parse.SeriesPatch) error {
+	if len(series) == 0 {
+		return errors.New("no patch given")
+	}
+
+	dir := PatchDir(report)
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	for i, patch := range series {
+		if strings.TrimSpace(patch.Diff) == "" {
+			return fmt.Errorf("patch %d (%s) is empty", i+1, patch.Subject)
+		}
+		if err := os.WriteFile(filepath.Join(dir, patchFileName(i+1)), []byte(patch.Diff), 0644); err != nil {
+			return fmt.Errorf("failed to write patch file: %v", err)
+		}
+	}
+	log.Infof("%d patch files generated successfully", len(series))
+	return nil
+}
+
+// ApplySeries 按顺序应用补丁系列：每个补丁先用 git apply --check 检查，通过后再应用，
+// 因此失败的补丁不会留下部分修改。在第一个失败的补丁处停止，之后的补丁记为 skipped；
+// 各补丁的结果写入补丁目录的 result.json
+func ApplySeries(report *parse.CrashReport, series []parse.SeriesPatch) error {
+	f, out, err := openPatchLog(report, applyLogFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := os.MkdirAll(appliedDir(report), 0755); err != nil {
+		return err
+	}
+
+	results := make([]PatchApplyResult, len(series))
+	for i, patch := range series {
+		results[i] = PatchApplyResult{Index: i + 1, Subject: patch.Subject, Status: PatchSkipped}
+	}
+	defer writePatchResults(report, results)
+
+	path := kernelPath(report)
+	for i, patch := range series {
+		name := patchFileName(i + 1)
+		file := filepath.Join(PatchDir(report), name)
+		fmt.Fprintf(out, "[%d/%d] %s\n", i+1, len(series), patch.Subject)
+
+		var output bytes.Buffer
+		err := gitApply(path, io.MultiWriter(out, &output), "--check", file)
+		if err == nil {
+			err = gitApply(path, io.MultiWriter(out, &output), file)
+		}
+		if err != nil {
+			results[i].Status = PatchFailed
+			results[i].Error = gitError(output.String(), err)
+			fmt.Fprintf(out, "result: patch %d/%d does not apply\n", i+1, len(series))
+			return fmt.Errorf("patch %d/%d (%s) does not apply: %s", i+1, len(series), patch.Subject, results[i].Error)
+		}
+
+		if err := os.WriteFile(filepath.Join(appliedDir(report), name), []byte(patch.Diff), 0644); err != nil {
+			return fmt.Errorf("failed to record applied patch: %v", err)
+		}
+		results[i].Status = PatchApplied
+		fmt.Fprintf(out, "result: patch %d/%d applied\n", i+1, len(series))
+	}
+
+	log.Infof("%d patches applied successfully", len(series))
+	return nil
+}
+
+// gitError 取 git apply 输出中的 error 行作为失败原因，没有时使用退出状态
+func gitError(output string, err error) string {
+	var messages []string
+	for _, line := range strings.Split(output, "\n") {
+		if message, ok := strings.CutPrefix(line, "error: "); ok {
+			messages = append(messages, message)
+		}
+	}
+	if len(messages) == 0 {
+		return err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+func writePatchResults(report *parse.CrashReport, results []PatchApplyResult) {
+	data, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		log.Errorln(err)
+		return
+	}
+	path := filepath.Join(PatchDir(report), resultFile)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		log.Errorln("failed to write patch results:", err)
+		return
+	}
+	result.SetPatches(path)
+}
+
+// RebuildKernel 在应用补丁后的源码树上增量编译，输出写入补丁目录的 rebuild.log
+func RebuildKernel(report *parse.CrashReport) error {
+	path := kernelPath(report)
+
+	if GlobalToolChain == nil {
+		return errors.New("toolchain not initialized")
+	}
+
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return err
+	}
+
+	configPath := filepath.Join(path, ".config")
+	if !fileExists(configPath) {
+		return errors.New("config file not found")
+	}
+
+	f, out, err := openPatchLog(report, rebuildLogFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	env := buildEnv()
+
+	numCPU := runtime.NumCPU() - 2
+	makeJobs := fmt.Sprintf("-j%d", numCPU)
+
+	time.Sleep(time.Second * 2)
+	log.Infoln("starting incremental kernel compilation in", path)
+
+	var compileCmd *exec.Cmd
+	if flag {
+		compileCmd = exec.Command("bear", "--output", "rebuild_compile_commands.json", "--", "make", "LLVM=1", makeJobs)
+	} else {
+		compileCmd = exec.Command("bear", "--output", "rebuild_compile_commands.json", "--", "make", makeJobs)
+	}
+	compileCmd.Env = env
+	compileCmd.Dir = path
+
+	// 增量编译的对象数无法预计，只报告已编译的数量
+	progressOut, progressErr := makeProgress("RebuildKernel", 0)
+	compileCmd.Stdout = io.MultiWriter(out, progressOut)
+	compileCmd.Stderr = io.MultiWriter(out, progressErr)
+
+	if err := compileCmd.Run(); err != nil {
+		return fmt.Errorf("error compiling kernel: %s", err)
+	}
+
+	log.Infoln("compilation succeeded")
+
+	bootImagePath := filepath.Join(path, GlobalArch.BootImage)
+	if _, err := os.Stat(bootImagePath); os.IsNotExist(err) {
+		return fmt.Errorf("boot image file not found: %s", bootImagePath)
+	}
+
+	time.Sleep(time.Second * 2)
+	log.Infoln("starting linux header install", path)
+
+	headerCmd := exec.Command("make", "headers_install", "INSTALL_HDR_PATH=./linux-header")
+	headerCmd.Env = env
+	headerCmd.Dir = path
+	headerCmd.Stdout = out
+	headerCmd.Stderr = out
+
+	if err := headerCmd.Run(); err != nil {
+		return fmt.Errorf("error installing header: %s", err)
+	}
+
+	empty, err := dirExistsAndNotEmpty(filepath.Join(path, "linux-header"))
+	if err != nil {
+		return err
+	}
+	if !empty {
+		return errors.New("linux header not generated")
+	}
+
+	return nil
+}
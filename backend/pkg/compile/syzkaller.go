@@ -0,0 +1,331 @@
+package compile
+
+// syz 复现程序：按漏洞报告的 syzkaller 提交编译 syz-execprog 与 syz-executor，复制到源码树的 syz 目录，
+// 由 mount.sh 与 bug.syz 一起放入客户机。syzkaller 源码缓存在 repo/syzkaller.git，各提交编译出的程序
+// 缓存在 repo/syzkaller/<commit>，已编译过的提交不再需要网络
+
+import (
+	"backend/pkg/config"
+	"backend/pkg/parse"
+	"backend/pkg/progress"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	CReproducerFile   = "bug.c"
+	SyzReproducerFile = "bug.syz"
+	// syzBinDir 源码树中存放 syzkaller 程序的目录
+	syzBinDir = "syz"
+
+	defaultSyzkallerGit = "https://github.com/google/syzkaller"
+	syzkallerRepo       = "repo/syzkaller.git"
+	syzkallerBuilds     = "repo/syzkaller"
+)
+
+// syzkaller 程序在客户机 /root 中的名称
+var syzBinaries = []string{"syz-execprog", "syz-executor"}
+
+// HasSyzReproducer 报告是否有 syz 复现程序
+func HasSyzReproducer(report *parse.CrashReport) bool {
+	return report.Crashes[0].SyzReproducer != ""
+}
+
+// SyzReady 源码树中是否有 syz 复现程序及编译好的 syzkaller 程序，没有时运行 c 复现程序
+func SyzReady(report *parse.CrashReport) bool {
+	dir := kernelPath(report)
+	return fileExists(filepath.Join(dir, SyzReproducerFile)) && syzkallerBuilt(filepath.Join(dir, syzBinDir))
+}
+
+// DropSyzReproducer 删除源码树中的 syz 复现程序，退回到 c 复现程序
+func DropSyzReproducer(report *parse.CrashReport) error {
+	for _, name := range []string{SyzReproducerFile, syzBinDir} {
+		if err := os.RemoveAll(filepath.Join(kernelPath(report), name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BuildSyzkaller 编译报告的 syzkaller 提交中的 syz-execprog 与 syz-executor 并复制到源码树
+func BuildSyzkaller(report *parse.CrashReport) error {
+	crash := report.Crashes[0]
+	commit := crash.SyzkallerCommit
+	if commit == "" {
+		return errors.New("report has no syzkaller commit")
+	}
+
+	binDir := filepath.Join(syzkallerBuilds, commit, "bin", "linux_"+GlobalArch.Name)
+	if !syzkallerBuilt(binDir) {
+		if err := fetchSyzkaller(syzkallerSource(crash), commit); err != nil {
+			return err
+		}
+		if err := makeSyzkaller(commit, GlobalArch.Name); err != nil {
+			return err
+		}
+	} else {
+		log.Infoln("syzkaller", commit, "already built in", binDir)
+	}
+
+	dst := filepath.Join(kernelPath(report), syzBinDir)
+	if err := os.MkdirAll(dst, 0755); err != nil {
+		return err
+	}
+	for _, name := range syzBinaries {
+		if err := copyExecutable(filepath.Join(binDir, name), filepath.Join(dst, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// syzkallerSource 克隆与拉取 syzkaller 的来源：配置的本地镜像优先于报告中的仓库
+func syzkallerSource(crash parse.Crash) string {
+	switch {
+	case config.GlobalConfig.Syzkaller.Mirror != "":
+		return config.GlobalConfig.Syzkaller.Mirror
+	case crash.SyzkallerGit != "":
+		return crash.SyzkallerGit
+	default:
+		return defaultSyzkallerGit
+	}
+}
+
+func syzkallerBuilt(binDir string) bool {
+	for _, name := range syzBinaries {
+		if !fileExists(filepath.Join(binDir, name)) {
+			return false
+		}
+	}
+	return true
+}
+
+func syzkallerGit(args ...string) error {
+	cmd := exec.Command("git", append([]string{"--git-dir", syzkallerRepo}, args...)...)
+	cmd.Stdout = progress.Stdout
+	cmd.Stderr = progress.Stdout
+	return cmd.Run()
+}
+
+// fetchSyzkaller 第一次使用时克隆 syzkaller，缓存中没有 commit 时再从 source 拉取
+func fetchSyzkaller(source, commit string) error {
+	if !fileExists(syzkallerRepo) {
+		log.Infoln("cloning syzkaller from", source, "into", syzkallerRepo)
+		cmd := exec.Command("git", "clone", "--bare", source, syzkallerRepo)
+		cmd.Stdout = progress.Stdout
+		cmd.Stderr = progress.Stdout
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("failed to clone %s: %v", source, err)
+		}
+	}
+
+	if syzkallerGit("cat-file", "-e", commit+"^{commit}") == nil {
+		return nil
+	}
+	log.Infoln("syzkaller commit", commit, "not in", syzkallerRepo, "fetching from", source)
+	if err := syzkallerGit("fetch", source, "+refs/heads/*:refs/heads/*"); err != nil {
+		return fmt.Errorf("failed to fetch %s: %v", source, err)
+	}
+	if syzkallerGit("cat-file", "-e", commit+"^{commit}") != nil {
+		return fmt.Errorf("syzkaller commit %s not found in %s", commit, source)
+	}
+	return nil
+}
+
+// makeSyzkaller 导出 commit 的源码并为 targetArch 编译；源码目录不是 git 仓库，REV 由参数给出。
+// 同一提交的各架构共用导出的源码，程序在 bin/linux_<arch> 中
+func makeSyzkaller(commit, targetArch string) error {
+	dir := filepath.Join(syzkallerBuilds, commit)
+	if !fileExists(filepath.Join(dir, "Makefile")) {
+		if err := exportSyzkaller(commit, dir); err != nil {
+			return err
+		}
+	}
+
+	log.Infoln("building syz-execprog and syz-executor for", targetArch, "at syzkaller", commit)
+	cmd := exec.Command("make", "execprog", "executor", "REV="+commit, "TARGETOS=linux", "TARGETARCH="+targetArch)
+	cmd.Dir = dir
+	cmd.Stdout = progress.Stdout
+	cmd.Stderr = progress.Stdout
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to build syzkaller %s: %v", commit, err)
+	}
+	return nil
+}
+
+func exportSyzkaller(commit, dir string) error {
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	archive := dir + ".tar"
+	defer os.Remove(archive)
+	if err := syzkallerGit("archive", "--format=tar", "-o", archive, commit); err != nil {
+		return fmt.Errorf("failed to export syzkaller %s: %v", commit, err)
+	}
+	untar := exec.Command("tar", "-xf", archive, "-C", dir)
+	untar.Stdout = progress.Stdout
+	untar.Stderr = progress.Stdout
+	if err := untar.Run(); err != nil {
+		return fmt.Errorf("failed to extract syzkaller %s: %v", commit, err)
+	}
+	return nil
+}
+
+func copyExecutable(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0755)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// SyzOptions 复现程序开头注释中记录的运行选项，新版 syzkaller 为 json，旧版为 Go 结构体的格式
+type SyzOptions struct {
+	Threaded    bool
+	Repeat      bool
+	RepeatTimes int
+	Procs       int
+	Slowdown    int
+	Sandbox     string
+	Fault       bool
+	FaultCall   int
+	FaultNth    int
+}
+
+// ParseSyzOptions 解析复现程序的运行选项，没有选项注释时使用 syzkaller 的缺省值。
+// json 格式省略值为 false 的 threaded 与 repeat，有选项注释时二者缺省为 false
+func ParseSyzOptions(data []byte) SyzOptions {
+	opts := SyzOptions{Threaded: true, Repeat: true, Procs: 1, Slowdown: 1, Sandbox: "none"}
+	for _, line := range strings.Split(string(data), "\n") {
+		if !strings.HasPrefix(line, "#") {
+			break
+		}
+		header := strings.TrimSpace(strings.TrimPrefix(line, "#"))
+		if !strings.HasPrefix(header, "{") {
+			continue
+		}
+		opts.Threaded, opts.Repeat = false, false
+		for key, value := range syzOptionFields(header) {
+			opts.set(key, value)
+		}
+		break
+	}
+	return opts
+}
+
+// syzOptionFields 把选项注释拆成小写、不含下划线的键与字符串值
+func syzOptionFields(header string) map[string]string {
+	fields := make(map[string]string)
+	var values map[string]any
+	if err := json.Unmarshal([]byte(header), &values); err == nil {
+		for key, value := range values {
+			fields[normalizeSyzOption(key)] = fmt.Sprint(value)
+		}
+		return fields
+	}
+	// 旧格式：{Threaded:true Repeat:true Procs:1 Sandbox:none ... LegacyOptions:{Fault:true FaultCall:3 FaultNth:5}}，
+	// 嵌套的 LegacyOptions 展开到同一层
+	for _, field := range strings.Fields(header) {
+		key, value, ok := strings.Cut(strings.Trim(field, "{}"), ":")
+		if !ok {
+			continue
+		}
+		if value = strings.Trim(value, "{}"); value != "" {
+			fields[normalizeSyzOption(key)] = value
+		}
+	}
+	return fields
+}
+
+func normalizeSyzOption(key string) string {
+	return strings.ToLower(strings.ReplaceAll(key, "_", ""))
+}
+
+func (o *SyzOptions) set(key, value string) {
+	b, _ := strconv.ParseBool(value)
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		n = 0
+	}
+	switch key {
+	case "threaded":
+		o.Threaded = b
+	case "repeat":
+		o.Repeat = b
+	case "repeattimes":
+		o.RepeatTimes = int(n)
+	case "procs":
+		o.Procs = max(int(n), 1)
+	case "slowdown":
+		o.Slowdown = max(int(n), 1)
+	case "sandbox":
+		o.Sandbox = value
+	case "fault":
+		o.Fault = b
+	case "faultcall":
+		o.FaultCall = int(n)
+	case "faultnth":
+		o.FaultNth = int(n)
+	}
+}
+
+// Args syz-execprog 的参数，repeat 为 0 表示一直运行到超时
+func (o SyzOptions) Args() []string {
+	repeat := 1
+	if o.Repeat {
+		repeat = o.RepeatTimes
+	}
+	sandbox := o.Sandbox
+	if sandbox == "" {
+		sandbox = "none"
+	}
+	args := []string{
+		"-executor=/root/syz-executor",
+		fmt.Sprintf("-repeat=%d", repeat),
+		fmt.Sprintf("-procs=%d", o.Procs),
+		fmt.Sprintf("-threaded=%t", o.Threaded),
+		"-sandbox=" + sandbox,
+		"-cover=0",
+	}
+	// 旧版 syz-execprog 没有 -slowdown，只在选项中出现时传入
+	if o.Slowdown > 1 {
+		args = append(args, fmt.Sprintf("-slowdown=%d", o.Slowdown))
+	}
+	if o.Fault {
+		args = append(args, fmt.Sprintf("-fault_call=%d", o.FaultCall), fmt.Sprintf("-fault_nth=%d", o.FaultNth))
+	}
+	return args
+}
+
+// SyzCommand 在客户机中运行 syz 复现程序的命令
+func SyzCommand(report *parse.CrashReport) (string, error) {
+	data, err := os.ReadFile(filepath.Join(kernelPath(report), SyzReproducerFile))
+	if err != nil {
+		return "", err
+	}
+	args := ParseSyzOptions(data).Args()
+	return fmt.Sprintf("./syz-execprog %s %s", strings.Join(args, " "), SyzReproducerFile), nil
+}
@@ -0,0 +1,60 @@
+package compile
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseSyzOptions(t *testing.T) {
+	tests := []struct {
+		file string
+		want SyzOptions
+		args []string
+	}{
+		{
+			file: "json-options.syz",
+			want: SyzOptions{Threaded: true, Repeat: true, Procs: 6, Slowdown: 1, Sandbox: "none"},
+			args: []string{"-executor=/root/syz-executor", "-repeat=0", "-procs=6", "-threaded=true", "-sandbox=none", "-cover=0"},
+		},
+		{
+			// threaded 为 false 时 json 中没有这个键
+			file: "slowdown.syz",
+			want: SyzOptions{Repeat: true, RepeatTimes: 20, Procs: 2, Slowdown: 10},
+			args: []string{"-executor=/root/syz-executor", "-repeat=20", "-procs=2", "-threaded=false", "-sandbox=none", "-cover=0", "-slowdown=10"},
+		},
+		{
+			// 故障注入选项在嵌套的 LegacyOptions 中
+			file: "legacy-fault.syz",
+			want: SyzOptions{Threaded: true, Procs: 1, Slowdown: 1, Sandbox: "none", Fault: true, FaultCall: 3, FaultNth: 5},
+			args: []string{"-executor=/root/syz-executor", "-repeat=1", "-procs=1", "-threaded=true", "-sandbox=none", "-cover=0", "-fault_call=3", "-fault_nth=5"},
+		},
+		{
+			file: "old-struct.syz",
+			want: SyzOptions{Threaded: true, Repeat: true, Procs: 8, Slowdown: 1, Sandbox: "namespace", FaultCall: -1},
+			args: []string{"-executor=/root/syz-executor", "-repeat=0", "-procs=8", "-threaded=true", "-sandbox=namespace", "-cover=0"},
+		},
+		{
+			file: "no-options.syz",
+			want: SyzOptions{Threaded: true, Repeat: true, Procs: 1, Slowdown: 1, Sandbox: "none"},
+			args: []string{"-executor=/root/syz-executor", "-repeat=0", "-procs=1", "-threaded=true", "-sandbox=none", "-cover=0"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", tt.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			opts := ParseSyzOptions(data)
+			if opts != tt.want {
+				t.Errorf("options %+v, want %+v", opts, tt.want)
+			}
+			if args := opts.Args(); !reflect.DeepEqual(args, tt.args) {
+				t.Errorf("args %q, want %q", args, tt.args)
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ package compress
 
 import (
 	"backend/pkg/parse"
+	"backend/pkg/progress"
 	"fmt"
 	"os"
 	"os/exec"
@@ -41,7 +42,7 @@ func Compress(report *parse.CrashReport) error {
 		kernelBase,
 	)
 	cmd.Dir = kernelDir
-	cmd.Stdout = os.Stdout
+	cmd.Stdout = progress.Stdout
 	cmd.Stderr = os.Stderr
 
 	if err := cmd.Run(); err != nil {
@@ -56,3 +57,19 @@ func Compress(report *parse.CrashReport) error {
 
 	return nil
 }
+
+// PatchResult 打包补丁目录（补丁、git apply 输出与增量编译日志），作为 patch-apply 任务的产物
+func PatchResult(report *parse.CrashReport, patchDir string) error {
+	commit := report.Crashes[0].KernelSourceCommit
+	outputPath := filepath.Join(filepath.Dir(patchDir), fmt.Sprintf("patch-%s.tar.gz", commit))
+
+	cmd := exec.Command("tar", "-czf", outputPath, "-C", filepath.Dir(patchDir), filepath.Base(patchDir))
+	cmd.Stdout = progress.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("tar patch result failed: %w", err)
+	}
+
+	log.Infof("patch result packed into %s", outputPath)
+	return nil
+}
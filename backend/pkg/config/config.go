@@ -7,12 +7,27 @@ import (
 )
 
 type Config struct {
-	Port string   `json:"port"` // proxy port
-	VM   VMConfig `json:"vm"`
+	Port      string          `json:"port"` // proxy port
+	VM        VMConfig        `json:"vm"`
+	Repro     ReproConfig     `json:"repro"`
+	Syzkaller SyzkallerConfig `json:"syzkaller"`
 }
 
 type VMConfig struct {
 	Memory string `json:"memory"` // memory size in MB
+	Slots  int    `json:"slots"`  // 同一台机器上同时运行的虚拟机数，缺省为 4
+}
+
+// ReproConfig 复现程序的运行方式，缺省时运行一次、每次 60 秒
+type ReproConfig struct {
+	Runs       int  `json:"runs"`        // 运行次数，每次重新启动虚拟机
+	RunTimeout int  `json:"run_timeout"` // 每次运行的秒数，客户机崩溃后重启时提前结束
+	Snapshot   bool `json:"snapshot"`    // 每次运行从 debian.img 上新建的 qcow2 覆盖层启动，丢弃上一次运行的写入
+}
+
+// SyzkallerConfig 编译 syz 复现程序所需 syzkaller 的来源，缺省时从漏洞报告的 syzkaller-git 克隆
+type SyzkallerConfig struct {
+	Mirror string `json:"mirror"` // 本地镜像的路径或地址，离线时从这里克隆与拉取
 }
 
 var GlobalConfig Config
@@ -30,5 +45,15 @@ func Load(file string) error {
 		return fmt.Errorf("failed to parse config file %s: %v", file, err)
 	}
 
+	if GlobalConfig.VM.Slots <= 0 {
+		GlobalConfig.VM.Slots = 4
+	}
+	if GlobalConfig.Repro.Runs <= 0 {
+		GlobalConfig.Repro.Runs = 1
+	}
+	if GlobalConfig.Repro.RunTimeout <= 0 {
+		GlobalConfig.Repro.RunTimeout = 60
+	}
+
 	return nil
 }
@@ -1,8 +1,12 @@
 package kvm
 
 import (
+	"backend/pkg/arch"
 	"backend/pkg/parse"
+	"backend/pkg/progress"
+	"backend/pkg/result"
 	"bytes"
+	"fmt"
 	"io"
 	"os"
 	"os/exec"
@@ -11,7 +15,8 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
-func ConfigImage(report *parse.CrashReport) error {
+// ConfigImage 把客户机镜像与启动镜像复制到槽位的虚拟机工作目录，并放入头文件与复现程序
+func ConfigImage(report *parse.CrashReport, slot *Slot) error {
 	workDir, err := os.Getwd()
 	if err != nil {
 		return err
@@ -19,7 +24,7 @@ func ConfigImage(report *parse.CrashReport) error {
 	scriptDir := filepath.Join(workDir, "script")
 
 	logger := log.New()
-	logger.SetOutput(os.Stdout)
+	logger.SetOutput(progress.Stdout)
 	logger.SetFormatter(&log.TextFormatter{
 		FullTimestamp: true,
 	})
@@ -27,8 +32,13 @@ func ConfigImage(report *parse.CrashReport) error {
 	stdout := &bytes.Buffer{}
 	stderr := &bytes.Buffer{}
 
+	a, err := arch.Of(report)
+	if err != nil {
+		return err
+	}
+
 	commitID := report.Crashes[0].KernelSourceCommit
-	imageCmd := exec.Command(filepath.Join(scriptDir, "mount.sh"), commitID)
+	imageCmd := exec.Command(filepath.Join(scriptDir, "mount.sh"), commitID, a.BootImage, a.GuestImage, slot.Dir)
 	imageCmd.Stdout = io.MultiWriter(stdout, logger.Writer())
 	imageCmd.Stderr = io.MultiWriter(stderr, logger.Writer())
 	if err := imageCmd.Run(); err != nil {
@@ -38,7 +48,8 @@ func ConfigImage(report *parse.CrashReport) error {
 	return nil
 }
 
-func ClearImage(report *parse.CrashReport) error {
+// ClearImage 删除虚拟机工作目录：slot 为 nil 时为旧的 work/<commit>，否则为槽位的工作目录
+func ClearImage(report *parse.CrashReport, slot *Slot) error {
 	workDir, err := os.Getwd()
 	if err != nil {
 		return err
@@ -46,7 +57,7 @@ func ClearImage(report *parse.CrashReport) error {
 	scriptDir := filepath.Join(workDir, "script")
 
 	logger := log.New()
-	logger.SetOutput(os.Stdout)
+	logger.SetOutput(progress.Stdout)
 	logger.SetFormatter(&log.TextFormatter{
 		FullTimestamp: true,
 	})
@@ -55,7 +66,11 @@ func ClearImage(report *parse.CrashReport) error {
 	stderr := &bytes.Buffer{}
 
 	commitID := report.Crashes[0].KernelSourceCommit
-	clearCmd := exec.Command(filepath.Join(scriptDir, "clear.sh"), commitID)
+	args := []string{commitID}
+	if slot != nil {
+		args = append(args, slot.Dir)
+	}
+	clearCmd := exec.Command(filepath.Join(scriptDir, "clear.sh"), args...)
 	clearCmd.Stdout = io.MultiWriter(stdout, logger.Writer())
 	clearCmd.Stderr = io.MultiWriter(stderr, logger.Writer())
 	if err := clearCmd.Run(); err != nil {
@@ -65,7 +80,9 @@ func ClearImage(report *parse.CrashReport) error {
 	return nil
 }
 
-func GetVmcore(report *parse.CrashReport) error {
+// GetVmcore 把槽位磁盘镜像中的 vmcore 与串口日志移动到内核构建目录，并记录到结果文件。
+// 之前执行留下的 vmcore 与串口日志先被删除，get.sh 没有找到时构建目录中不会残留旧文件
+func GetVmcore(report *parse.CrashReport, slot *Slot) error {
 	workDir, err := os.Getwd()
 	if err != nil {
 		return err
@@ -73,7 +90,7 @@ func GetVmcore(report *parse.CrashReport) error {
 	scriptDir := filepath.Join(workDir, "script")
 
 	logger := log.New()
-	logger.SetOutput(os.Stdout)
+	logger.SetOutput(progress.Stdout)
 	logger.SetFormatter(&log.TextFormatter{
 		FullTimestamp: true,
 	})
@@ -82,12 +99,43 @@ func GetVmcore(report *parse.CrashReport) error {
 	stderr := &bytes.Buffer{}
 
 	commitID := report.Crashes[0].KernelSourceCommit
-	getCmd := exec.Command(filepath.Join(scriptDir, "get.sh"), commitID)
+	vmcore := filepath.Join(buildTree(commitID), "vmcore")
+	console := filepath.Join(buildTree(commitID), commitID+".log")
+	for _, path := range []string{vmcore, console} {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	args := []string{commitID, slot.Dir}
+	// 从覆盖层启动时 vmcore 只在覆盖层中，合并后交给 get.sh 挂载
+	if slot.overlay {
+		image, err := flattenOverlay(slot)
+		if err != nil {
+			return err
+		}
+		defer os.Remove(image)
+		args = append(args, image)
+	}
+
+	getCmd := exec.Command(filepath.Join(scriptDir, "get.sh"), args...)
 	getCmd.Stdout = io.MultiWriter(stdout, logger.Writer())
 	getCmd.Stderr = io.MultiWriter(stderr, logger.Writer())
 	if err := getCmd.Run(); err != nil {
 		return err
 	}
 
+	// get.sh 没有找到 vmcore 时同样以 0 退出
+	if _, err := os.Stat(vmcore); err == nil {
+		result.SetVmcore(commitID, vmcore)
+	}
+	if _, err := os.Stat(console); err == nil {
+		result.SetConsole(commitID, console)
+	}
 	return nil
 }
+
+// buildTree 内核构建目录，get.sh 把 vmcore 与串口日志移动到这里
+func buildTree(commit string) string {
+	return fmt.Sprintf("build/%s/linux-%s", commit, commit)
+}
@@ -1,33 +1,54 @@
 package kvm
 
 import (
-	"bufio"
+	"backend/pkg/result"
+	"errors"
 	"fmt"
-	"io"
-	"math/rand"
 	"net"
+	"os"
 	"os/exec"
 	"strings"
-	"sync"
+	"syscall"
 	"time"
 
 	log "github.com/sirupsen/logrus"
 )
 
+// 虚拟机的加速方式
+const (
+	AccelKVM = "kvm"
+	AccelTCG = "tcg"
+)
+
+// TCGSlowdown TCG 模拟运行相对 KVM 的减速倍数，用于放宽启动与复现的等待时间
+const TCGSlowdown = 5
+
 type QEMUManager struct {
-	cmd         *exec.Cmd
-	monitorConn net.Conn
-	SSH         *SSHManager
-	vmConfig    VMConfig
+	cmd      *exec.Cmd
+	exited   chan struct{} // 虚拟机进程退出时关闭；-no-reboot 下客户机重启（如 kdump 保存 vmcore 后）也会退出
+	qmp      *QMPClient
+	events   <-chan Event
+	SSH      *SSHManager
+	vmConfig VMConfig
+	accel    string
 }
 
 type VMConfig struct {
 	ImagePath    string
+	ImageFormat  string // 磁盘镜像格式，缺省为 raw
 	KernelPath   string
 	Memory       string
-	MonitorPort  int
+	MonitorPort  int // QMP 端口
+	SSHPort      int // 转发到客户机 22 端口的宿主机端口
 	KernelAppend string
 	LogFile      string
+	QEMU         string   // qemu 可执行程序，缺省为 qemu-system-x86_64
+	Machine      []string // 机器类型参数
+	KVM          bool     // 客户机与宿主机架构相同，/dev/kvm 可用时使用 KVM
+	KVMCPU       string   // KVM 下的 CPU 型号，缺省为 host,-x2apic
+	TCGCPU       string   // TCG 下的 CPU 型号，缺省为 max
+	Drive        string   // 磁盘接口，缺省为 ide
+	PanicDevice  string   // 客户机 panic 时发出 GUEST_PANICKED 事件的 pvpanic 设备
 }
 
 func NewQEMUManager(config VMConfig) *QEMUManager {
@@ -36,142 +57,210 @@ func NewQEMUManager(config VMConfig) *QEMUManager {
 	}
 }
 
+// KVMAvailable /dev/kvm 是否存在且可读写；CI 与嵌套虚拟机中通常没有
+func KVMAvailable() bool {
+	f, err := os.OpenFile("/dev/kvm", os.O_RDWR, 0)
+	if err != nil {
+		return false
+	}
+	f.Close()
+	return true
+}
+
+// accelArgs 选择加速方式：KVM 不可用时退回到 TCG
+func (qm *QEMUManager) accelArgs() []string {
+	if qm.vmConfig.KVM && KVMAvailable() {
+		qm.accel = AccelKVM
+		cpu := qm.vmConfig.KVMCPU
+		if cpu == "" {
+			cpu = "host,-x2apic"
+		}
+		return []string{"-enable-kvm", "-cpu", cpu}
+	}
+	if qm.vmConfig.KVM {
+		log.Warnln("/dev/kvm is not available, falling back to TCG")
+	}
+	qm.accel = AccelTCG
+	cpu := qm.vmConfig.TCGCPU
+	if cpu == "" {
+		cpu = "max"
+	}
+	return []string{"-accel", "tcg,thread=multi", "-cpu", cpu}
+}
+
+// Accel 虚拟机使用的加速方式，StartVM 之后有效
+func (qm *QEMUManager) Accel() string {
+	return qm.accel
+}
+
+// Slowdown 等待客户机启动与复现的时间倍数
+func (qm *QEMUManager) Slowdown() int {
+	if qm.accel == AccelTCG {
+		return TCGSlowdown
+	}
+	return 1
+}
+
 func (qm *QEMUManager) StartVM() error {
+	qemu, drive, format := qm.vmConfig.QEMU, qm.vmConfig.Drive, qm.vmConfig.ImageFormat
+	if qemu == "" {
+		qemu = "qemu-system-x86_64"
+	}
+	if drive == "" {
+		drive = "ide"
+	}
+	if format == "" {
+		format = "raw"
+	}
+
 	args := []string{
 		"-m", qm.vmConfig.Memory,
 		"-kernel", qm.vmConfig.KernelPath,
-		"-drive", fmt.Sprintf("file=%s,format=raw,if=ide", qm.vmConfig.ImagePath),
+		"-drive", fmt.Sprintf("file=%s,format=%s,if=%s", qm.vmConfig.ImagePath, format, drive),
 		"-append", qm.vmConfig.KernelAppend,
 		"-device", "virtio-net,netdev=net0",
-		"-netdev", "user,id=net0,hostfwd=tcp::2222-:22",
+		"-netdev", fmt.Sprintf("user,id=net0,hostfwd=tcp:127.0.0.1:%d-:22", qm.vmConfig.SSHPort),
 		"-serial", fmt.Sprintf("file:%s", qm.vmConfig.LogFile),
 	}
 	args = append(args, "-nographic")
-	args = append(args, "-enable-kvm")
-	args = append(args, "-cpu", "host,-x2apic")
+	args = append(args, qm.vmConfig.Machine...)
+	args = append(args, qm.accelArgs()...)
+	if qm.vmConfig.PanicDevice != "" {
+		args = append(args, "-device", qm.vmConfig.PanicDevice)
+	}
 	args = append(args, "-no-reboot")
-	args = append(args, "-monitor", fmt.Sprintf("tcp:127.0.0.1:%d,server,wait", qm.vmConfig.MonitorPort))
+	// QEMU 等待 QMP 连接后才开始运行客户机，不会错过启动后的事件
+	args = append(args, "-qmp", fmt.Sprintf("tcp:127.0.0.1:%d,server=on,wait=on", qm.vmConfig.MonitorPort))
 
-	qm.cmd = exec.Command("qemu-system-x86_64", args...)
+	qm.cmd = exec.Command(qemu, args...)
+	// kernel-builder 被结束时 QEMU 随之退出，不会继续占用槽位的端口
+	qm.cmd.SysProcAttr = &syscall.SysProcAttr{Pdeathsig: syscall.SIGKILL}
 
+	log.Infoln("qemu accelerator:", qm.accel)
+	result.SetAccel(qm.accel)
 	log.Infof("start qemu vm, command as follow:\n%s\n", strings.Join(args, " "))
 	if err := qm.cmd.Start(); err != nil {
 		return err
 	}
-	time.Sleep(time.Second * 10) // waiting for qemu to start. just a workaround, should be replaced with a better way
+	qm.exited = make(chan struct{})
+	go func() {
+		if err := qm.cmd.Wait(); err != nil {
+			log.Infoln("qemu exited:", err)
+		}
+		close(qm.exited)
+	}()
 
-	if err := qm.connectMonitor(); err != nil {
-		return fmt.Errorf("fail to connect monitor port: %v", err)
+	if err := qm.connectQMP(); err != nil {
+		return fmt.Errorf("fail to connect qmp port: %v", err)
 	}
 
 	log.Infoln("start qemu vm successfully, pid =", qm.cmd.Process.Pid)
 	return nil
 }
 
-func (qm *QEMUManager) connectMonitor() error {
-	var err error
-
-	r := rand.New(rand.NewSource(time.Now().UnixNano()))
-
-	for i := range 10 {
-		qm.monitorConn, err = net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", qm.vmConfig.MonitorPort))
+// connectQMP 等待 QEMU 打开 QMP 端口并连接，订阅客户机关机、重启、panic 与暂停事件
+func (qm *QEMUManager) connectQMP() error {
+	address := fmt.Sprintf("127.0.0.1:%d", qm.vmConfig.MonitorPort)
+	deadline := time.Now().Add(time.Minute)
+	for {
+		client, err := DialQMP(address)
 		if err == nil {
-			break
+			qm.qmp = client
+			qm.events = client.Subscribe(EventShutdown, EventReset, EventGuestPanicked, EventStop)
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return err
+		}
+		select {
+		case <-qm.exited:
+			return errors.New("qemu exited before qmp was available")
+		case <-time.After(200 * time.Millisecond):
 		}
-
-		backoff := min(1<<i, 30)
-		jitter := time.Duration(r.Intn(backoff)+1) * time.Second
-		log.Infof("sleep %s before retrying to connect monitor port: %v\n", jitter, err)
-		time.Sleep(jitter)
 	}
+}
 
-	if err != nil {
-		return fmt.Errorf("cannot connect monitor port: %v", err)
+// ExecuteMonitorCommand 通过 QMP 执行人类监视器命令
+func (qm *QEMUManager) ExecuteMonitorCommand(command string) (string, error) {
+	if qm.qmp == nil {
+		return "", fmt.Errorf("qmp connection not established")
 	}
-
-	reader := bufio.NewReader(qm.monitorConn)
-	_, err = reader.ReadString('\n')
-	return err
+	return qm.qmp.HumanMonitorCommand(command)
 }
 
-var monitorMutex sync.Mutex
+func (qm *QEMUManager) ConnectVM(config SSHConfig) error {
+	sshManager := NewSSHManager(config)
+	err := sshManager.Connect()
+	qm.SSH = sshManager
 
-func drainMonitor(conn net.Conn) {
-	conn.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
-	io.Copy(io.Discard, conn)
-	conn.SetReadDeadline(time.Time{})
+	return err
 }
 
-// ExecuteMonitorCommand !TODO debug this one to display better output
-func (qm *QEMUManager) ExecuteMonitorCommand(command string) (string, error) {
-	if qm.monitorConn == nil {
-		return "", fmt.Errorf("monitor connection not established")
+func (qm *QEMUManager) GetVMStatus() (string, error) {
+	if qm.qmp == nil {
+		return "", fmt.Errorf("qmp connection not established")
 	}
-
-	monitorMutex.Lock()
-	defer monitorMutex.Unlock()
-
-	drainMonitor(qm.monitorConn)
-
-	_, err := fmt.Fprintf(qm.monitorConn, "%s\n", command)
+	status, err := qm.qmp.QueryStatus()
 	if err != nil {
 		return "", err
 	}
+	return fmt.Sprintf("VM status: %s", status.Status), nil
+}
 
-	reader := bufio.NewReader(qm.monitorConn)
-	var response strings.Builder
-
-	for {
-		qm.monitorConn.SetReadDeadline(time.Now().Add(3 * time.Second))
-		line, err := reader.ReadString('\n')
-		if err != nil {
-			if ne, ok := err.(net.Error); ok && ne.Timeout() {
-				break
-			}
-			return "", fmt.Errorf("failed reading response: %w", err)
-		}
-
-		// fmt.Print(line)
-
-		response.WriteString(line)
-
-		if strings.HasPrefix(line, "(qemu)") {
-			break
-		}
+func (qm *QEMUManager) PauseVM() error {
+	if qm.qmp == nil {
+		return fmt.Errorf("qmp connection not established")
 	}
-
-	return response.String(), nil
+	return qm.qmp.Stop()
 }
 
-func (qm *QEMUManager) ConnectVM(config SSHConfig) error {
-	sshManager := NewSSHManager(config)
-	err := sshManager.Connect()
-	qm.SSH = sshManager
-
-	return err
+func (qm *QEMUManager) ResumeVM() error {
+	if qm.qmp == nil {
+		return fmt.Errorf("qmp connection not established")
+	}
+	return qm.qmp.Cont()
 }
 
-func (qm *QEMUManager) GetVMStatus() (string, error) {
-	return qm.ExecuteMonitorCommand("info status")
+// DumpGuestMemory 把客户机内存以 ELF 格式写入宿主机的 path，用于 kdump 未能接管时保留现场
+func (qm *QEMUManager) DumpGuestMemory(path string) error {
+	if qm.qmp == nil {
+		return fmt.Errorf("qmp connection not established")
+	}
+	return qm.qmp.DumpGuestMemory(path, 10*time.Minute)
 }
 
-func (qm *QEMUManager) PauseVM() (string, error) {
-	return qm.ExecuteMonitorCommand("stop")
+// Exited 虚拟机进程退出时关闭的 channel
+func (qm *QEMUManager) Exited() <-chan struct{} {
+	return qm.exited
 }
 
-func (qm *QEMUManager) ResumeVM() (string, error) {
-	return qm.ExecuteMonitorCommand("cont")
+// Events 客户机的 SHUTDOWN、RESET、GUEST_PANICKED 与 STOP 事件
+func (qm *QEMUManager) Events() <-chan Event {
+	return qm.events
 }
 
 func (qm *QEMUManager) ShutdownVM() error {
 	log.Infoln("shutdown qemu vm...")
 
-	if qm.monitorConn != nil {
-		_, err := qm.ExecuteMonitorCommand("system_powerdown")
-		if err != nil {
+	running := qm.exited != nil
+	if running {
+		select {
+		case <-qm.exited:
+			running = false
+		default:
+		}
+	}
+
+	// 暂停的客户机（panic 后或被 stop）不会响应关机请求，直接结束 QEMU
+	if running && qm.qmp != nil {
+		if status, err := qm.qmp.QueryStatus(); err == nil && !status.Running {
+			log.Infoln("vm is", status.Status, "- quitting qemu")
+			if err := qm.qmp.Quit(); err != nil {
+				log.Errorln(err)
+			}
+		} else if err := qm.qmp.SystemPowerdown(); err != nil {
 			log.Errorln(err)
 		}
-		time.Sleep(10 * time.Second)
 	}
 
 	if qm.SSH != nil {
@@ -181,17 +270,22 @@ func (qm *QEMUManager) ShutdownVM() error {
 		}
 	}
 
-	if qm.monitorConn != nil {
-		err := qm.monitorConn.Close()
-		if err != nil {
-			return err
+	// 等待客户机响应关机请求后 QEMU 退出，超时则强制结束
+	if qm.exited != nil {
+		select {
+		case <-qm.exited:
+		case <-time.After(30 * time.Second):
+			log.Warnln("qemu did not power down, killing it")
+			if err := qm.cmd.Process.Kill(); err != nil {
+				return err
+			}
+			<-qm.exited
 		}
 	}
 
-	if qm.cmd != nil && qm.cmd.Process != nil {
-		_, err := qm.cmd.Process.Wait()
-		if err != nil {
-			return err
+	if qm.qmp != nil {
+		if err := qm.qmp.Close(); err != nil && !errors.Is(err, net.ErrClosed) {
+			log.Errorln(err)
 		}
 	}
 
@@ -0,0 +1,264 @@
+package kvm
+
+// QMP（QEMU Machine Protocol）客户端：连接后完成 qmp_capabilities 协商，命令串行执行并按 id 取得结果，
+// 客户机事件分发给订阅者。读取连接的 goroutine 在 QEMU 退出、连接关闭时结束
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// 订阅的客户机事件
+const (
+	EventShutdown      = "SHUTDOWN"
+	EventReset         = "RESET"
+	EventGuestPanicked = "GUEST_PANICKED"
+	EventStop          = "STOP"
+)
+
+// qmpTimeout 普通命令等待结果的时间，dump-guest-memory 另行指定
+const qmpTimeout = 30 * time.Second
+
+var errQMPClosed = errors.New("qmp connection closed")
+
+// Event QEMU 发出的异步事件
+type Event struct {
+	Name      string
+	Data      json.RawMessage
+	Timestamp time.Time
+}
+
+// VMStatus query-status 的结果
+type VMStatus struct {
+	Running bool   `json:"running"`
+	Status  string `json:"status"`
+}
+
+type qmpError struct {
+	Class string `json:"class"`
+	Desc  string `json:"desc"`
+}
+
+type qmpMessage struct {
+	QMP       json.RawMessage `json:"QMP"`
+	Return    json.RawMessage `json:"return"`
+	Error     *qmpError       `json:"error"`
+	ID        string          `json:"id"`
+	Event     string          `json:"event"`
+	Data      json.RawMessage `json:"data"`
+	Timestamp struct {
+		Seconds      int64 `json:"seconds"`
+		Microseconds int64 `json:"microseconds"`
+	} `json:"timestamp"`
+}
+
+type qmpCommand struct {
+	Execute   string `json:"execute"`
+	Arguments any    `json:"arguments,omitempty"`
+	ID        string `json:"id"`
+}
+
+type subscription struct {
+	names []string
+	ch    chan Event
+}
+
+type QMPClient struct {
+	conn      net.Conn
+	timeout   time.Duration // 问候消息与普通命令等待的时间
+	mu        sync.Mutex    // 串行执行命令
+	nextID    int
+	responses chan qmpMessage
+	done      chan struct{} // 读取连接的 goroutine 结束时关闭
+
+	subsMu sync.Mutex
+	subs   []subscription
+}
+
+// DialQMP 连接 QMP 端口并完成能力协商
+func DialQMP(address string) (*QMPClient, error) {
+	conn, err := net.DialTimeout("tcp", address, 5*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	return newQMPClient(conn, qmpTimeout)
+}
+
+// newQMPClient 在建立的连接上读取问候消息并完成能力协商
+func newQMPClient(conn net.Conn, timeout time.Duration) (*QMPClient, error) {
+	c := &QMPClient{
+		conn:      conn,
+		timeout:   timeout,
+		responses: make(chan qmpMessage, 1),
+		done:      make(chan struct{}),
+	}
+
+	// 连接后 QEMU 先发送问候消息
+	dec := json.NewDecoder(conn)
+	var greeting qmpMessage
+	conn.SetReadDeadline(time.Now().Add(timeout))
+	if err := dec.Decode(&greeting); err != nil || greeting.QMP == nil {
+		conn.Close()
+		return nil, fmt.Errorf("no qmp greeting from %s: %v", conn.RemoteAddr(), err)
+	}
+	conn.SetReadDeadline(time.Time{})
+
+	go c.read(dec)
+
+	if err := c.execute(timeout, "qmp_capabilities", nil, nil); err != nil {
+		c.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *QMPClient) read(dec *json.Decoder) {
+	defer close(c.done)
+	for {
+		var msg qmpMessage
+		if err := dec.Decode(&msg); err != nil {
+			return
+		}
+		if msg.Event != "" {
+			c.dispatch(Event{
+				Name:      msg.Event,
+				Data:      msg.Data,
+				Timestamp: time.Unix(msg.Timestamp.Seconds, msg.Timestamp.Microseconds*1000),
+			})
+			continue
+		}
+		select {
+		case c.responses <- msg:
+		case <-time.After(c.timeout):
+			log.Warnln("dropping unexpected qmp response", msg.ID)
+		}
+	}
+}
+
+// dispatch 把事件发给订阅者；订阅者来不及接收时丢弃，不阻塞命令的结果
+func (c *QMPClient) dispatch(event Event) {
+	log.Infof("qmp event %s %s", event.Name, event.Data)
+
+	c.subsMu.Lock()
+	defer c.subsMu.Unlock()
+	for _, sub := range c.subs {
+		if !slices.Contains(sub.names, event.Name) {
+			continue
+		}
+		select {
+		case sub.ch <- event:
+		default:
+			log.Warnln("subscriber too slow, dropping qmp event", event.Name)
+		}
+	}
+}
+
+// Subscribe 订阅指定名称的事件。连接关闭后 channel 不会关闭，调用方应同时等待 QEMU 退出
+func (c *QMPClient) Subscribe(names ...string) <-chan Event {
+	ch := make(chan Event, 16)
+	c.subsMu.Lock()
+	c.subs = append(c.subs, subscription{names: names, ch: ch})
+	c.subsMu.Unlock()
+	return ch
+}
+
+// execute 执行命令并把 return 解析到 result；result 为 nil 时忽略返回值
+func (c *QMPClient) execute(timeout time.Duration, command string, arguments, result any) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.nextID++
+	id := strconv.Itoa(c.nextID)
+	data, err := json.Marshal(qmpCommand{Execute: command, Arguments: arguments, ID: id})
+	if err != nil {
+		return err
+	}
+	if _, err := c.conn.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("qmp %s: %v", command, err)
+	}
+
+	deadline := time.After(timeout)
+	for {
+		select {
+		case msg := <-c.responses:
+			// 之前超时的命令的结果
+			if msg.ID != id {
+				continue
+			}
+			if msg.Error != nil {
+				return fmt.Errorf("qmp %s: %s: %s", command, msg.Error.Class, msg.Error.Desc)
+			}
+			if result == nil {
+				return nil
+			}
+			return json.Unmarshal(msg.Return, result)
+		case <-c.done:
+			return fmt.Errorf("qmp %s: %w", command, errQMPClosed)
+		case <-deadline:
+			return fmt.Errorf("qmp %s: no response in %s", command, timeout)
+		}
+	}
+}
+
+func (c *QMPClient) QueryStatus() (VMStatus, error) {
+	var status VMStatus
+	err := c.execute(c.timeout, "query-status", nil, &status)
+	return status, err
+}
+
+// SystemPowerdown 向客户机发送 ACPI 关机请求，客户机可能不响应
+func (c *QMPClient) SystemPowerdown() error {
+	return c.execute(c.timeout, "system_powerdown", nil, nil)
+}
+
+func (c *QMPClient) Stop() error {
+	return c.execute(c.timeout, "stop", nil, nil)
+}
+
+func (c *QMPClient) Cont() error {
+	return c.execute(c.timeout, "cont", nil, nil)
+}
+
+// Quit 立即结束 QEMU，用于不再响应关机请求的客户机
+func (c *QMPClient) Quit() error {
+	err := c.execute(c.timeout, "quit", nil, nil)
+	// QEMU 可能在返回结果前就关闭了连接
+	if errors.Is(err, errQMPClosed) {
+		return nil
+	}
+	return err
+}
+
+// DumpGuestMemory 以 ELF 格式把客户机内存写入宿主机的 path，同步执行直到完成或超时
+func (c *QMPClient) DumpGuestMemory(path string, timeout time.Duration) error {
+	arguments := map[string]any{
+		"paging":   false,
+		"protocol": "file:" + path,
+		"format":   "elf",
+	}
+	return c.execute(timeout, "dump-guest-memory", arguments, nil)
+}
+
+// HumanMonitorCommand 通过 QMP 执行人类监视器命令并返回其输出
+func (c *QMPClient) HumanMonitorCommand(command string) (string, error) {
+	var output string
+	err := c.execute(c.timeout, "human-monitor-command", map[string]string{"command-line": command}, &output)
+	return output, err
+}
+
+// Closed 连接关闭时关闭的 channel
+func (c *QMPClient) Closed() <-chan struct{} {
+	return c.done
+}
+
+func (c *QMPClient) Close() error {
+	return c.conn.Close()
+}
@@ -0,0 +1,214 @@
+package kvm
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+const qmpGreeting = `{"QMP": {"version": {"qemu": {"micro": 1, "minor": 2, "major": 8}, "package": "Debian 1:8.2.1+ds-1"}, "capabilities": ["oob"]}}`
+
+// qmpServer 在 net.Pipe 的另一端扮演 QEMU
+type qmpServer struct {
+	t    *testing.T
+	conn net.Conn
+	dec  *json.Decoder
+}
+
+func (s *qmpServer) send(msg string) {
+	s.conn.Write([]byte(msg + "\n"))
+}
+
+// expect 读取下一条命令，返回它的 id
+func (s *qmpServer) expect(execute string) string {
+	var cmd qmpCommand
+	if err := s.dec.Decode(&cmd); err != nil {
+		s.t.Errorf("waiting for %s: %v", execute, err)
+		return ""
+	}
+	if cmd.Execute != execute {
+		s.t.Errorf("got command %s, want %s", cmd.Execute, execute)
+	}
+	return cmd.ID
+}
+
+func (s *qmpServer) reply(id, ret string) {
+	s.send(`{"return": ` + ret + `, "id": "` + id + `"}`)
+}
+
+// handshake 发送问候消息并接受 qmp_capabilities
+func (s *qmpServer) handshake() {
+	s.send(qmpGreeting)
+	s.reply(s.expect("qmp_capabilities"), "{}")
+}
+
+// serveQMP 在另一个 goroutine 中按 script 应答，返回客户端一端的连接
+func serveQMP(t *testing.T, script func(s *qmpServer)) net.Conn {
+	client, server := net.Pipe()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer server.Close()
+		script(&qmpServer{t: t, conn: server, dec: json.NewDecoder(server)})
+	}()
+	t.Cleanup(func() {
+		client.Close()
+		<-done
+	})
+	return client
+}
+
+func dialTest(t *testing.T, script func(s *qmpServer)) *QMPClient {
+	t.Helper()
+	c, err := newQMPClient(serveQMP(t, script), time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestQMPHandshake(t *testing.T) {
+	c := dialTest(t, func(s *qmpServer) {
+		s.handshake()
+		s.reply(s.expect("query-status"), `{"running": true, "singlestep": false, "status": "running"}`)
+	})
+	status, err := c.QueryStatus()
+	if err != nil || !status.Running || status.Status != "running" {
+		t.Errorf("query-status = %+v, %v", status, err)
+	}
+}
+
+func TestQMPNoGreeting(t *testing.T) {
+	tests := []struct {
+		name   string
+		script func(s *qmpServer)
+	}{
+		{name: "closed", script: func(s *qmpServer) {}},
+		{name: "not a greeting", script: func(s *qmpServer) { s.send(`{"return": {}}`) }},
+		// 没有发送任何内容时等待超时
+		{name: "silent", script: func(s *qmpServer) { time.Sleep(300 * time.Millisecond) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := newQMPClient(serveQMP(t, tt.script), 100*time.Millisecond); err == nil || !strings.Contains(err.Error(), "no qmp greeting") {
+				t.Errorf("error %v, want no qmp greeting", err)
+			}
+		})
+	}
+}
+
+func TestQMPCapabilitiesRejected(t *testing.T) {
+	conn := serveQMP(t, func(s *qmpServer) {
+		s.send(qmpGreeting)
+		id := s.expect("qmp_capabilities")
+		s.send(`{"error": {"class": "CommandNotFound", "desc": "Capabilities negotiation is already complete, command ignored"}, "id": "` + id + `"}`)
+	})
+	if _, err := newQMPClient(conn, time.Second); err == nil || !strings.Contains(err.Error(), "CommandNotFound") {
+		t.Errorf("error %v, want the capabilities error", err)
+	}
+}
+
+// 命令的结果与客户机事件交错到达：事件分发给订阅者，结果交给等待的命令
+func TestQMPEventsInterleaved(t *testing.T) {
+	c := dialTest(t, func(s *qmpServer) {
+		s.handshake()
+		id := s.expect("query-status")
+		s.send(`{"timestamp": {"seconds": 1709547165, "microseconds": 250000}, "event": "STOP"}`)
+		s.send(`{"timestamp": {"seconds": 1709547165, "microseconds": 251000}, "event": "GUEST_PANICKED", "data": {"action": "pause", "info": {"type": "hyper-v"}}}`)
+		s.reply(id, `{"running": false, "singlestep": false, "status": "guest-panicked"}`)
+		s.send(`{"timestamp": {"seconds": 1709547166, "microseconds": 0}, "event": "SHUTDOWN", "data": {"guest": true, "reason": "guest-panic"}}`)
+		s.reply(s.expect("stop"), "{}")
+	})
+	events := c.Subscribe(EventGuestPanicked, EventShutdown)
+
+	status, err := c.QueryStatus()
+	if err != nil || status.Running || status.Status != "guest-panicked" {
+		t.Fatalf("query-status = %+v, %v", status, err)
+	}
+	for _, want := range []string{EventGuestPanicked, EventShutdown} {
+		select {
+		case event := <-events:
+			if event.Name != want {
+				t.Errorf("got event %s, want %s", event.Name, want)
+			}
+			if want == EventGuestPanicked && (!strings.Contains(string(event.Data), `"action": "pause"`) || event.Timestamp.Unix() != 1709547165) {
+				t.Errorf("GUEST_PANICKED data %s at %v", event.Data, event.Timestamp)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("no %s event", want)
+		}
+	}
+	if err := c.Stop(); err != nil {
+		t.Errorf("stop after the events: %v", err)
+	}
+	// STOP 没有订阅
+	select {
+	case event := <-events:
+		t.Errorf("unexpected event %s", event.Name)
+	default:
+	}
+}
+
+func TestQMPCommandError(t *testing.T) {
+	c := dialTest(t, func(s *qmpServer) {
+		s.handshake()
+		id := s.expect("human-monitor-command")
+		s.send(`{"error": {"class": "GenericError", "desc": "unknown command: 'info nothing'"}, "id": "` + id + `"}`)
+	})
+	if _, err := c.HumanMonitorCommand("info nothing"); err == nil || !strings.Contains(err.Error(), "GenericError: unknown command") {
+		t.Errorf("error %v, want the QMP error", err)
+	}
+}
+
+// 超时的命令的结果迟到时被下一条命令跳过
+func TestQMPTimeout(t *testing.T) {
+	conn := serveQMP(t, func(s *qmpServer) {
+		s.handshake()
+		late := s.expect("cont")
+		id := s.expect("query-status")
+		s.reply(late, "{}")
+		s.reply(id, `{"running": true, "singlestep": false, "status": "running"}`)
+	})
+	c, err := newQMPClient(conn, 100*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Cont(); err == nil || !strings.Contains(err.Error(), "no response") {
+		t.Errorf("cont without reply: %v", err)
+	}
+	if status, err := c.QueryStatus(); err != nil || status.Status != "running" {
+		t.Errorf("query-status after a timeout = %+v, %v", status, err)
+	}
+}
+
+// QEMU 退出时连接关闭，等待中的命令立即返回
+func TestQMPEOF(t *testing.T) {
+	c := dialTest(t, func(s *qmpServer) {
+		s.handshake()
+		s.expect("system_powerdown")
+	})
+	start := time.Now()
+	if err := c.SystemPowerdown(); !errors.Is(err, errQMPClosed) {
+		t.Errorf("system_powerdown on a closed connection: %v", err)
+	}
+	if time.Since(start) > 500*time.Millisecond {
+		t.Errorf("command waited %s for a closed connection", time.Since(start))
+	}
+	select {
+	case <-c.Closed():
+	case <-time.After(time.Second):
+		t.Error("Closed not signalled after EOF")
+	}
+
+	// quit 的结果可能来不及发送
+	c = dialTest(t, func(s *qmpServer) {
+		s.handshake()
+		s.expect("quit")
+	})
+	if err := c.Quit(); err != nil {
+		t.Errorf("quit: %v", err)
+	}
+}
@@ -0,0 +1,98 @@
+package kvm
+
+// 复现程序的重复运行：每次运行启动一次虚拟机，各次运行的结果与串口日志保存在内核构建目录的 runs 目录，
+// 随内核构建目录一起打包；worker 读取后解析每次运行的崩溃标题
+
+import (
+	"backend/pkg/parse"
+	"backend/pkg/result"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const runsResultFile = "result.json"
+
+// oopsPattern 内核报告的开头，与 worker 解析串口日志时识别的报告一致
+var oopsPattern = regexp.MustCompile(`BUG: |WARNING: |UBSAN: |Oops: |general protection fault|kernel BUG at |INFO: task \S+ blocked for more than|INFO: rcu_\w+ (?:self-)?detected|Kernel panic - not syncing`)
+
+// Run 一次运行的结果；Error 表示虚拟机未能启动或无法运行复现程序，这样的运行不计入崩溃率
+type Run struct {
+	Index          int    `json:"index"`
+	Crashed        bool   `json:"crashed"`
+	VmcoreCaptured bool   `json:"vmcore_captured"`
+	DurationMs     int64  `json:"duration_ms"`
+	Error          string `json:"error,omitempty"`
+}
+
+func commitOf(report *parse.CrashReport) string {
+	return report.Crashes[0].KernelSourceCommit
+}
+
+// RunsDir 保存各次运行结果与串口日志的目录
+func RunsDir(report *parse.CrashReport) string {
+	commit := commitOf(report)
+	return fmt.Sprintf("build/%s/linux-%s/runs", commit, commit)
+}
+
+// Crashed 串口日志中是否有内核报告
+func Crashed(console []byte) bool {
+	return oopsPattern.Match(console)
+}
+
+// SaveRun 把本次运行的串口日志复制到 runs 目录，返回日志中是否有内核报告
+func SaveRun(report *parse.CrashReport, slot *Slot, index int) (bool, error) {
+	data, err := os.ReadFile(slot.ConsoleLog())
+	if err != nil {
+		return false, err
+	}
+	if err := os.WriteFile(filepath.Join(RunsDir(report), fmt.Sprintf("%d.log", index)), data, 0644); err != nil {
+		return false, err
+	}
+	return Crashed(data), nil
+}
+
+// WriteRuns 写入 runs 目录的 result.json，每次运行之后都会更新
+func WriteRuns(report *parse.CrashReport, runs []Run) {
+	data, err := json.MarshalIndent(runs, "", "  ")
+	if err != nil {
+		log.Errorln(err)
+		return
+	}
+	path := filepath.Join(RunsDir(report), runsResultFile)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		log.Errorln("failed to write run results:", err)
+		return
+	}
+	result.SetRuns(commitOf(report), path)
+}
+
+// NewOverlay 为下一次运行重新创建以 debian.img 为底层镜像的 qcow2 覆盖层，虚拟机此后从覆盖层启动。
+// 客户机的写入只进入覆盖层，debian.img 保持第一次启动前的状态，每次运行都从相同的状态启动；
+// 创建覆盖层只写入元数据，不复制镜像
+func NewOverlay(slot *Slot) error {
+	if err := os.Remove(slot.overlayPath()); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	cmd := exec.Command("qemu-img", "create", "-f", "qcow2", "-b", slot.ImagePath(), "-F", "raw", slot.overlayPath())
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to create image overlay: %v: %s", err, bytes.TrimSpace(out))
+	}
+	slot.overlay = true
+	return nil
+}
+
+// flattenOverlay 把覆盖层与底层镜像合并为一个 raw 镜像供 get.sh 挂载，debian.img 不变
+func flattenOverlay(slot *Slot) (string, error) {
+	cmd := exec.Command("qemu-img", "convert", "-f", "qcow2", "-O", "raw", slot.overlayPath(), slot.flatPath())
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return "", fmt.Errorf("failed to flatten image overlay: %v: %s", err, bytes.TrimSpace(out))
+	}
+	return slot.flatPath(), nil
+}
@@ -0,0 +1,52 @@
+package kvm
+
+import "testing"
+
+func TestCrashed(t *testing.T) {
+	tests := []struct {
+		name    string
+		console string
+		want    bool
+	}{
+		{
+			name: "clean boot",
+			console: `[    0.000000] Linux version 6.8.0-rc5 (root@builder) (gcc (Debian 12.2.0-14) 12.2.0) #1 SMP PREEMPT_DYNAMIC
+[    3.512004] EXT4-fs (sda): mounted filesystem with ordered data mode.
+[   21.043311] random: crng init done
+`,
+		},
+		{
+			name: "kasan",
+			console: `[   88.102741] ==================================================================
+[   88.103377] BUG: KASAN: slab-use-after-free in tun_chr_close+0x2c/0x40
+[   88.104003] Read of size 8 at addr ffff88801c7f2a10 by task syz-executor.0/5061
+`,
+			want: true,
+		},
+		{
+			name:    "warning",
+			console: "[   40.120111] WARNING: CPU: 0 PID: 812 at net/core/dev.c:10874 netdev_run_todo+0x5e1/0x6a0\n",
+			want:    true,
+		},
+		{
+			name:    "hung task",
+			console: "[  246.310008] INFO: task syz-executor.3:5120 blocked for more than 143 seconds.\n",
+			want:    true,
+		},
+		{
+			name:    "rcu stall",
+			console: "[  180.451126] INFO: rcu_preempt detected stalls on CPUs/tasks:\n",
+			want:    true,
+		},
+		{
+			name:    "panic",
+			console: "[   91.000441] Kernel panic - not syncing: KASAN: panic_on_warn set ...\n",
+			want:    true,
+		},
+	}
+	for _, tt := range tests {
+		if got := Crashed([]byte(tt.console)); got != tt.want {
+			t.Errorf("%s: Crashed = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,231 @@
+package kvm
+
+// 宿主机上的虚拟机槽位：同一台机器上的多个 kernel-builder 进程各自占用一个槽位，槽位 i 独占 SSH 转发端口
+// 2222+i、QMP 端口 4444+i 与虚拟机工作目录 work/vm<i>（磁盘镜像、启动镜像、串口日志）。
+// 槽位以 slots/<i>.lock 上的 flock 互斥，文件内容为当前的分配信息；进程崩溃或被结束时内核释放锁，
+// 下一个占用该槽位的进程清理遗留的工作目录
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"syscall"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	slotDir     = "slots"
+	sshPortBase = 2222
+	qmpPortBase = 4444
+	// slotPoll 所有槽位都被占用时重新检查的间隔
+	slotPoll = 5 * time.Second
+)
+
+// Slot 一台虚拟机独占的宿主机资源
+type Slot struct {
+	Index   int       `json:"index"`
+	PID     int       `json:"pid"`
+	Commit  string    `json:"commit"`
+	SSHPort int       `json:"ssh_port"`
+	QMPPort int       `json:"qmp_port"`
+	Dir     string    `json:"dir"`
+	Since   time.Time `json:"since"`
+
+	lock    *os.File
+	overlay bool // 虚拟机从 NewOverlay 创建的覆盖层启动
+}
+
+// AcquireSlot 占用一个空闲槽位，所有槽位都被占用时等待；端口被其他程序占用的槽位会被跳过
+func AcquireSlot(commit string, slots int) (*Slot, error) {
+	if err := os.MkdirAll(slotDir, 0755); err != nil {
+		return nil, err
+	}
+	waiting := false
+	for {
+		for i := 0; i < slots; i++ {
+			slot, err := tryAcquire(i, commit)
+			if err != nil {
+				return nil, err
+			}
+			if slot != nil {
+				log.Infof("acquired vm slot %d: ssh port %d, qmp port %d, work dir %s", slot.Index, slot.SSHPort, slot.QMPPort, slot.Dir)
+				return slot, nil
+			}
+		}
+		if !waiting {
+			log.Infof("all %d vm slots are busy, waiting", slots)
+			waiting = true
+		}
+		time.Sleep(slotPoll)
+	}
+}
+
+// tryAcquire 槽位被占用时返回 nil
+func tryAcquire(index int, commit string) (*Slot, error) {
+	f, err := os.OpenFile(lockPath(index), os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		f.Close()
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	// QEMU 与脚本在其他目录中运行，工作目录使用绝对路径
+	dir, err := filepath.Abs(filepath.Join("work", fmt.Sprintf("vm%d", index)))
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	slot := &Slot{
+		Index:   index,
+		PID:     os.Getpid(),
+		Commit:  commit,
+		SSHPort: sshPortBase + index,
+		QMPPort: qmpPortBase + index,
+		Dir:     dir,
+		Since:   time.Now(),
+		lock:    f,
+	}
+
+	// 上一个进程崩溃后遗留的 QEMU 或其他程序可能仍占用端口
+	for _, port := range []int{slot.SSHPort, slot.QMPPort} {
+		if !portFree(port) {
+			log.Warnf("port %d of vm slot %d is in use, skipping the slot", port, index)
+			f.Close()
+			return nil, nil
+		}
+	}
+
+	if err := os.RemoveAll(slot.Dir); err != nil {
+		slot.Release()
+		return nil, err
+	}
+	if err := os.MkdirAll(slot.Dir, 0755); err != nil {
+		slot.Release()
+		return nil, err
+	}
+	if err := slot.write(); err != nil {
+		slot.Release()
+		return nil, err
+	}
+	return slot, nil
+}
+
+func lockPath(index int) string {
+	return filepath.Join(slotDir, fmt.Sprintf("%d.lock", index))
+}
+
+func portFree(port int) bool {
+	l, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	if err != nil {
+		return false
+	}
+	l.Close()
+	return true
+}
+
+// write 把分配信息写入锁文件，供 ListSlots 查看
+func (s *Slot) write() error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	if err := s.lock.Truncate(0); err != nil {
+		return err
+	}
+	_, err = s.lock.WriteAt(data, 0)
+	return err
+}
+
+// Release 删除虚拟机工作目录并释放槽位
+func (s *Slot) Release() {
+	if s.lock == nil {
+		return
+	}
+	if err := os.RemoveAll(s.Dir); err != nil {
+		log.Warnln("failed to remove vm work dir:", err)
+	}
+	if err := s.lock.Truncate(0); err != nil {
+		log.Warnln("failed to clear vm slot:", err)
+	}
+	// 关闭文件即释放 flock
+	s.lock.Close()
+	s.lock = nil
+	log.Infoln("released vm slot", s.Index)
+}
+
+// ImagePath 虚拟机的磁盘镜像
+func (s *Slot) ImagePath() string {
+	return filepath.Join(s.Dir, "debian.img")
+}
+
+// ConsoleLog 虚拟机的串口日志，每次启动时被 QEMU 重写
+func (s *Slot) ConsoleLog() string {
+	return filepath.Join(s.Dir, "console.log")
+}
+
+// DiskImage 虚拟机启动时使用的磁盘镜像及其格式：创建了覆盖层时为覆盖层，否则为 debian.img
+func (s *Slot) DiskImage() (path, format string) {
+	if s.overlay {
+		return s.overlayPath(), "qcow2"
+	}
+	return s.ImagePath(), "raw"
+}
+
+func (s *Slot) overlayPath() string {
+	return filepath.Join(s.Dir, "debian.run.qcow2")
+}
+
+// flatPath 合并覆盖层得到的 raw 镜像，get.sh 从中取出 vmcore
+func (s *Slot) flatPath() string {
+	return filepath.Join(s.Dir, "debian.flat.img")
+}
+
+// ListSlots 当前被占用的槽位；锁已释放的槽位即使留有分配信息也不列出
+func ListSlots() ([]Slot, error) {
+	paths, err := filepath.Glob(filepath.Join(slotDir, "*.lock"))
+	if err != nil {
+		return nil, err
+	}
+	var slots []Slot
+	for _, path := range paths {
+		if !locked(path) {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var slot Slot
+		if err := json.Unmarshal(data, &slot); err != nil {
+			log.Warnf("slot %s is locked but has no allocation yet", path)
+			continue
+		}
+		slots = append(slots, slot)
+	}
+	slices.SortFunc(slots, func(a, b Slot) int { return a.Index - b.Index })
+	return slots, nil
+}
+
+func locked(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_SH|syscall.LOCK_NB); err != nil {
+		return true
+	}
+	syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+	return false
+}
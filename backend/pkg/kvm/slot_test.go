@@ -0,0 +1,109 @@
+package kvm
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// inTempDir 槽位的锁文件与工作目录都相对于当前目录
+func inTempDir(t *testing.T) {
+	t.Helper()
+	dir := wd(t)
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(dir) })
+}
+
+func wd(t *testing.T) string {
+	t.Helper()
+	dir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestAcquireSlot(t *testing.T) {
+	inTempDir(t)
+	const slots = 3
+
+	// 同时分配：每次分配各自打开锁文件，同一进程中的 flock 同样互斥
+	acquired := make([]*Slot, slots)
+	var wg sync.WaitGroup
+	for i := range acquired {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			slot, err := AcquireSlot("0f1e2d3c", slots)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			acquired[i] = slot
+		}()
+	}
+	wg.Wait()
+	if t.Failed() {
+		t.FailNow()
+	}
+	defer func() {
+		for _, slot := range acquired {
+			slot.Release()
+		}
+	}()
+
+	seen := make(map[int]bool)
+	for _, slot := range acquired {
+		if seen[slot.Index] {
+			t.Fatalf("slot %d allocated twice", slot.Index)
+		}
+		seen[slot.Index] = true
+		if slot.SSHPort != sshPortBase+slot.Index || slot.QMPPort != qmpPortBase+slot.Index {
+			t.Errorf("slot %d has ports %d and %d", slot.Index, slot.SSHPort, slot.QMPPort)
+		}
+		if slot.Dir != filepath.Join(wd(t), "work", fmt.Sprintf("vm%d", slot.Index)) {
+			t.Errorf("slot %d has work dir %s", slot.Index, slot.Dir)
+		}
+		if info, err := os.Stat(slot.Dir); err != nil || !info.IsDir() {
+			t.Errorf("work dir of slot %d was not created: %v", slot.Index, err)
+		}
+	}
+
+	for i := 0; i < slots; i++ {
+		if slot, err := tryAcquire(i, "9f8e7d6c"); slot != nil || err != nil {
+			t.Errorf("busy slot %d acquired again: %v, %v", i, slot, err)
+		}
+	}
+	listed, err := ListSlots()
+	if err != nil || len(listed) != slots {
+		t.Fatalf("ListSlots = %d slots, %v, want %d", len(listed), err, slots)
+	}
+	for i, slot := range listed {
+		if slot.Index != i || slot.Commit != "0f1e2d3c" || slot.PID != os.Getpid() {
+			t.Errorf("listed slot %+v", slot)
+		}
+	}
+
+	// 释放后工作目录被删除，槽位可以再次分配
+	released := acquired[1]
+	dir := released.Dir
+	released.Release()
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("work dir %s left after release: %v", dir, err)
+	}
+	if listed, _ := ListSlots(); len(listed) != slots-1 {
+		t.Errorf("ListSlots after release = %d slots, want %d", len(listed), slots-1)
+	}
+	again, err := AcquireSlot("9f8e7d6c", slots)
+	if err != nil {
+		t.Fatal(err)
+	}
+	acquired[1] = again
+	if again.Index != released.Index || again.Dir != dir || again.Commit != "9f8e7d6c" {
+		t.Errorf("reacquired slot %d at %s, want slot %d at %s", again.Index, again.Dir, released.Index, dir)
+	}
+}
@@ -1,6 +1,7 @@
 package kvm
 
 import (
+	"backend/pkg/progress"
 	"fmt"
 	"io"
 	"math/rand"
@@ -26,12 +27,16 @@ type SSHConfig struct {
 	Passwd  string
 	KeyPath string
 	Timeout time.Duration
+	Retries int // 连接失败时的重试次数，模拟运行的客户机启动较慢
 }
 
 func NewSSHManager(config SSHConfig) *SSHManager {
 	if config.Timeout == 0 {
 		config.Timeout = 30 * time.Second
 	}
+	if config.Retries == 0 {
+		config.Retries = 5
+	}
 
 	return &SSHManager{
 		config: config,
@@ -60,20 +65,23 @@ func (sm *SSHManager) Connect() error {
 	address := fmt.Sprintf("%s:%d", sm.config.Host, sm.config.Port)
 
 	r := rand.New(rand.NewSource(time.Now().UnixNano()))
-	maxRetries := 5
 	var client *ssh.Client
-	for i := range maxRetries {
-		var err error
+	var err error
+	for i := range sm.config.Retries {
 		client, err = ssh.Dial("tcp", address, clientConfig)
-		if err != nil {
-			log.Errorf("fail to dial at try %d: %v", i, err)
+		if err == nil {
+			break
 		}
+		log.Errorf("fail to dial at try %d: %v", i, err)
 
 		backoff := min(1<<i, 30)
 		jitter := time.Duration(r.Intn(backoff)+1) * time.Second
 		log.Infof("sleep %s before retrying to connect SSH: %v\n", jitter, err)
 		time.Sleep(jitter)
 	}
+	if err != nil {
+		return fmt.Errorf("fail to dial %s: %v", address, err)
+	}
 
 	sm.client = client
 	log.Infof("SSH connection successful: %s\n", address)
@@ -115,7 +123,7 @@ func (sm *SSHManager) Execute(cmd string) (string, error) {
 		return string(output), fmt.Errorf("fail to execute command: %v", err)
 	}
 
-	fmt.Printf("command output: %s", string(output))
+	fmt.Fprintf(progress.Stdout, "command output: %s", string(output))
 	return string(output), nil
 }
 
@@ -142,7 +150,7 @@ func (sm *SSHManager) ExecutePersistent(cmd string) error {
 	}
 
 	go func() {
-		_, err := io.Copy(os.Stdout, stdout)
+		_, err := io.Copy(progress.Stdout, stdout)
 		if err != nil {
 			log.Errorln(err)
 		}
@@ -0,0 +1,66 @@
+package lock
+
+// 同一台机器上的多个 kernel-builder 进程共用 build-vmcore 中以提交命名的目录：构建目录 build/<commit>、
+// 旧的虚拟机工作目录 work/<commit>，以及二分使用的 repo/linux.git 与其中的 git bisect 状态。
+// 使用前以 locks/ 下文件上的 flock 互斥，被占用时等待；进程崩溃或被结束时内核释放锁
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const lockDir = "locks"
+
+// Lock 一个被占用的 flock
+type Lock struct {
+	name string
+	file *os.File
+}
+
+// Commit 占用 commit 的构建目录与工作目录
+func Commit(commit string) (*Lock, error) {
+	return acquire(commit)
+}
+
+// Repo 占用二分使用的提交历史仓库与其中的二分状态
+func Repo() (*Lock, error) {
+	return acquire("repo")
+}
+
+func acquire(name string) (*Lock, error) {
+	if err := os.MkdirAll(lockDir, 0755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(filepath.Join(lockDir, name+".lock"), os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		if !errors.Is(err, syscall.EWOULDBLOCK) {
+			f.Close()
+			return nil, err
+		}
+		log.Infof("%s is in use by another kernel-builder, waiting", name)
+		if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+	log.Infoln("locked", name)
+	return &Lock{name: name, file: f}, nil
+}
+
+// Release 释放锁
+func (l *Lock) Release() {
+	if l.file == nil {
+		return
+	}
+	// 关闭文件即释放 flock
+	l.file.Close()
+	l.file = nil
+	log.Infoln("unlocked", l.name)
+}
@@ -32,18 +32,42 @@ type Crash struct {
 	CrashReportLink     string `json:"crash-report-link"`
 }
 type CrashReport struct {
-	Version           int         `json:"version"`
-	Title             string      `json:"title"`
-	DisplayTitle      string      `json:"display-title"`
-	ID                string      `json:"id"`
-	Status            string      `json:"status"`
-	FixCommits        []FixCommit `json:"fix-commits"`
-	Discussions       []string    `json:"discussions"`
-	Crashes           []Crash     `json:"crashes"`
-	Subsystems        []string    `json:"subsystems"`
-	ParentOfFixCommit string      `json:"parent_of_fix_commit"`
-	Patch             string      `json:"patch"`
-	PatchModified     []string    `json:"patch_modified_files"`
+	Version           int           `json:"version"`
+	Title             string        `json:"title"`
+	DisplayTitle      string        `json:"display-title"`
+	ID                string        `json:"id"`
+	Status            string        `json:"status"`
+	FixCommits        []FixCommit   `json:"fix-commits"`
+	Discussions       []string      `json:"discussions"`
+	Crashes           []Crash       `json:"crashes"`
+	Subsystems        []string      `json:"subsystems"`
+	ParentOfFixCommit string        `json:"parent_of_fix_commit"`
+	Patch             string        `json:"patch"`
+	PatchModified     []string      `json:"patch_modified_files"`
+	Patches           []SeriesPatch `json:"patches"`
+	Bisect            *BisectRange  `json:"bisect"`
+}
+
+// BisectRange bisect 任务的二分范围：复现程序在 Good 上不崩溃、在 Bad 上崩溃
+type BisectRange struct {
+	Good string `json:"good"`
+	Bad  string `json:"bad"`
+}
+
+// SeriesPatch 补丁系列中的一个补丁，按顺序应用
+type SeriesPatch struct {
+	Subject string `json:"subject"`
+	Diff    string `json:"diff"`
+}
+
+// FixCommit 第一个带有哈希的修复提交，没有修复提交时返回空字符串
+func (cr *CrashReport) FixCommit() string {
+	for _, fix := range cr.FixCommits {
+		if fix.Hash != "" {
+			return fix.Hash
+		}
+	}
+	return ""
 }
 
 // construct directory with CrashReport
@@ -0,0 +1,58 @@
+package progress
+
+import (
+	"bytes"
+	"sync"
+)
+
+// Lines 返回按行回调的 io.Writer，用于从子进程输出中识别进度；未以换行结尾的内容留到下次写入
+func Lines(fn func(line string)) *LineWriter {
+	return &LineWriter{fn: fn}
+}
+
+type LineWriter struct {
+	mu  sync.Mutex
+	buf []byte
+	fn  func(line string)
+}
+
+func (w *LineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		w.fn(string(bytes.TrimSuffix(w.buf[:i], []byte("\r"))))
+		w.buf = w.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+// Warnings 限制单个步骤报告的警告数量，超出后只报告一次省略提示
+type Warnings struct {
+	mu    sync.Mutex
+	step  string
+	limit int
+	count int
+}
+
+func NewWarnings(step string, limit int) *Warnings {
+	return &Warnings{step: step, limit: limit}
+}
+
+func (w *Warnings) Warn(message string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.count++
+	switch {
+	case w.count <= w.limit:
+		Warn(w.step, "%s", message)
+	case w.count == w.limit+1:
+		Warn(w.step, "more than %d warnings, further warnings are only in the log", w.limit)
+	}
+}
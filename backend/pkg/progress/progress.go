@@ -0,0 +1,175 @@
+package progress
+
+// 以 "@@progress {json}" 行的形式向 worker 报告工作流进度：步骤边界、完成百分比与警告。
+// worker 识别这些行并转发给服务器，其余输出照常作为日志
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Prefix 标记进度行，worker 根据该前缀区分进度事件与普通日志
+const Prefix = "@@progress "
+
+const (
+	KindStepStart  = "step_start"
+	KindStepFinish = "step_finish"
+	KindPercent    = "percent"
+	KindWarning    = "warning"
+)
+
+// Event 进度事件；步骤边界与服务器 POST /tasks/:id/events 的请求体字段一致，
+// percent 事件携带 Current/Total，总量未知时 Percent 为空
+type Event struct {
+	Kind       string    `json:"kind"`
+	Step       string    `json:"step"`
+	OccurredAt time.Time `json:"occurred_at"`
+	DurationMs *int64    `json:"duration_ms,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	Percent    *float64  `json:"percent,omitempty"`
+	Current    int64     `json:"current,omitempty"`
+	Total      int64     `json:"total,omitempty"`
+	Message    string    `json:"message,omitempty"`
+}
+
+var mu sync.Mutex
+
+// stdoutWriter 与 Emit 共用 mu 写标准输出，并记录上一次写入是否停在行中间
+type stdoutWriter struct {
+	w       io.Writer
+	midLine bool
+}
+
+func (s *stdoutWriter) Write(p []byte) (int, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	n, err := s.w.Write(p)
+	if n > 0 {
+		s.midLine = p[n-1] != '\n'
+	}
+	return n, err
+}
+
+var stdout = &stdoutWriter{w: os.Stdout}
+
+// Stdout 子进程输出与日志写标准输出时使用的 io.Writer。子进程的 Stdout 直接设为 os.Stdout 时
+// 其输出绕过锁，未结束的行会与进度行拼在一起，worker 便无法识别进度行
+var Stdout io.Writer = stdout
+
+// Emit 输出一条进度事件；整行一次写入，子进程输出停在行中间时先换行，进度行总是独占一行
+func Emit(event Event) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if stdout.midLine {
+		fmt.Fprintln(stdout.w)
+		stdout.midLine = false
+	}
+	fmt.Fprintf(stdout.w, "%s%s\n", Prefix, data)
+}
+
+// Step 执行一个工作流步骤并报告其开始与结束，返回 fn 的错误
+func Step(name string, fn func() error) error {
+	start := time.Now()
+	Emit(Event{Kind: KindStepStart, Step: name, OccurredAt: start.UTC()})
+
+	err := fn()
+
+	end := time.Now()
+	duration := end.Sub(start).Milliseconds()
+	finish := Event{Kind: KindStepFinish, Step: name, OccurredAt: end.UTC(), DurationMs: &duration}
+	if err != nil {
+		finish.Error = err.Error()
+	}
+	Emit(finish)
+	return err
+}
+
+// Warn 报告步骤中不致命的问题，例如被修改的内核配置或编译器警告
+func Warn(step string, format string, args ...any) {
+	Emit(Event{Kind: KindWarning, Step: step, OccurredAt: time.Now().UTC(), Message: fmt.Sprintf(format, args...)})
+}
+
+// Counter 统计步骤的完成量并报告百分比；百分比每变化一个整数点才输出一次，
+// 总量未知（<= 0）时每秒最多输出一次当前量
+type Counter struct {
+	mu          sync.Mutex
+	step        string
+	current     int64
+	total       int64
+	lastPercent int
+	lastEmit    time.Time
+}
+
+func NewCounter(step string, total int64) *Counter {
+	return &Counter{step: step, total: total, lastPercent: -1}
+}
+
+// Add 增加完成量
+func (c *Counter) Add(n int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.current += n
+	c.report()
+}
+
+// SetTotal 修正估计的总量，如编译对象数超出估计时
+func (c *Counter) SetTotal(total int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.total = total
+}
+
+// Total 返回当前的总量
+func (c *Counter) Total() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.total
+}
+
+// Current 返回当前的完成量
+func (c *Counter) Current() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.current
+}
+
+// Write 将写入的字节数计入完成量，用于 io.Copy 下载或解压时统计字节
+func (c *Counter) Write(p []byte) (int, error) {
+	c.Add(int64(len(p)))
+	return len(p), nil
+}
+
+func (c *Counter) report() {
+	now := time.Now()
+	event := Event{Kind: KindPercent, Step: c.step, OccurredAt: now.UTC(), Current: c.current, Total: c.total}
+
+	if c.total <= 0 {
+		if now.Sub(c.lastEmit) < time.Second {
+			return
+		}
+	} else {
+		percent := min(float64(c.current)*100/float64(c.total), 100)
+		if int(percent) == c.lastPercent {
+			return
+		}
+		c.lastPercent = int(percent)
+		event.Percent = &percent
+	}
+
+	c.lastEmit = now
+	Emit(event)
+}
@@ -0,0 +1,32 @@
+package progress
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEmitStartsNewLine(t *testing.T) {
+	var buf bytes.Buffer
+	saved := stdout.w
+	stdout.w = &buf
+	defer func() { stdout.w = saved }()
+
+	fmt.Fprint(Stdout, "  CC      kernel/fork.o")
+	Emit(Event{Kind: KindPercent, Step: "MakeKernel", OccurredAt: time.Unix(0, 0).UTC()})
+	fmt.Fprint(Stdout, "\n  CC      kernel/exit.o\n")
+	Emit(Event{Kind: KindPercent, Step: "MakeKernel", OccurredAt: time.Unix(0, 0).UTC()})
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	want := []bool{false, true, false, false, true}
+	if len(lines) != len(want) {
+		t.Fatalf("got %d lines, want %d:\n%s", len(lines), len(want), buf.String())
+	}
+	for i, line := range lines {
+		if strings.HasPrefix(line, Prefix) != want[i] {
+			t.Errorf("line %d %q: progress line expected %v", i, line, want[i])
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package result
+
+// 一次执行的结果文件：kernel-builder 在执行过程中记录本次执行产生的文件与结论，每次更新都整体重写，
+// 执行中途退出时也保留已经记录的内容。worker 以 --result 指定文件位置，执行结束后只读取其中列出的文件，
+// 不再根据输出内容或文件修改时间推断哪些文件属于本次执行
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Commit 本次执行在一个内核提交上产生的文件，路径均为绝对路径
+type Commit struct {
+	Config  string `json:"config,omitempty"`  // 生效的内核 .config
+	Console string `json:"console,omitempty"` // get.sh 移动到内核构建目录的串口日志
+	Vmcore  string `json:"vmcore,omitempty"`  // get.sh 移动到内核构建目录的 vmcore
+	Runs    string `json:"runs,omitempty"`    // 各次复现运行的 result.json，串口日志在同一目录
+}
+
+// Result 结果文件的内容
+type Result struct {
+	Accel   string             `json:"accel,omitempty"`   // 最后一次启动虚拟机时的加速方式
+	Patches string             `json:"patches,omitempty"` // 补丁系列的 result.json
+	Bisect  string             `json:"bisect,omitempty"`  // 二分目录的 result.json，各步串口日志在同一目录
+	Commits map[string]*Commit `json:"commits,omitempty"`
+}
+
+var (
+	mu      sync.Mutex
+	path    string
+	current = Result{Commits: make(map[string]*Commit)}
+)
+
+// Open 设置结果文件的位置并写入空结果，之前执行留下的同名文件被覆盖；
+// 没有调用时结果只记录在内存中
+func Open(p string) error {
+	mu.Lock()
+	defer mu.Unlock()
+	path = p
+	return write()
+}
+
+// Of 本次执行在 commit 上记录的文件
+func Of(commit string) Commit {
+	mu.Lock()
+	defer mu.Unlock()
+	if c := current.Commits[commit]; c != nil {
+		return *c
+	}
+	return Commit{}
+}
+
+// SetAccel 记录启动虚拟机时的加速方式
+func SetAccel(accel string) {
+	update(func(r *Result) { r.Accel = accel })
+}
+
+// SetPatches 记录补丁系列的 result.json
+func SetPatches(file string) {
+	file = abs(file)
+	update(func(r *Result) { r.Patches = file })
+}
+
+// SetBisect 记录二分目录的 result.json
+func SetBisect(file string) {
+	file = abs(file)
+	update(func(r *Result) { r.Bisect = file })
+}
+
+// SetConfig 记录 commit 生效的内核 .config
+func SetConfig(commit, file string) {
+	file = abs(file)
+	updateCommit(commit, func(c *Commit) { c.Config = file })
+}
+
+// SetConsole 记录 commit 的串口日志；file 为空表示本次运行没有串口日志
+func SetConsole(commit, file string) {
+	file = abs(file)
+	updateCommit(commit, func(c *Commit) { c.Console = file })
+}
+
+// SetVmcore 记录 commit 取得的 vmcore
+func SetVmcore(commit, file string) {
+	file = abs(file)
+	updateCommit(commit, func(c *Commit) { c.Vmcore = file })
+}
+
+// SetRuns 记录 commit 各次复现运行的 result.json
+func SetRuns(commit, file string) {
+	file = abs(file)
+	updateCommit(commit, func(c *Commit) { c.Runs = file })
+}
+
+func updateCommit(commit string, f func(c *Commit)) {
+	update(func(r *Result) {
+		c := r.Commits[commit]
+		if c == nil {
+			c = &Commit{}
+			r.Commits[commit] = c
+		}
+		f(c)
+	})
+}
+
+func update(f func(r *Result)) {
+	mu.Lock()
+	defer mu.Unlock()
+	f(&current)
+	if err := write(); err != nil {
+		log.Errorln("failed to write result file:", err)
+	}
+}
+
+// write 先写入临时文件再重命名，worker 不会读到写了一半的结果
+func write() error {
+	if path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(current, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func abs(file string) string {
+	if file == "" {
+		return ""
+	}
+	if p, err := filepath.Abs(file); err == nil {
+		return p
+	}
+	return file
+}
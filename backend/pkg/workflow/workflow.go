@@ -1,19 +1,34 @@
 package workflow
 
 import (
+	"backend/pkg/arch"
+	"backend/pkg/bisect"
 	"backend/pkg/compile"
 	"backend/pkg/compress"
 	"backend/pkg/config"
 	"backend/pkg/kvm"
+	"backend/pkg/lock"
 	"backend/pkg/parse"
+	"backend/pkg/progress"
+	"backend/pkg/result"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"time"
 
 	log "github.com/sirupsen/logrus"
 )
 
+const (
+	// kdumpTimeout 内核崩溃后等待 kdump 保存 vmcore 并重启客户机的时间
+	kdumpTimeout = 3 * time.Minute
+	// reproGrace 复现程序退出后等待客户机崩溃的时间，复现程序留下的内核线程与定时器可能稍后才触发崩溃
+	reproGrace = 15 * time.Second
+)
+
 func sleep() {
 	time.Sleep(time.Second * 2)
 }
@@ -31,33 +46,64 @@ func WalkDir(callback func(data *parse.CrashReport), dir string) {
 	}
 }
 
-func CompileKernel(f string) error {
-	data := parse.Parse(f)
-	sleep()
-	compile.InitToolChain(&data)
+// prepareTree 准备基准源码树：撤销之前应用的补丁，下载缺少的源码、配置与复现程序
+func prepareTree(data *parse.CrashReport) error {
+	if _, err := arch.Of(data); err != nil {
+		return err
+	}
 
-	if err := compile.DownloadKernel(&data); err != nil {
+	if err := progress.Step("RestoreTree", func() error { return compile.RestoreBaseTree(data) }); err != nil {
+		log.Errorln(err)
+		return err
+	}
+
+	if err := progress.Step("DownloadKernel", func() error { return compile.DownloadKernel(data) }); err != nil {
 		log.Errorln(err)
 		return err
 	}
 
 	sleep()
 
-	if err := compile.DownloadConfig(&data); err != nil {
+	if err := progress.Step("DownloadConfig", func() error { return compile.DownloadConfig(data) }); err != nil {
 		log.Errorln(err)
 		return err
 	}
 
 	sleep()
 
-	if err := compile.DownloadBug(&data); err != nil {
+	if err := progress.Step("DownloadBug", func() error { return compile.DownloadBug(data) }); err != nil {
 		log.Errorln(err)
 		return err
 	}
 
 	sleep()
 
-	if err := compile.MakeKernel(&data); err != nil {
+	// 有 c 复现程序时 syzkaller 编译失败不影响任务，退回到 c 复现程序
+	if compile.HasSyzReproducer(data) {
+		if err := progress.Step("BuildSyzkaller", func() error { return compile.BuildSyzkaller(data) }); err != nil {
+			log.Errorln(err)
+			if data.Crashes[0].CReproducer == "" {
+				return err
+			}
+			progress.Warn("BuildSyzkaller", "falling back to c reproducer: %v", err)
+			if err := compile.DropSyzReproducer(data); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func CompileKernel(f string) error {
+	data := parse.Parse(f)
+	sleep()
+	compile.InitToolChain(&data)
+
+	if err := prepareTree(&data); err != nil {
+		return err
+	}
+
+	if err := progress.Step("MakeKernel", func() error { return compile.MakeKernel(&data) }); err != nil {
 		log.Errorln(err)
 		return err
 	}
@@ -68,8 +114,8 @@ func CompileKernel(f string) error {
 	return nil
 }
 
-func BuildKernel(report *parse.CrashReport) error {
-	if err := kvm.GetVmcore(report); err != nil {
+func BuildKernel(report *parse.CrashReport, slot *kvm.Slot) error {
+	if err := progress.Step("GetVmcore", func() error { return kvm.GetVmcore(report, slot) }); err != nil {
 		log.Errorln(err)
 		return err
 	}
@@ -89,29 +135,33 @@ func ClearKernel(f string) error {
 		return err
 	}
 	sleep()
-	if err := kvm.ClearImage(&data); err != nil {
+	if err := kvm.ClearImage(&data, nil); err != nil {
 		return err
 	}
 	log.Infoln("clear successfully")
 	return nil
 }
 
-func SSHConnect() *kvm.SSHManager {
+// SSHConnect 连接槽位的虚拟机；TCG 模拟运行的客户机启动需要数分钟，重试更多次
+func SSHConnect(slot *kvm.Slot, accel string) (*kvm.SSHManager, error) {
 	config := kvm.SSHConfig{
 		Host:    "127.0.0.1",
-		Port:    2222,
+		Port:    slot.SSHPort,
 		User:    "root",
 		Passwd:  "123456",
 		Timeout: 30 * time.Second,
 	}
+	if accel == kvm.AccelTCG {
+		config.Retries = 12
+	}
 
 	sshManager := kvm.NewSSHManager(config)
 
 	if err := sshManager.Connect(); err != nil {
-		log.Panicln(err)
+		return nil, err
 	}
 
-	return sshManager
+	return sshManager, nil
 }
 
 func SSHExecutePersistent(cmd string, sshManager *kvm.SSHManager) error {
@@ -124,26 +174,45 @@ func SSHExecute(cmd string, sshManager *kvm.SSHManager) error {
 	return err
 }
 
-func QEMUVMConnect(report *parse.CrashReport) (*kvm.QEMUManager, error) {
-	workPath, _ := os.Getwd()
-	commit := report.Crashes[0].KernelSourceCommit
+// QEMUVMConnect 以槽位的端口与工作目录启动虚拟机
+func QEMUVMConnect(report *parse.CrashReport, slot *kvm.Slot) (*kvm.QEMUManager, error) {
+	a, err := arch.Of(report)
+	if err != nil {
+		return nil, err
+	}
+	image, format := slot.DiskImage()
 	config := kvm.VMConfig{
-		ImagePath:    filepath.Join(workPath, fmt.Sprintf("work/%s/debian.img", commit)),
-		KernelPath:   filepath.Join(workPath, fmt.Sprintf("work/%s/bzImage", commit)),
+		ImagePath:    image,
+		ImageFormat:  format,
+		KernelPath:   filepath.Join(slot.Dir, a.BootImageName()),
 		Memory:       config.GlobalConfig.VM.Memory,
-		MonitorPort:  4444,
-		KernelAppend: "root=/dev/sda console=ttyS0,115200n8 rw crashkernel=256M",
-		LogFile:      filepath.Join(workPath, fmt.Sprintf("log/%s.log", commit)),
+		MonitorPort:  slot.QMPPort,
+		SSHPort:      slot.SSHPort,
+		KernelAppend: a.KernelAppend(),
+		LogFile:      slot.ConsoleLog(),
+		QEMU:         a.QEMU,
+		Machine:      a.Machine,
+		KVM:          a.Native(),
+		KVMCPU:       a.KVMCPU,
+		TCGCPU:       a.TCGCPU,
+		Drive:        a.Drive,
+		PanicDevice:  a.PanicDevice,
 	}
 
 	q := kvm.NewQEMUManager(config)
-	err := q.StartVM()
+	err = q.StartVM()
 	return q, err
 }
 
-// kexec -p /boot/crash-bzImage --initrd=/boot/crash-initramfs.cpio.gz --append="root=/dev/ram0 console=ttyS0"
-func Kexec(sshManager *kvm.SSHManager) error {
-	return SSHExecute("kexec -p /boot/crash-bzImage --initrd=/boot/crash-initramfs.cpio.gz --append=\"root=/dev/ram0 console=ttyS0\"", sshManager)
+// kexec -p /boot/crash-bzImage --initrd=/boot/crash-initramfs.cpio.gz --append="root=/dev/ram0 console=ttyS0"，
+// kdump 内核与控制台随架构变化
+func Kexec(sshManager *kvm.SSHManager, a arch.Arch) error {
+	return SSHExecute(fmt.Sprintf("kexec -p %s --initrd=/boot/crash-initramfs.cpio.gz --append=\"root=/dev/ram0 console=%s\"", a.CrashKernel, a.Console), sshManager)
+}
+
+// SyzBug 以复现程序记录的选项运行 syz 复现程序
+func SyzBug(sshManager *kvm.SSHManager, cmd string) error {
+	return SSHExecute(cmd, sshManager)
 }
 
 func Gcc(sshManager *kvm.SSHManager) error {
@@ -168,17 +237,110 @@ func Generate(f string) error {
 	log.Infof("starting generate vmcore with file: %s", f)
 
 	data := parse.Parse(f)
+	return generate(&data)
+}
+
+// generate 在 report 指定提交的内核上按配置重复运行复现程序，每次运行重新启动虚拟机。
+// 只有第一次崩溃的运行取得 vmcore，之后的运行产生的 vmcore 留在虚拟机镜像中。
+// 整个过程占用一个虚拟机槽位，同一台机器上的其他任务使用其他槽位并行运行
+func generate(data *parse.CrashReport) error {
+	var slot *kvm.Slot
+	if err := progress.Step("AcquireSlot", func() (err error) {
+		slot, err = kvm.AcquireSlot(data.Crashes[0].KernelSourceCommit, config.GlobalConfig.VM.Slots)
+		return err
+	}); err != nil {
+		log.Errorln(err)
+		return err
+	}
+	defer slot.Release()
+	// 槽位工作目录中可能留有 mount.sh 或 get.sh 以 root 身份创建的文件与挂载点，释放前由 clear.sh 清理
+	defer func() {
+		if err := kvm.ClearImage(data, slot); err != nil {
+			log.Warnln("failed to clear vm slot:", err)
+		}
+	}()
 
-	if err := kvm.ConfigImage(&data); err != nil {
+	if err := progress.Step("ConfigImage", func() error { return kvm.ConfigImage(data, slot) }); err != nil {
 		log.Errorln(err)
 		return err
 	}
 
 	sleep()
 
-	vm, err := QEMUVMConnect(&data)
-	if err != nil {
+	repro := config.GlobalConfig.Repro
+	if err := os.RemoveAll(kvm.RunsDir(data)); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(kvm.RunsDir(data), 0755); err != nil {
+		return err
+	}
+
+	var runs []kvm.Run
+	var lastErr error
+	vmcoreTaken := false
+	crashes := 0
+	for i := 1; i <= repro.Runs; i++ {
+		if repro.Snapshot {
+			if err := kvm.NewOverlay(slot); err != nil {
+				return err
+			}
+		}
+
+		start := time.Now()
+		run := runReproducer(data, slot, i, time.Duration(repro.RunTimeout)*time.Second)
+		if run.Error != "" {
+			lastErr = errors.New(run.Error)
+		}
+		if run.Crashed {
+			crashes++
+			if !vmcoreTaken {
+				if err := BuildKernel(data, slot); err != nil {
+					return err
+				}
+				vmcoreTaken = true
+				run.VmcoreCaptured = result.Of(data.Crashes[0].KernelSourceCommit).Vmcore != ""
+			}
+		}
+		run.DurationMs = time.Since(start).Milliseconds()
+		runs = append(runs, run)
+		kvm.WriteRuns(data, runs)
+		log.Infof("run %d/%d: crashed %v, %d crashes so far", i, repro.Runs, run.Crashed, crashes)
+	}
+
+	// 每次运行都没能启动虚拟机或运行复现程序
+	if lastErr != nil && !slices.ContainsFunc(runs, func(run kvm.Run) bool { return run.Error == "" }) {
+		return lastErr
+	}
+
+	// 串口日志中没有内核报告时照常检查镜像中是否有 vmcore
+	if !vmcoreTaken {
+		if err := BuildKernel(data, slot); err != nil {
+			return err
+		}
+	}
+	log.Infoln("generate vmcore successfully!")
+	return nil
+}
+
+// runReproducer 启动虚拟机运行一次复现程序，最长运行到超时：复现程序退出后再等待 reproGrace，
+// 期间没有崩溃则结束本次运行；客户机在 kdump 保存 vmcore 后重启，虚拟机随之退出，此时提前结束
+func runReproducer(data *parse.CrashReport, slot *kvm.Slot, index int, timeout time.Duration) kvm.Run {
+	run := kvm.Run{Index: index}
+
+	var vm *kvm.QEMUManager
+	err := progress.Step("BootVM", func() error {
+		var err error
+		vm, err = QEMUVMConnect(data, slot)
 		return err
+	})
+	if err != nil {
+		run.Error = err.Error()
+		if vm != nil {
+			if err := vm.ShutdownVM(); err != nil {
+				log.Errorln(err)
+			}
+		}
+		return run
 	}
 	resp, err := vm.GetVMStatus()
 	if err != nil {
@@ -186,36 +348,107 @@ func Generate(f string) error {
 	}
 
 	log.Infof(resp)
-	defer func() {
-		if err := vm.ShutdownVM(); err != nil {
+
+	// 复现失败只记录日志，是否崩溃由串口日志判断
+	err = progress.Step("RunReproducer", func() error {
+		a, err := arch.Of(data)
+		if err != nil {
+			return err
+		}
+		ssh, err := SSHConnect(slot, vm.Accel())
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err := ssh.Close(); err != nil {
+				log.Errorln(err)
+			}
+		}()
+		if err := Kexec(ssh, a); err != nil {
 			log.Errorln(err)
+			progress.Warn("RunReproducer", "kexec: %v", err)
 		}
-	}()
 
-	ssh := SSHConnect()
-	if err = Kexec(ssh); err != nil {
-		log.Errorln(err)
+		// 有编译好的 syzkaller 时运行 syz 复现程序，否则编译运行 c 复现程序
+		bug := Bug
+		if compile.SyzReady(data) {
+			cmd, err := compile.SyzCommand(data)
+			if err != nil {
+				return err
+			}
+			bug = func(ssh *kvm.SSHManager) error { return SyzBug(ssh, cmd) }
+		} else if err := Gcc(ssh); err != nil {
+			log.Errorln(err)
+			progress.Warn("RunReproducer", "gcc: %v", err)
+		}
+
+		done := make(chan error, 1)
+		go func() { done <- bug(ssh) }()
+		// TCG 模拟运行时复现与 kdump 都慢得多，按减速倍数放宽等待时间
+		slowdown := time.Duration(vm.Slowdown())
+		waitReproducer(done, vm.Exited(), vm.Events(), timeout*slowdown, reproGrace*slowdown, index)
+
+		// 内核已经报告崩溃时等待 kdump 保存 vmcore 后重启
+		if console, err := os.ReadFile(slot.ConsoleLog()); err == nil && kvm.Crashed(console) {
+			select {
+			case <-vm.Exited():
+			case event := <-vm.Events():
+				log.Infof("guest %s after the crash", event.Name)
+			case <-time.After(kdumpTimeout * slowdown):
+				log.Warnf("vm still running %s after the crash", kdumpTimeout*slowdown)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		run.Error = err.Error()
 	}
-	if err = Gcc(ssh); err != nil {
+
+	if err := vm.ShutdownVM(); err != nil {
 		log.Errorln(err)
 	}
-	if err = Bug(ssh); err != nil {
-		log.Errorln(err)
+
+	if run.Crashed, err = kvm.SaveRun(data, slot, index); err != nil {
+		log.Warnln("failed to save console log of run", index, err)
 	}
-	time.Sleep(time.Minute)
+	return run
+}
 
-	if err = BuildKernel(&data); err != nil {
-		return err
+// waitReproducer 等待一次复现运行结束：超时、复现程序退出 grace 后仍未结束、虚拟机退出或收到客户机事件
+func waitReproducer(done <-chan error, exited <-chan struct{}, events <-chan kvm.Event, timeout, grace time.Duration, index int) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	var graceC <-chan time.Time
+	for {
+		select {
+		case err := <-done:
+			if err != nil {
+				log.Errorln(err)
+				progress.Warn("RunReproducer", "bug: %v", err)
+			}
+			done = nil
+			graceC = time.After(grace)
+		case <-graceC:
+			log.Infof("reproducer exited %s ago during run %d", grace, index)
+			return
+		case <-exited:
+			log.Infof("vm exited during run %d", index)
+			return
+		case event := <-events:
+			// 客户机关机、重启（kdump 保存 vmcore 后）、panic 时 kdump 未接管或被暂停，本次运行结束
+			log.Infof("guest %s during run %d", event.Name, index)
+			return
+		case <-timer.C:
+			return
+		}
 	}
-	log.Infoln("generate vmcore successfully!")
-	return nil
 }
 
 func Compress(f string) error {
 	log.Infof("starting compress with file: %s", f)
 
 	data := parse.Parse(f)
-	if err := compress.Compress(&data); err != nil {
+	if err := progress.Step("Compress", func() error { return compress.Compress(&data) }); err != nil {
 		log.Errorln(err)
 		return err
 	}
@@ -224,6 +457,24 @@ func Compress(f string) error {
 	return nil
 }
 
+// Slots 列出本机被占用的虚拟机槽位，用于排查端口与工作目录的分配
+func Slots() error {
+	slots, err := kvm.ListSlots()
+	if err != nil {
+		return err
+	}
+	if len(slots) == 0 {
+		fmt.Printf("no vm slot in use (%d slots)\n", config.GlobalConfig.VM.Slots)
+		return nil
+	}
+	fmt.Printf("%-5s %-8s %-8s %-8s %-20s %-40s %s\n", "SLOT", "PID", "SSH", "QMP", "SINCE", "COMMIT", "DIR")
+	for _, slot := range slots {
+		fmt.Printf("%-5d %-8d %-8d %-8d %-20s %-40s %s\n", slot.Index, slot.PID, slot.SSHPort, slot.QMPPort,
+			slot.Since.Format("2006-01-02 15:04:05"), slot.Commit, slot.Dir)
+	}
+	return nil
+}
+
 func Clean(f string) error {
 	log.Infof("starting clean with file: %s", f)
 
@@ -235,9 +486,258 @@ func Clean(f string) error {
 	return nil
 }
 
+// Patch 验证补丁系列：在基准源码树上按顺序检查并应用补丁，增量编译后重新运行复现程序；
+// 崩溃是否消失由 worker 上报的串口日志判断。补丁目录无论成败都会打包为产物
 func Patch(f string, path string) error {
 	log.Infof("starting patch with file: %s, path: %s", f, path)
-	// !TODO
+
+	data := parse.Parse(f)
+	series, err := patchSeries(&data, path)
+	if err != nil {
+		return err
+	}
+
+	compile.InitToolChain(&data)
+
+	if err := prepareTree(&data); err != nil {
+		return err
+	}
+
+	if err := compile.GeneratePatch(&data, series); err != nil {
+		log.Errorln(err)
+		return err
+	}
+	defer func() {
+		if err := compress.PatchResult(&data, compile.PatchDir(&data)); err != nil {
+			log.Errorln(err)
+		}
+	}()
+
+	if err := progress.Step("ApplyPatch", func() error { return compile.ApplySeries(&data, series) }); err != nil {
+		log.Errorln(err)
+		return err
+	}
+
+	sleep()
+
+	if err := progress.Step("RebuildKernel", func() error { return compile.RebuildKernel(&data) }); err != nil {
+		log.Errorln(err)
+		return err
+	}
+
+	sleep()
+
+	if err := Generate(f); err != nil {
+		return err
+	}
+
 	log.Infoln("patch apply successfully!")
 	return nil
 }
+
+// FixVerify 验证修复提交：用同一份配置和工具链分别编译修复提交的父提交与修复提交，
+// 各自运行复现程序；崩溃是否在修复提交上消失由 worker 上报的两次运行结果判断。
+// 两个提交的源码与其他任务一样从 torvalds/linux 的归档下载，只存在于子系统树中的修复提交无法下载
+func FixVerify(f string) error {
+	log.Infof("starting fix verify with file: %s", f)
+
+	data := parse.Parse(f)
+	if len(data.Crashes) == 0 {
+		return errors.New("no valid report data")
+	}
+	fixCommit := data.FixCommit()
+	if data.ParentOfFixCommit == "" || fixCommit == "" {
+		return errors.New("report has no parent_of_fix_commit or fix commit hash")
+	}
+
+	// 工具链取自漏洞报告中的编译器描述，两个提交相同
+	compile.InitToolChain(&data)
+
+	for _, commit := range []string{data.ParentOfFixCommit, fixCommit} {
+		if err := verifyAt(&data, commit); err != nil {
+			return err
+		}
+	}
+
+	log.Infoln("fix verify successfully!")
+	return nil
+}
+
+// verifyAt 在 commit 上编译内核并运行复现程序，期间占用 commit 的构建目录
+func verifyAt(data *parse.CrashReport, commit string) error {
+	l, err := lock.Commit(commit)
+	if err != nil {
+		return err
+	}
+	defer l.Release()
+
+	report := reportAt(data, commit)
+	log.Infof("verifying fix at commit %s", commit)
+
+	if err := prepareTree(report); err != nil {
+		return err
+	}
+
+	if err := progress.Step("MakeKernel", func() error { return compile.MakeKernel(report) }); err != nil {
+		log.Errorln(err)
+		return err
+	}
+
+	sleep()
+
+	return generate(report)
+}
+
+// Bisect 在 good 与 bad 之间二分查找引入崩溃的提交。先确认复现程序在 bad 上崩溃、在 good 上不崩溃，
+// 然后由 git bisect 选出候选提交，用漏洞报告的配置和工具链编译并运行复现程序：崩溃为 bad，
+// 没有崩溃为 good，无法下载、编译或启动的提交跳过。每一步的结果写入 build/bisect/<bad>/result.json
+func Bisect(f string) error {
+	log.Infof("starting bisect with file: %s", f)
+
+	data := parse.Parse(f)
+	if len(data.Crashes) == 0 {
+		return errors.New("no valid report data")
+	}
+	if data.Bisect == nil || data.Bisect.Good == "" || data.Bisect.Bad == "" {
+		return errors.New("report has no bisect range")
+	}
+	if data.Crashes[0].CReproducer == "" && data.Crashes[0].SyzReproducer == "" {
+		return errors.New("bisect needs a reproducer")
+	}
+
+	// 提交历史仓库中只有一份二分状态，同一台机器上的二分依次进行
+	repo, err := lock.Repo()
+	if err != nil {
+		return err
+	}
+	defer repo.Release()
+
+	dir := bisect.Dir(data.Bisect.Bad)
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	if err := progress.Step("FetchHistory", func() error { return bisect.FetchHistory(data.Bisect.Good, data.Bisect.Bad) }); err != nil {
+		log.Errorln(err)
+		return err
+	}
+
+	result := &bisect.Result{}
+	if result.Good, err = bisect.Resolve(data.Bisect.Good); err != nil {
+		return err
+	}
+	if result.Bad, err = bisect.Resolve(data.Bisect.Bad); err != nil {
+		return err
+	}
+
+	compile.InitToolChain(&data)
+
+	// 两端的结论不成立时二分没有意义
+	for _, end := range []struct{ commit, want string }{{result.Bad, bisect.Bad}, {result.Good, bisect.Good}} {
+		step := testCommit(&data, end.commit, dir)
+		result.Steps = append(result.Steps, step)
+		bisect.WriteResult(dir, result)
+		if err := bisect.CheckEnd(step, end.want); err != nil {
+			return err
+		}
+	}
+
+	defer bisect.Reset()
+	next, err := bisect.Start(result)
+	for err == nil && next != "" {
+		step := testCommit(&data, next, dir)
+		result.Steps = append(result.Steps, step)
+		bisect.WriteResult(dir, result)
+		next, err = bisect.Mark(result, step)
+	}
+	bisect.WriteResult(dir, result)
+	if err != nil {
+		log.Errorln(err)
+		return err
+	}
+
+	if result.Culprit != "" {
+		log.Infof("first bad commit: %s %s", result.Culprit, result.CulpritTitle)
+	} else {
+		log.Infof("first bad commit is one of the skipped commits: %s", strings.Join(result.Suspects, " "))
+	}
+	log.Infoln("bisect successfully!")
+	return nil
+}
+
+// testCommit 编译 commit 并运行复现程序，返回这一步的结论。本次新建的构建目录与虚拟机工作目录
+// 在结束后删除，只在二分目录中保留串口日志；之前任务留下的构建目录保持不变。
+// 期间占用 commit 的构建目录，其他 kernel-builder 正在使用时等待其释放；加锁本身失败的提交跳过
+func testCommit(data *parse.CrashReport, commit string, dir string) bisect.Step {
+	log.Infof("bisect: testing commit %s", commit)
+
+	step := bisect.Step{Commit: commit}
+	l, err := lock.Commit(commit)
+	if err != nil {
+		step.Verdict = bisect.Skip
+		step.Reason = err.Error()
+		return step
+	}
+	defer l.Release()
+
+	report := reportAt(data, commit)
+	buildDir := filepath.Join("build", commit)
+	_, statErr := os.Stat(buildDir)
+	created := os.IsNotExist(statErr)
+
+	err = prepareTree(report)
+	if err == nil {
+		err = progress.Step("MakeKernel", func() error { return compile.MakeKernel(report) })
+	}
+	if err == nil {
+		sleep()
+		err = generate(report)
+	}
+
+	if err == nil {
+		step.Crashed, step.VmcoreCaptured = bisect.Crashed(commit)
+	}
+	bisect.Judge(&step, err)
+	bisect.SaveConsole(dir, commit)
+	log.Infof("bisect: commit %s is %s", commit, step.Verdict)
+
+	if created {
+		if err := kvm.ClearImage(report, nil); err != nil {
+			log.Warnln("failed to clear image of", commit, err)
+		}
+		if err := os.RemoveAll(buildDir); err != nil {
+			log.Warnln("failed to remove", buildDir, err)
+		}
+	}
+	return step
+}
+
+// reportAt 复制漏洞报告并把内核提交替换为 commit，配置与复现程序保持不变
+func reportAt(data *parse.CrashReport, commit string) *parse.CrashReport {
+	report := *data
+	report.Crashes = slices.Clone(data.Crashes)
+	report.Crashes[0].KernelSourceCommit = commit
+	return &report
+}
+
+// patchSeries 取得要应用的补丁系列：-p 指定的补丁文件优先，其次是任务中的补丁系列，
+// 没有系列的旧任务只有一个 Patch
+func patchSeries(data *parse.CrashReport, path string) ([]parse.SeriesPatch, error) {
+	if path != "" {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return []parse.SeriesPatch{{Subject: filepath.Base(path), Diff: string(content)}}, nil
+	}
+	if len(data.Patches) > 0 {
+		return data.Patches, nil
+	}
+	if data.Patch != "" {
+		return []parse.SeriesPatch{{Subject: "patch", Diff: data.Patch}}, nil
+	}
+	return nil, errors.New("no patch given")
+}
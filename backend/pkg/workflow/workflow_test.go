@@ -0,0 +1,59 @@
+package workflow
+
+import (
+	"backend/pkg/kvm"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWaitReproducer(t *testing.T) {
+	const (
+		timeout = 2 * time.Second
+		grace   = 200 * time.Millisecond
+	)
+	tests := []struct {
+		name string
+		// exit 复现程序退出的时间，0 表示一直运行
+		exit time.Duration
+		err  error
+		// event 客户机事件到达的时间，0 表示没有事件
+		event    time.Duration
+		min, max time.Duration
+	}{
+		{name: "reproducer exits without a crash", exit: 50 * time.Millisecond, min: 250 * time.Millisecond, max: timeout / 2},
+		{name: "reproducer fails", exit: 50 * time.Millisecond, err: errors.New("exit status 1"), min: 250 * time.Millisecond, max: timeout / 2},
+		// 崩溃发生在复现程序退出后的等待期内，kdump 保存 vmcore 后重启
+		{name: "crash during the grace period", exit: 50 * time.Millisecond, event: 100 * time.Millisecond, min: 100 * time.Millisecond, max: 200 * time.Millisecond},
+		{name: "reproducer keeps running", min: timeout, max: timeout + time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			done := make(chan error, 1)
+			if tt.exit > 0 {
+				time.AfterFunc(tt.exit, func() { done <- tt.err })
+			}
+			events := make(chan kvm.Event, 1)
+			if tt.event > 0 {
+				time.AfterFunc(tt.event, func() { events <- kvm.Event{Name: "RESET"} })
+			}
+
+			start := time.Now()
+			waitReproducer(done, make(chan struct{}), events, timeout, grace, 0)
+			if elapsed := time.Since(start); elapsed < tt.min || elapsed > tt.max {
+				t.Errorf("run ended after %s, want between %s and %s", elapsed, tt.min, tt.max)
+			}
+		})
+	}
+}
+
+// 虚拟机退出时不再等待复现程序
+func TestWaitReproducerExited(t *testing.T) {
+	exited := make(chan struct{})
+	close(exited)
+	start := time.Now()
+	waitReproducer(make(chan error), exited, nil, time.Minute, time.Minute, 0)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("run ended %s after the vm exited", elapsed)
+	}
+}
@@ -16,6 +16,8 @@ ROOT_DIR=$(dirname "$SCRIPT_DIR")
 IMAGE_DIR="$ROOT_DIR/image"
 WORK_DIR="$ROOT_DIR/work"
 COMMIT_ID=$1
+# 虚拟机槽位的工作目录，缺省为 work/COMMIT_ID
+VM_DIR=${2:-$WORK_DIR/$COMMIT_ID}
 LINUX_BUILD_DIR="$ROOT_DIR/build/$COMMIT_ID/linux-$COMMIT_ID"
 
 if [ -z "$COMMIT_ID" ]; then
@@ -26,7 +28,11 @@ log "INFO" "Starting process with COMMIT_ID: $COMMIT_ID"
 
 cd "$WORK_DIR" || error_exit "Failed to change directory to $WORK_DIR"
 
-TARGET_DIR="$WORK_DIR/$COMMIT_ID"
+TARGET_DIR="$VM_DIR"
+if mountpoint -q "$TARGET_DIR/mnt"; then
+    log "INFO" "Unmounting $TARGET_DIR/mnt"
+    sudo umount "$TARGET_DIR/mnt" || error_exit "Failed to unmount $TARGET_DIR/mnt"
+fi
 if [ -d "$TARGET_DIR" ]; then
     log "INFO" "Removing existing directory: $TARGET_DIR"
     sudo rm -rf "$TARGET_DIR" || error_exit "Failed to remove directory: $TARGET_DIR"
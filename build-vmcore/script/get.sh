@@ -8,6 +8,10 @@ IMAGE_DIR="$ROOT_DIR/image"
 WORK_DIR="$ROOT_DIR/work"
 LOG_DIR="$ROOT_DIR/log"
 COMMIT_ID="${1:-}"
+# 虚拟机槽位的工作目录，缺省为 work/COMMIT_ID，其中的串口日志为 console.log
+VM_DIR="${2:-}"
+# 挂载取出 vmcore 的 raw 镜像，缺省为 VM_DIR 下的 debian.img；虚拟机从 qcow2 覆盖层启动时为合并后的镜像
+IMAGE_PATH="${3:-}"
 
 if [[ -z "$COMMIT_ID" ]]; then
   echo "缺少 COMMIT_ID 参数"
@@ -15,9 +19,16 @@ if [[ -z "$COMMIT_ID" ]]; then
 fi
 
 LINUX_BUILD_DIR="$ROOT_DIR/build/$COMMIT_ID/linux-$COMMIT_ID"
-IMAGE_PATH="$WORK_DIR/$COMMIT_ID/debian.img"
-MNT_DIR="$WORK_DIR/$COMMIT_ID/mnt"
-LOG_PATH="$LOG_DIR/$COMMIT_ID.log"
+if [[ -n "$VM_DIR" ]]; then
+  LOG_PATH="$VM_DIR/console.log"
+else
+  VM_DIR="$WORK_DIR/$COMMIT_ID"
+  LOG_PATH="$LOG_DIR/$COMMIT_ID.log"
+fi
+if [[ -z "$IMAGE_PATH" ]]; then
+  IMAGE_PATH="$VM_DIR/debian.img"
+fi
+MNT_DIR="$VM_DIR/mnt"
 
 if [[ ! -f "$IMAGE_PATH" ]]; then
   echo "镜像文件不存在: $IMAGE_PATH"
@@ -47,7 +58,7 @@ else
 fi
 
 if [[ -f "$LOG_PATH" ]]; then
-  mv "$LOG_PATH" "$LINUX_BUILD_DIR"
+  mv "$LOG_PATH" "$LINUX_BUILD_DIR/$COMMIT_ID.log"
   echo "日志目录已移动到: $LINUX_BUILD_DIR"
 else
   echo "日志目录不存在: $LOG_PATH"
@@ -24,6 +24,11 @@ ROOT_DIR=$(dirname "$SCRIPT_DIR")
 IMAGE_DIR="$ROOT_DIR/image"
 WORK_DIR="$ROOT_DIR/work"
 COMMIT_ID=$1
+# boot image relative to the kernel tree and guest image in image/, both depend on the architecture
+BOOT_IMAGE=${2:-arch/x86_64/boot/bzImage}
+GUEST_IMAGE=${3:-debian.img}
+# per-VM work directory of the slot kernel-builder allocated, defaults to work/COMMIT_ID
+VM_DIR=${4:-$WORK_DIR/$COMMIT_ID}
 LINUX_BUILD_DIR="$ROOT_DIR/build/$COMMIT_ID/linux-$COMMIT_ID"
 
 if [ -z "$COMMIT_ID" ]; then
@@ -32,15 +37,11 @@ fi
 
 log "INFO" "Starting process with COMMIT_ID: $COMMIT_ID"
 
-create_dir "$WORK_DIR"
+create_dir "$VM_DIR"
+cd "$VM_DIR" || error_exit "Failed to change directory to $VM_DIR"
 
-cd "$WORK_DIR" || error_exit "Failed to change directory to $WORK_DIR"
-
-create_dir "$WORK_DIR/$COMMIT_ID"
-cd "$COMMIT_ID" || error_exit "Failed to change directory to $WORK_DIR/$COMMIT_ID"
-
-log "INFO" "Copying debian.img..."
-rsync -av "$IMAGE_DIR/debian.img" ./ || error_exit "Failed to copy debian.img"
+log "INFO" "Copying $GUEST_IMAGE to debian.img..."
+rsync -av "$IMAGE_DIR/$GUEST_IMAGE" ./debian.img || error_exit "Failed to copy $GUEST_IMAGE"
 
 create_dir "mnt"
 
@@ -54,14 +55,21 @@ sudo rm -rf ./linux || error_exit "Failed to remove linux directory"
 sudo cp -R "$LINUX_BUILD_DIR/linux-header/include/asm" ./ || error_exit "Failed to copy asm headers"
 sudo cp -R "$LINUX_BUILD_DIR/linux-header/include/linux" ./ || error_exit "Failed to copy linux headers"
 
-log "INFO" "Copying bug.c to root..."
 cd ../../ || error_exit "Failed to return to mnt directory"
-sudo cp -R "$LINUX_BUILD_DIR/bug.c" ./root || error_exit "Failed to copy bug.c"
+if [ -f "$LINUX_BUILD_DIR/bug.c" ]; then
+    log "INFO" "Copying bug.c to root..."
+    sudo cp -R "$LINUX_BUILD_DIR/bug.c" ./root || error_exit "Failed to copy bug.c"
+fi
+if [ -f "$LINUX_BUILD_DIR/bug.syz" ] && [ -d "$LINUX_BUILD_DIR/syz" ]; then
+    log "INFO" "Copying bug.syz, syz-execprog and syz-executor to root..."
+    sudo cp "$LINUX_BUILD_DIR/bug.syz" ./root || error_exit "Failed to copy bug.syz"
+    sudo cp "$LINUX_BUILD_DIR/syz/syz-execprog" "$LINUX_BUILD_DIR/syz/syz-executor" ./root || error_exit "Failed to copy syzkaller binaries"
+fi
 
 log "INFO" "Unmounting debian.img..."
 cd ..
 sudo umount mnt || error_exit "Failed to unmount debian.img"
 
-cp "$LINUX_BUILD_DIR/arch/x86_64/boot/bzImage" ./
+cp "$LINUX_BUILD_DIR/$BOOT_IMAGE" ./ || error_exit "Failed to copy $BOOT_IMAGE"
 
 log "INFO" "Image has been successfully copied."
\ No newline at end of file
@@ -26,6 +26,7 @@ const LOCAL_STORAGE_GLOBAL_LOGS_KEY = 'kernelExperimentGlobalLogs';
 const LOCAL_STORAGE_THEME_KEY = 'kernelExperimentTheme';
 const LOCAL_STORAGE_EXPANDED_LOG_TASKS_KEY = 'kernelExperimentExpandedLogTasks';
 const LOCAL_STORAGE_TERMINALS_KEY = 'kernelExperimentTerminals';
+const SESSION_STORAGE_ADMIN_TOKEN_KEY = 'kernelExperimentAdminToken';
 
 const formatDate = (dateString) => {
   if (!dateString) return '-';
@@ -313,11 +314,20 @@ const KernelExperimentDashboard = () => {
     }
     const taskName = taskToDelete?.payload?.title || taskIdToDelete;
     if (window.confirm(`您确定要删除任务 "${taskName}" 吗？此操作不可撤销。`)) {
+      // 删除任务需要管理员令牌，输入一次后在本次会话中保留
+      const adminToken = sessionStorage.getItem(SESSION_STORAGE_ADMIN_TOKEN_KEY) || window.prompt('请输入管理员令牌：');
+      if (!adminToken) {
+        return;
+      }
       try {
-        await api.deleteTask(taskIdToDelete);
+        await api.deleteTask(taskIdToDelete, adminToken);
+        sessionStorage.setItem(SESSION_STORAGE_ADMIN_TOKEN_KEY, adminToken);
         alert('任务已从服务器删除!');
         await fetchTasks();
       } catch (err) {
+        if (err.response?.status === 401 || err.response?.status === 403) {
+          sessionStorage.removeItem(SESSION_STORAGE_ADMIN_TOKEN_KEY);
+        }
         alert(`删除失败: ${err.response?.data?.error || err.message}`);
       }
     }
@@ -44,12 +44,15 @@ export const getTasks = () => apiClient.get('/tasks');
 export const getTaskById = (taskId) => apiClient.get(`/tasks/${taskId}`);
 
 /**
- * 根据ID删除一个任务
+ * 根据ID删除一个任务，需要管理员令牌
  * DELETE /api/v1/tasks/:id
  * @param {string} taskId - 任务ID
+ * @param {string} adminToken - 服务器的 PLATFORM_ADMIN_TOKEN
  * @returns {Promise<any>}
  */
-export const deleteTask = (taskId) => apiClient.delete(`/tasks/${taskId}`);
+export const deleteTask = (taskId, adminToken) => apiClient.delete(`/tasks/${taskId}`, {
+  headers: { Authorization: `Bearer ${adminToken}` },
+});
 
 /**
  * 提交一个新任务
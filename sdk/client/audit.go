@@ -0,0 +1,42 @@
+package client
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// ListAudit queries the audit log, newest first; requires the admin token
+func (c *Client) ListAudit(ctx context.Context, opts ListAuditOptions) ([]AuditEntry, error) {
+	query := url.Values{}
+	for name, value := range map[string]string{
+		"actor_type":    opts.ActorType,
+		"actor_id":      opts.ActorID,
+		"action":        opts.Action,
+		"resource_type": opts.ResourceType,
+		"resource_id":   opts.ResourceID,
+	} {
+		if value != "" {
+			query.Set(name, value)
+		}
+	}
+	if !opts.Since.IsZero() {
+		query.Set("since", opts.Since.Format(time.RFC3339))
+	}
+	if !opts.Until.IsZero() {
+		query.Set("until", opts.Until.Format(time.RFC3339))
+	}
+	if opts.Limit > 0 {
+		query.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	if opts.Offset > 0 {
+		query.Set("offset", strconv.Itoa(opts.Offset))
+	}
+
+	var entries []AuditEntry
+	if err := c.getJSON(ctx, "/admin/audit", query, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
@@ -0,0 +1,73 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/websocket"
+)
+
+// StreamLogs follows the live log WebSocket and calls fn for every line of the
+// given task, or of all tasks when taskID is empty. Only lines produced after
+// the connection was established are seen. It returns when ctx is done, the
+// connection drops, or fn returns an error.
+func (c *Client) StreamLogs(ctx context.Context, taskID string, fn func(LogLine) error) error {
+	wsURL := strings.Replace(c.endpoint("/logs/ws", nil), "http", "ws", 1)
+
+	header := http.Header{}
+	c.mu.RLock()
+	if c.token != "" {
+		header.Set("Authorization", "Bearer "+c.token)
+	}
+	c.mu.RUnlock()
+
+	conn, resp, err := websocket.DefaultDialer.DialContext(ctx, wsURL, header)
+	if err != nil {
+		if resp != nil {
+			defer resp.Body.Close()
+			if apiErr := checkResponse(resp); apiErr != nil {
+				return apiErr
+			}
+		}
+		return fmt.Errorf("failed to connect to log stream: %w", err)
+	}
+	defer conn.Close()
+
+	// unblock ReadMessage when the caller gives up
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	for {
+		_, frame, err := conn.ReadMessage()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("log stream closed: %w", err)
+		}
+
+		// the server batches queued lines into one frame as concatenated JSON objects
+		decoder := json.NewDecoder(bytes.NewReader(frame))
+		for {
+			var line LogLine
+			if err := decoder.Decode(&line); err != nil {
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				return fmt.Errorf("invalid log message: %w", err)
+			}
+			if taskID != "" && line.TaskID != taskID {
+				continue
+			}
+			if err := fn(line); err != nil {
+				return err
+			}
+		}
+	}
+}
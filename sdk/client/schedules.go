@@ -0,0 +1,52 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+func (c *Client) ListSchedules(ctx context.Context) ([]Schedule, error) {
+	var schedules []Schedule
+	if err := c.getJSON(ctx, "/schedules", nil, &schedules); err != nil {
+		return nil, err
+	}
+	return schedules, nil
+}
+
+func (c *Client) GetSchedule(ctx context.Context, scheduleID string) (*Schedule, error) {
+	var schedule Schedule
+	if err := c.getJSON(ctx, "/schedules/"+url.PathEscape(scheduleID), nil, &schedule); err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+func (c *Client) CreateSchedule(ctx context.Context, in ScheduleRequest) (*Schedule, error) {
+	var schedule Schedule
+	if err := c.sendJSON(ctx, http.MethodPost, "/schedules", in, &schedule); err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+func (c *Client) UpdateSchedule(ctx context.Context, scheduleID string, in ScheduleRequest) (*Schedule, error) {
+	var schedule Schedule
+	if err := c.sendJSON(ctx, http.MethodPut, "/schedules/"+url.PathEscape(scheduleID), in, &schedule); err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+func (c *Client) DeleteSchedule(ctx context.Context, scheduleID string) error {
+	return c.sendJSON(ctx, http.MethodDelete, "/schedules/"+url.PathEscape(scheduleID), nil, nil)
+}
+
+// RunSchedule materializes the tasks of a schedule now, independent of its cron
+func (c *Client) RunSchedule(ctx context.Context, scheduleID string) (*ScheduleRun, error) {
+	var run ScheduleRun
+	if err := c.sendJSON(ctx, http.MethodPost, "/schedules/"+url.PathEscape(scheduleID)+"/run", nil, &run); err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
@@ -0,0 +1,304 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// CreateTask submits a task and returns it as queued
+func (c *Client) CreateTask(ctx context.Context, in CreateTaskRequest) (*Task, error) {
+	if in.Priority > MaxTaskPriority {
+		return nil, fmt.Errorf("priority must be between 0 and %d", MaxTaskPriority)
+	}
+
+	pr, pw := io.Pipe()
+	writer := multipart.NewWriter(pw)
+
+	go func() {
+		pw.CloseWithError(writeTaskForm(writer, in))
+	}()
+
+	req, err := c.newRequest(ctx, http.MethodPost, "/tasks", nil, pr)
+	if err != nil {
+		pr.Close()
+		return nil, err
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	var task Task
+	if err := c.send(req, &task); err != nil {
+		pr.Close()
+		return nil, err
+	}
+	return &task, nil
+}
+
+func writeTaskForm(writer *multipart.Writer, in CreateTaskRequest) error {
+	fields := map[string]string{
+		"task_type": string(in.Type),
+		"priority":  strconv.Itoa(int(in.Priority)),
+	}
+	if in.Campaign != "" {
+		fields["campaign"] = in.Campaign
+	}
+	if in.BaseTaskID != "" {
+		fields["id"] = in.BaseTaskID
+	}
+	for name, value := range fields {
+		if err := writer.WriteField(name, value); err != nil {
+			return err
+		}
+	}
+
+	switch in.Type {
+	case TaskTypeKernelBuild:
+		if in.Report == nil {
+			return errors.New("kernel-build task requires a crash report")
+		}
+		part, err := writer.CreateFormFile("report", "report.json")
+		if err != nil {
+			return err
+		}
+		if err := json.NewEncoder(part).Encode(in.Report); err != nil {
+			return err
+		}
+	case TaskTypePatchApply:
+		patches := in.Patches
+		if len(in.Patch) > 0 {
+			patches = append([]PatchUpload{{Name: "fix.patch", Data: in.Patch}}, patches...)
+		}
+		if in.BaseTaskID == "" || len(patches) == 0 {
+			return errors.New("patch-apply task requires a base task ID and a patch")
+		}
+		// the server applies the patch files in the order of the form
+		for _, patch := range patches {
+			part, err := writer.CreateFormFile("patch", patch.Name)
+			if err != nil {
+				return err
+			}
+			if _, err := part.Write(patch.Data); err != nil {
+				return err
+			}
+		}
+	case TaskTypeFixVerify, TaskTypeBisect:
+		if in.Report == nil && in.BaseTaskID == "" {
+			return fmt.Errorf("%s task requires a crash report or a base task ID", in.Type)
+		}
+		if in.Type == TaskTypeBisect {
+			if in.Bisect == nil || in.Bisect.Good == "" || in.Bisect.Bad == "" {
+				return errors.New("bisect task requires a good and a bad commit")
+			}
+			for name, value := range map[string]string{"good": in.Bisect.Good, "bad": in.Bisect.Bad} {
+				if err := writer.WriteField(name, value); err != nil {
+					return err
+				}
+			}
+		}
+		if in.Report != nil {
+			part, err := writer.CreateFormFile("report", "report.json")
+			if err != nil {
+				return err
+			}
+			if err := json.NewEncoder(part).Encode(in.Report); err != nil {
+				return err
+			}
+		}
+	}
+
+	return writer.Close()
+}
+
+func (c *Client) ListTasks(ctx context.Context, opts ListTasksOptions) ([]Task, error) {
+	query := url.Values{}
+	if opts.Status != "" {
+		query.Set("status", string(opts.Status))
+	}
+	if opts.Campaign != "" {
+		query.Set("campaign", opts.Campaign)
+	}
+	if opts.FailureCategory != "" {
+		query.Set("failure_category", string(opts.FailureCategory))
+	}
+	if opts.Verdict != "" {
+		query.Set("verdict", string(opts.Verdict))
+	}
+	if opts.Sort != "" {
+		query.Set("sort", opts.Sort)
+	}
+
+	var tasks []Task
+	if err := c.getJSON(ctx, "/tasks", query, &tasks); err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+func (c *Client) GetTask(ctx context.Context, taskID string) (*Task, error) {
+	var task Task
+	if err := c.getJSON(ctx, "/tasks/"+url.PathEscape(taskID), nil, &task); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// DeleteTask removes a task and its records, requires the admin token
+func (c *Client) DeleteTask(ctx context.Context, taskID string) error {
+	return c.sendJSON(ctx, http.MethodDelete, "/tasks/"+url.PathEscape(taskID), nil, nil)
+}
+
+// CancelTask stops a pending or running task, requires the admin token
+func (c *Client) CancelTask(ctx context.Context, taskID string) (*Task, error) {
+	var task Task
+	if err := c.sendJSON(ctx, http.MethodPost, "/tasks/"+url.PathEscape(taskID)+"/cancel", nil, &task); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// SetTaskPriority re-prioritizes a pending task, requires the admin token
+func (c *Client) SetTaskPriority(ctx context.Context, taskID string, priority uint8) (*Task, error) {
+	in := map[string]uint8{"priority": priority}
+	var task Task
+	if err := c.sendJSON(ctx, http.MethodPatch, "/admin/tasks/"+url.PathEscape(taskID)+"/priority", in, &task); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// AcceptTask claims a queued task for a worker. A conflict error means the task
+// is no longer pending or the queue message was stale.
+func (c *Client) AcceptTask(ctx context.Context, in AcceptTaskRequest) (*Task, error) {
+	form := url.Values{
+		"id":       {in.ID},
+		"revision": {strconv.Itoa(in.Revision)},
+	}
+	if in.WorkerID != "" {
+		form.Set("worker_id", in.WorkerID)
+	}
+	var task Task
+	if err := c.sendForm(ctx, "/tasks/accept", form, &task); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// UpdateTaskStatus reports the final status of a task run by the worker
+func (c *Client) UpdateTaskStatus(ctx context.Context, taskID string, in UpdateTaskStatusRequest) (*Task, error) {
+	var task Task
+	if err := c.sendJSON(ctx, http.MethodPatch, "/tasks/"+url.PathEscape(taskID), in, &task); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// GetTaskTimeline returns the status transitions and workflow steps of a task
+func (c *Client) GetTaskTimeline(ctx context.Context, taskID string) (*TaskTimeline, error) {
+	var timeline TaskTimeline
+	if err := c.sendJSON(ctx, http.MethodGet, "/tasks/"+url.PathEscape(taskID)+"/timeline", nil, &timeline); err != nil {
+		return nil, err
+	}
+	return &timeline, nil
+}
+
+// GetTaskProgress returns the live progress of a task
+func (c *Client) GetTaskProgress(ctx context.Context, taskID string) (*TaskProgress, error) {
+	var progress TaskProgress
+	if err := c.sendJSON(ctx, http.MethodGet, "/tasks/"+url.PathEscape(taskID)+"/progress", nil, &progress); err != nil {
+		return nil, err
+	}
+	return &progress, nil
+}
+
+// GetFailureStats counts failed tasks per campaign and failure category, an
+// empty campaign returns all campaigns
+func (c *Client) GetFailureStats(ctx context.Context, campaign string) ([]FailureStat, error) {
+	query := url.Values{}
+	if campaign != "" {
+		query.Set("campaign", campaign)
+	}
+	var stats []FailureStat
+	if err := c.getJSON(ctx, "/tasks/failures", query, &stats); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// CompareTasks compares two task runs side by side
+func (c *Client) CompareTasks(ctx context.Context, a, b string) (*TaskComparison, error) {
+	query := url.Values{"a": {a}, "b": {b}}
+	var comparison TaskComparison
+	if err := c.getJSON(ctx, "/tasks/compare", query, &comparison); err != nil {
+		return nil, err
+	}
+	return &comparison, nil
+}
+
+// ReportTaskEvent records a step boundary of a task run by the worker
+func (c *Client) ReportTaskEvent(ctx context.Context, taskID string, in ReportTaskEventRequest) (*TaskEvent, error) {
+	var event TaskEvent
+	if err := c.sendJSON(ctx, http.MethodPost, "/tasks/"+url.PathEscape(taskID)+"/events", in, &event); err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+// UploadArtifact streams an artifact of a task to the server without buffering it
+func (c *Client) UploadArtifact(ctx context.Context, taskID, fileName string, content io.Reader) error {
+	pr, pw := io.Pipe()
+	writer := multipart.NewWriter(pw)
+
+	go func() {
+		part, err := writer.CreateFormFile("artifact", fileName)
+		if err == nil {
+			_, err = io.Copy(part, content)
+		}
+		if err == nil {
+			err = writer.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+
+	req, err := c.newRequest(ctx, http.MethodPost, "/tasks/"+url.PathEscape(taskID)+"/artifact", nil, pr)
+	if err != nil {
+		pr.Close()
+		return err
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	if err := c.send(req, nil); err != nil {
+		pr.Close()
+		return err
+	}
+	return nil
+}
+
+// DownloadArtifact opens the artifact of a task; the caller closes the returned reader
+func (c *Client) DownloadArtifact(ctx context.Context, taskID string) (io.ReadCloser, string, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, "/artifacts/"+url.PathEscape(taskID), nil, nil)
+	if err != nil {
+		return nil, "", err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("GET %s: %w", req.URL.Path, err)
+	}
+	if err := checkResponse(resp); err != nil {
+		resp.Body.Close()
+		return nil, "", err
+	}
+
+	fileName := ""
+	if _, params, err := mime.ParseMediaType(resp.Header.Get("Content-Disposition")); err == nil {
+		fileName = params["filename"]
+	}
+	return resp.Body, fileName, nil
+}
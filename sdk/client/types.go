@@ -0,0 +1,685 @@
+package client
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type TaskType string
+
+const (
+	TaskTypeKernelBuild TaskType = "kernel-build"
+	TaskTypePatchApply  TaskType = "patch-apply"
+	TaskTypeFixVerify   TaskType = "fix-verify"
+	TaskTypeBisect      TaskType = "bisect"
+)
+
+type TaskStatus string
+
+const (
+	StatusPending   TaskStatus = "pending"
+	StatusRunning   TaskStatus = "running"
+	StatusSuccess   TaskStatus = "success"
+	StatusFailed    TaskStatus = "failed"
+	StatusCancelled TaskStatus = "cancelled"
+)
+
+// Finished reports whether the task reached a terminal status
+func (s TaskStatus) Finished() bool {
+	return s == StatusSuccess || s == StatusFailed || s == StatusCancelled
+}
+
+type FailureCategory string
+
+const (
+	FailureDownload       FailureCategory = "download_failed"
+	FailureConfig         FailureCategory = "config_error"
+	FailureCompiler       FailureCategory = "compiler_error"
+	FailureToolchain      FailureCategory = "missing_toolchain"
+	FailureHeadersInstall FailureCategory = "headers_install_failed"
+	FailureVMBootTimeout  FailureCategory = "vm_boot_timeout"
+	FailureKdumpNotLoaded FailureCategory = "kdump_not_loaded"
+	FailureNoVmcore       FailureCategory = "no_vmcore"
+	FailureArtifactUpload FailureCategory = "artifact_upload_failed"
+	FailurePatchApply     FailureCategory = "patch_apply_failed"
+	FailureUnknown        FailureCategory = "unknown"
+)
+
+const (
+	PriorityLow     uint8 = 0
+	PriorityNormal  uint8 = 5
+	PriorityUrgent  uint8 = 9
+	MaxTaskPriority uint8 = PriorityUrgent
+)
+
+type FixCommit struct {
+	Title  string `json:"title"`
+	Link   string `json:"link"`
+	Hash   string `json:"hash"`
+	Repo   string `json:"repo"`
+	Branch string `json:"branch"`
+}
+
+type Crash struct {
+	Title               string `json:"title"`
+	SyzReproducer       string `json:"syz-reproducer"`
+	CReproducer         string `json:"c-reproducer"`
+	KernelConfig        string `json:"kernel-config"`
+	KernelSourceGit     string `json:"kernel-source-git"`
+	KernelSourceCommit  string `json:"kernel-source-commit"`
+	SyzkallerGit        string `json:"syzkaller-git"`
+	SyzkallerCommit     string `json:"syzkaller-commit"`
+	CompilerDescription string `json:"compiler-description"`
+	Architecture        string `json:"architecture"`
+	CrashReportLink     string `json:"crash-report-link"`
+}
+
+// CrashReport is a syzbot bug report as exported by syzkaller.appspot.com with ?json=1
+type CrashReport struct {
+	Version           int           `json:"version"`
+	Title             string        `json:"title"`
+	DisplayTitle      string        `json:"display-title"`
+	ID                string        `json:"id"`
+	Status            string        `json:"status"`
+	FixCommits        []FixCommit   `json:"fix-commits"`
+	Discussions       []string      `json:"discussions"`
+	Crashes           []Crash       `json:"crashes"`
+	Subsystems        []string      `json:"subsystems"`
+	ParentOfFixCommit string        `json:"parent_of_fix_commit"`
+	Patch             string        `json:"patch"`
+	PatchModified     []string      `json:"patch_modified_files"`
+	Patches           []SeriesPatch `json:"patches,omitempty"` // the series Patch was assembled from, in order
+	Bisect            *BisectRange  `json:"bisect,omitempty"`
+}
+
+// BisectRange is the commit range of a bisect task: the reproducer must not
+// crash the kernel at Good and must crash it at Bad
+type BisectRange struct {
+	Good string `json:"good"`
+	Bad  string `json:"bad"`
+}
+
+// FixCommit is the hash of the first fix commit that has one, or "" when the
+// bug has no known fix
+func (cr *CrashReport) FixCommit() string {
+	for _, fix := range cr.FixCommits {
+		if fix.Hash != "" {
+			return fix.Hash
+		}
+	}
+	return ""
+}
+
+// SeriesPatch is one patch of a series with the subject of its mail, or the
+// name of the uploaded file when it has no mail headers
+type SeriesPatch struct {
+	Subject string `json:"subject"`
+	Diff    string `json:"diff"`
+}
+
+type Task struct {
+	ID           string         `json:"id"`
+	Type         TaskType       `json:"type"`
+	Status       TaskStatus     `json:"status"`
+	Priority     uint8          `json:"priority"`
+	Revision     int            `json:"revision"`
+	Campaign     string         `json:"campaign"`
+	ScheduleID   *string        `json:"schedule_id"`
+	Payload      CrashReport    `json:"payload"`
+	PatchSummary *PatchSummary  `json:"patch_summary"`
+	WorkerID     string         `json:"worker_id"`
+	Result       *TaskResult    `json:"result"`
+	Failure      *TaskFailure   `json:"failure"`
+	Console      *ConsoleReport `json:"console_report"`
+	ArtifactPath string         `json:"artifact_path"`
+	ArtifactName string         `json:"artifact_name"`
+	CreatedAt    time.Time      `json:"created_at"`
+	StartedAt    *time.Time     `json:"started_at"`
+	FinishedAt   *time.Time     `json:"finished_at"`
+}
+
+// CreateTaskRequest submits a new task. Kernel builds need Report, patch
+// applications need BaseTaskID and Patch or a series in Patches, applied in
+// order. Each patch may be a unified diff or git format-patch output. Fix
+// verifications and bisections take the bug from Report or from the task
+// BaseTaskID; bisections also need Bisect and a report with a C or syz reproducer.
+type CreateTaskRequest struct {
+	Type       TaskType
+	Priority   uint8
+	Campaign   string
+	Report     *CrashReport
+	BaseTaskID string
+	Patch      []byte
+	Patches    []PatchUpload
+	Bisect     *BisectRange
+}
+
+// PatchUpload is one patch file of a series; Name stands in for the subject
+// when the patch has no mail headers
+type PatchUpload struct {
+	Name string
+	Data []byte
+}
+
+type ListTasksOptions struct {
+	Status          TaskStatus
+	Campaign        string
+	FailureCategory FailureCategory
+	Verdict         Verdict
+	Sort            string // "created_at" (default) or "priority"
+}
+
+type AcceptTaskRequest struct {
+	ID string
+	// WorkerID is optional, the task goes to the worker whose key authenticates the request
+	WorkerID string
+	Revision int
+}
+
+// UpdateTaskStatusRequest reports the end of a task, Failure is only accepted
+// together with StatusFailed
+type UpdateTaskStatusRequest struct {
+	Status  TaskStatus     `json:"status"`
+	Result  TaskResult     `json:"result"`
+	Failure *TaskFailure   `json:"failure,omitempty"`
+	Console *ConsoleReport `json:"console_report,omitempty"`
+
+	// effective .config and warning and error lines, used to compare runs
+	KernelConfig string   `json:"kernel_config,omitempty"`
+	Diagnostics  []string `json:"diagnostics,omitempty"`
+}
+
+type RegisterWorkerRequest struct {
+	WorkerID string `json:"worker_id"`
+	APIKey   string `json:"api_key,omitempty"`
+	Hostname string `json:"hostname"`
+}
+
+// RegisterWorkerResponse carries the API key of the worker; for a newly created
+// worker this is the only time the key is returned
+type RegisterWorkerResponse struct {
+	Status   string `json:"status"`
+	Message  string `json:"message"`
+	WorkerID string `json:"worker_id"`
+	APIKey   string `json:"api_key"`
+}
+
+type Schedule struct {
+	ID            string     `json:"id"`
+	Name          string     `json:"name"`
+	Cron          string     `json:"cron"`
+	Enabled       bool       `json:"enabled"`
+	Priority      uint8      `json:"priority"`
+	Subsystem     string     `json:"subsystem"`
+	Campaign      string     `json:"campaign"`
+	BugStatus     string     `json:"bug_status"`
+	KernelCommit  string     `json:"kernel_commit"`
+	LastRunAt     *time.Time `json:"last_run_at"`
+	LastRunStatus string     `json:"last_run_status"`
+	LastRunTasks  int        `json:"last_run_tasks"`
+	NextRunAt     *time.Time `json:"next_run_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+type ScheduleRequest struct {
+	Name         string `json:"name"`
+	Cron         string `json:"cron"`
+	Enabled      *bool  `json:"enabled,omitempty"`
+	Priority     uint8  `json:"priority"`
+	Subsystem    string `json:"subsystem,omitempty"`
+	Campaign     string `json:"campaign,omitempty"`
+	BugStatus    string `json:"bug_status,omitempty"`
+	KernelCommit string `json:"kernel_commit,omitempty"` // KernelCommitLatest rebuilds at the upstream head of every run
+}
+
+// KernelCommitLatest as ScheduleRequest.KernelCommit resolves the upstream head at every run
+const KernelCommitLatest = "latest"
+
+type ScheduleRun struct {
+	ScheduleID string `json:"schedule_id"`
+	Tasks      []Task `json:"tasks"`
+}
+
+type Webhook struct {
+	ID        string    `json:"id"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type WebhookRequest struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+	Secret string   `json:"secret,omitempty"`
+	Active *bool    `json:"active,omitempty"`
+}
+
+// CreatedWebhook is returned once on registration, Secret is not readable later
+type CreatedWebhook struct {
+	Webhook Webhook `json:"webhook"`
+	Secret  string  `json:"secret"`
+}
+
+type WebhookDelivery struct {
+	ID            string          `json:"id"`
+	WebhookID     string          `json:"webhook_id"`
+	Event         string          `json:"event"`
+	Payload       json.RawMessage `json:"payload"`
+	Status        string          `json:"status"`
+	Attempts      int             `json:"attempts"`
+	ResponseCode  int             `json:"response_code"`
+	LastError     string          `json:"last_error"`
+	RedeliveryOf  *string         `json:"redelivery_of"`
+	NextAttemptAt *time.Time      `json:"next_attempt_at"`
+	DeliveredAt   *time.Time      `json:"delivered_at"`
+	CreatedAt     time.Time       `json:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+}
+
+type AuditEntry struct {
+	ID           string          `json:"id"`
+	ActorType    string          `json:"actor_type"`
+	ActorID      string          `json:"actor_id"`
+	IP           string          `json:"ip"`
+	Action       string          `json:"action"`
+	ResourceType string          `json:"resource_type"`
+	ResourceID   string          `json:"resource_id"`
+	Before       json.RawMessage `json:"before"`
+	After        json.RawMessage `json:"after"`
+	Diff         json.RawMessage `json:"diff"`
+	CreatedAt    time.Time       `json:"created_at"`
+}
+
+type ListAuditOptions struct {
+	ActorType    string
+	ActorID      string
+	Action       string
+	ResourceType string
+	ResourceID   string
+	Since        time.Time
+	Until        time.Time
+	Limit        int
+	Offset       int
+}
+
+type RotatedKey struct {
+	WorkerID string `json:"worker_id"`
+	APIKey   string `json:"api_key"`
+}
+
+type WorkerInfo struct {
+	WorkerID  string     `json:"worker_id"`
+	Hostname  string     `json:"hostname"`
+	Status    string     `json:"status"`
+	Online    bool       `json:"online"`
+	LastSeen  *time.Time `json:"last_seen"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type TaskEventKind string
+
+const (
+	TaskEventStatus     TaskEventKind = "status"
+	TaskEventStepStart  TaskEventKind = "step_start"
+	TaskEventStepFinish TaskEventKind = "step_finish"
+)
+
+type TaskEvent struct {
+	ID         string        `json:"id"`
+	TaskID     string        `json:"task_id"`
+	Kind       TaskEventKind `json:"kind"`
+	FromStatus TaskStatus    `json:"from_status"`
+	ToStatus   TaskStatus    `json:"to_status"`
+	Step       string        `json:"step"`
+	DurationMs *int64        `json:"duration_ms"`
+	Error      string        `json:"error"`
+	Source     string        `json:"source"`
+	OccurredAt time.Time     `json:"occurred_at"`
+	CreatedAt  time.Time     `json:"created_at"`
+}
+
+type StepSpan struct {
+	Step       string     `json:"step"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+	DurationMs *int64     `json:"duration_ms"`
+	Error      string     `json:"error"`
+}
+
+type TaskTimeline struct {
+	TaskID string      `json:"task_id"`
+	Status TaskStatus  `json:"status"`
+	Events []TaskEvent `json:"events"`
+	Steps  []StepSpan  `json:"steps"`
+}
+
+// TaskProgress is the live progress of a task, Percent is nil while the total
+// of the current step is unknown
+type TaskProgress struct {
+	TaskID       string            `json:"task_id"`
+	Step         string            `json:"step"`
+	StepState    string            `json:"step_state"`
+	Percent      *float64          `json:"percent"`
+	Current      int64             `json:"current"`
+	Total        int64             `json:"total"`
+	Warnings     []ProgressWarning `json:"warnings"`
+	WarningCount int               `json:"warning_count"`
+	UpdatedAt    *time.Time        `json:"updated_at"`
+}
+
+type ProgressWarning struct {
+	Step       string    `json:"step"`
+	Message    string    `json:"message"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+type Verdict string
+
+const (
+	VerdictReproduced     Verdict = "reproduced"
+	VerdictDifferentCrash Verdict = "different_crash"
+	VerdictNotReproduced  Verdict = "not_reproduced"
+)
+
+// TaskResult is the outcome of a task. Workers report Message, Crashed and
+// VmcoreCaptured; the server fills in the titles and the Verdict, which stays
+// empty when the task failed before the guest could crash. CrashGone tells for
+// patch-apply tasks whether the patch fixed the crash and Patches how each
+// patch of the series applied. FixVerify holds the two runs of a fix-verify
+// task and Bisect the steps of a bisection. Runs lists each run of the
+// reproducer and CrashRate the share of started runs that crashed. Accel is
+// kvm or tcg once the task booted a VM.
+type TaskResult struct {
+	Message        string             `json:"message"`
+	Crashed        bool               `json:"crashed"`
+	VmcoreCaptured bool               `json:"vmcore_captured"`
+	CrashTitle     string             `json:"crash_title,omitempty"`
+	ExpectedTitle  string             `json:"expected_title,omitempty"`
+	Verdict        Verdict            `json:"verdict,omitempty"`
+	CrashGone      *bool              `json:"crash_gone,omitempty"`
+	Patches        []PatchApplyResult `json:"patches,omitempty"`
+	FixVerify      *FixVerification   `json:"fix_verify,omitempty"`
+	Bisect         *BisectResult      `json:"bisect,omitempty"`
+	Runs           []ReproRun         `json:"runs,omitempty"`
+	CrashRate      *float64           `json:"crash_rate,omitempty"`
+	Accel          string             `json:"accel,omitempty"`
+}
+
+// ReproRun is one run of the reproducer in a freshly booted VM. Runs with an
+// Error never started the reproducer and have no verdict; only the first run
+// that crashed captures a vmcore.
+type ReproRun struct {
+	Index          int            `json:"index"`
+	Crashed        bool           `json:"crashed"`
+	VmcoreCaptured bool           `json:"vmcore_captured"`
+	DurationMs     int64          `json:"duration_ms"`
+	Error          string         `json:"error,omitempty"`
+	Console        *ConsoleReport `json:"console,omitempty"`
+	CrashTitle     string         `json:"crash_title,omitempty"`
+	Verdict        Verdict        `json:"verdict,omitempty"`
+}
+
+// FixVerification compares the reproducer runs at the parent of the fix commit
+// and at the fix commit. A run is nil when the task failed before it; Fixed is
+// only set when the crash reproduced at the parent and the fix run tells
+// whether it is gone.
+type FixVerification struct {
+	ParentCommit string  `json:"parent_commit"`
+	FixCommit    string  `json:"fix_commit"`
+	Parent       *FixRun `json:"parent,omitempty"`
+	Fix          *FixRun `json:"fix,omitempty"`
+	Fixed        *bool   `json:"fixed,omitempty"`
+}
+
+type BisectVerdict string
+
+const (
+	BisectGood BisectVerdict = "good"
+	BisectBad  BisectVerdict = "bad"
+	BisectSkip BisectVerdict = "skip"
+)
+
+// BisectStep is one commit tested by a bisect task. Any kernel report counts
+// as bad; Reproduction tells whether it was the crash of the report.
+type BisectStep struct {
+	Commit         string         `json:"commit"`
+	Verdict        BisectVerdict  `json:"verdict"`
+	Reason         string         `json:"reason,omitempty"`
+	Crashed        bool           `json:"crashed"`
+	VmcoreCaptured bool           `json:"vmcore_captured"`
+	Console        *ConsoleReport `json:"console,omitempty"`
+	CrashTitle     string         `json:"crash_title,omitempty"`
+	Reproduction   Verdict        `json:"reproduction,omitempty"`
+}
+
+// BisectResult lists the commits a bisect task tested, the bad and the good
+// commit first, and the first bad commit. Suspects is set instead of Culprit
+// when skipped commits leave several candidates.
+type BisectResult struct {
+	Good         string       `json:"good"`
+	Bad          string       `json:"bad"`
+	Steps        []BisectStep `json:"steps"`
+	Culprit      string       `json:"culprit,omitempty"`
+	CulpritTitle string       `json:"culprit_title,omitempty"`
+	Suspects     []string     `json:"suspects,omitempty"`
+}
+
+// FixRun is the reproducer run of a fix-verify task at one commit
+type FixRun struct {
+	Crashed        bool           `json:"crashed"`
+	VmcoreCaptured bool           `json:"vmcore_captured"`
+	Console        *ConsoleReport `json:"console,omitempty"`
+	CrashTitle     string         `json:"crash_title,omitempty"`
+	Verdict        Verdict        `json:"verdict,omitempty"`
+}
+
+// UnmarshalJSON also accepts the plain string results of older servers
+func (r *TaskResult) UnmarshalJSON(data []byte) error {
+	var message string
+	if err := json.Unmarshal(data, &message); err == nil {
+		*r = TaskResult{Message: message}
+		return nil
+	}
+
+	type plain TaskResult
+	return json.Unmarshal(data, (*plain)(r))
+}
+
+// TaskFailure explains why a task failed, File and Line are set for compiler
+// errors
+type TaskFailure struct {
+	Category FailureCategory `json:"category"`
+	Step     string          `json:"step"`
+	Message  string          `json:"message"`
+	File     string          `json:"file,omitempty"`
+	Line     int             `json:"line,omitempty"`
+}
+
+// FailureStat is the number of failed tasks of one category within a campaign
+type FailureStat struct {
+	Campaign string          `json:"campaign"`
+	Category FailureCategory `json:"category"`
+	Count    int64           `json:"count"`
+}
+
+type ReportKind string
+
+const (
+	ReportKASAN      ReportKind = "kasan"
+	ReportKCSAN      ReportKind = "kcsan"
+	ReportKMSAN      ReportKind = "kmsan"
+	ReportUBSAN      ReportKind = "ubsan"
+	ReportBUG        ReportKind = "bug"
+	ReportWARNING    ReportKind = "warning"
+	ReportGPF        ReportKind = "gpf"
+	ReportLockdep    ReportKind = "lockdep"
+	ReportHungTask   ReportKind = "hung_task"
+	ReportRCUStall   ReportKind = "rcu_stall"
+	ReportSoftLockup ReportKind = "soft_lockup"
+	ReportPanic      ReportKind = "panic"
+)
+
+// StackFrame is one symbolized frame such as "kfree+0x8b/0x250 [ext4]"
+type StackFrame struct {
+	Function string `json:"function"`
+	Offset   uint64 `json:"offset"`
+	Size     uint64 `json:"size"`
+	Module   string `json:"module,omitempty"`
+	File     string `json:"file,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Inline   bool   `json:"inline,omitempty"`
+}
+
+// ConsoleReport is the first kernel report found in the serial console of the
+// reproducer VM, OtherTitles lists the reports that followed it
+type ConsoleReport struct {
+	Kind        ReportKind   `json:"kind"`
+	Title       string       `json:"title"`
+	Access      string       `json:"access,omitempty"`
+	Address     string       `json:"address,omitempty"`
+	Size        int          `json:"size,omitempty"`
+	Frames      []StackFrame `json:"frames"`
+	AllocStack  []StackFrame `json:"alloc_stack,omitempty"`
+	FreeStack   []StackFrame `json:"free_stack,omitempty"`
+	Text        string       `json:"text"`
+	OtherTitles []string     `json:"other_titles,omitempty"`
+}
+
+// TaskSummary identifies one side of a TaskComparison
+type TaskSummary struct {
+	ID         string     `json:"id"`
+	Type       TaskType   `json:"type"`
+	Status     TaskStatus `json:"status"`
+	Campaign   string     `json:"campaign"`
+	BugID      string     `json:"bug_id"`
+	Title      string     `json:"title"`
+	Commit     string     `json:"commit"`
+	Compiler   string     `json:"compiler"`
+	Verdict    Verdict    `json:"verdict"`
+	CrashTitle string     `json:"crash_title"`
+}
+
+// ValueDiff is a value that differs between the two tasks, empty when unset
+type ValueDiff struct {
+	Name string `json:"name"`
+	A    string `json:"a"`
+	B    string `json:"b"`
+}
+
+// StepDelta compares the duration of a workflow step, DeltaMs is B minus A
+type StepDelta struct {
+	Step    string `json:"step"`
+	AMs     *int64 `json:"a_ms"`
+	BMs     *int64 `json:"b_ms"`
+	DeltaMs *int64 `json:"delta_ms"`
+	AError  string `json:"a_error,omitempty"`
+	BError  string `json:"b_error,omitempty"`
+}
+
+type StackDiff struct {
+	Same  bool     `json:"same"`
+	OnlyA []string `json:"only_a"`
+	OnlyB []string `json:"only_b"`
+}
+
+type CrashDiff struct {
+	ATitle     string    `json:"a_title"`
+	BTitle     string    `json:"b_title"`
+	SameTitle  bool      `json:"same_title"`
+	Frames     StackDiff `json:"frames"`
+	AllocStack StackDiff `json:"alloc_stack"`
+	FreeStack  StackDiff `json:"free_stack"`
+}
+
+type LineCount struct {
+	Line  string `json:"line"`
+	Count int    `json:"count"`
+}
+
+type LogDiff struct {
+	OnlyA  []LineCount `json:"only_a"`
+	OnlyB  []LineCount `json:"only_b"`
+	Common int         `json:"common"`
+}
+
+type PatchFileStatus string
+
+const (
+	PatchFileModified PatchFileStatus = "modified"
+	PatchFileAdded    PatchFileStatus = "added"
+	PatchFileDeleted  PatchFileStatus = "deleted"
+	PatchFileRenamed  PatchFileStatus = "renamed"
+)
+
+// PatchFile is one file touched by a patch. OldPath is only set for renames.
+type PatchFile struct {
+	Path      string          `json:"path"`
+	OldPath   string          `json:"old_path,omitempty"`
+	Status    PatchFileStatus `json:"status"`
+	Hunks     int             `json:"hunks"`
+	Additions int             `json:"additions"`
+	Deletions int             `json:"deletions"`
+}
+
+// PatchSummary describes the patch of a patch-apply task as parsed by the
+// server when the task was created. For a series the files and counts add up
+// all patches and Series holds the summary of each one.
+type PatchSummary struct {
+	Subject   string         `json:"subject,omitempty"`
+	Files     []PatchFile    `json:"files"`
+	Hunks     int            `json:"hunks"`
+	Additions int            `json:"additions"`
+	Deletions int            `json:"deletions"`
+	Series    []PatchSummary `json:"series,omitempty"`
+}
+
+type PatchApplyStatus string
+
+const (
+	PatchApplied PatchApplyStatus = "applied"
+	PatchFailed  PatchApplyStatus = "failed"
+	PatchSkipped PatchApplyStatus = "skipped" // after the first failed patch
+)
+
+// PatchApplyResult tells how one patch of a series applied on the worker;
+// Index counts from 1
+type PatchApplyResult struct {
+	Index   int              `json:"index"`
+	Subject string           `json:"subject"`
+	Status  PatchApplyStatus `json:"status"`
+	Error   string           `json:"error,omitempty"`
+}
+
+// TaskComparison is the side-by-side comparison of two task runs, Crash is nil
+// when neither guest crashed
+type TaskComparison struct {
+	A           TaskSummary `json:"a"`
+	B           TaskSummary `json:"b"`
+	Config      []ValueDiff `json:"config"`
+	Toolchain   []ValueDiff `json:"toolchain"`
+	Steps       []StepDelta `json:"steps"`
+	SameVerdict bool        `json:"same_verdict"`
+	Crash       *CrashDiff  `json:"crash"`
+	Log         LogDiff     `json:"log"`
+	Notes       []string    `json:"notes"`
+}
+
+// ReportTaskEventRequest is a workflow step boundary; the server computes the
+// duration of a finished step from its start when DurationMs is nil
+type ReportTaskEventRequest struct {
+	Kind       TaskEventKind `json:"kind"`
+	Step       string        `json:"step"`
+	OccurredAt *time.Time    `json:"occurred_at,omitempty"`
+	DurationMs *int64        `json:"duration_ms,omitempty"`
+	Error      string        `json:"error,omitempty"`
+}
+
+// LogLine is one line of task output as broadcast on the log WebSocket
+type LogLine struct {
+	Time    string `json:"time"`
+	Message string `json:"message"`
+	TaskID  string `json:"taskId"`
+}
@@ -0,0 +1,71 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+func (c *Client) ListWebhooks(ctx context.Context) ([]Webhook, error) {
+	var webhooks []Webhook
+	if err := c.getJSON(ctx, "/webhooks", nil, &webhooks); err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+func (c *Client) GetWebhook(ctx context.Context, webhookID string) (*Webhook, error) {
+	var webhook Webhook
+	if err := c.getJSON(ctx, "/webhooks/"+url.PathEscape(webhookID), nil, &webhook); err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+func (c *Client) CreateWebhook(ctx context.Context, in WebhookRequest) (*CreatedWebhook, error) {
+	var created CreatedWebhook
+	if err := c.sendJSON(ctx, http.MethodPost, "/webhooks", in, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+func (c *Client) UpdateWebhook(ctx context.Context, webhookID string, in WebhookRequest) (*Webhook, error) {
+	var webhook Webhook
+	if err := c.sendJSON(ctx, http.MethodPut, "/webhooks/"+url.PathEscape(webhookID), in, &webhook); err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+func (c *Client) DeleteWebhook(ctx context.Context, webhookID string) error {
+	return c.sendJSON(ctx, http.MethodDelete, "/webhooks/"+url.PathEscape(webhookID), nil, nil)
+}
+
+// ListWebhookDeliveries returns the delivery log of a webhook, newest first;
+// status and limit are optional
+func (c *Client) ListWebhookDeliveries(ctx context.Context, webhookID, status string, limit int) ([]WebhookDelivery, error) {
+	query := url.Values{}
+	if status != "" {
+		query.Set("status", status)
+	}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+
+	var deliveries []WebhookDelivery
+	if err := c.getJSON(ctx, "/webhooks/"+url.PathEscape(webhookID)+"/deliveries", query, &deliveries); err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+func (c *Client) RedeliverWebhook(ctx context.Context, webhookID, deliveryID string) (*WebhookDelivery, error) {
+	path := "/webhooks/" + url.PathEscape(webhookID) + "/deliveries/" + url.PathEscape(deliveryID) + "/redeliver"
+	var delivery WebhookDelivery
+	if err := c.sendJSON(ctx, http.MethodPost, path, nil, &delivery); err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
@@ -0,0 +1,43 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+func (c *Client) ListWorkers(ctx context.Context) ([]WorkerInfo, error) {
+	var workers []WorkerInfo
+	if err := c.getJSON(ctx, "/workers", nil, &workers); err != nil {
+		return nil, err
+	}
+	return workers, nil
+}
+
+// RegisterWorker registers a new worker, or brings an existing one online when
+// in.APIKey is set
+func (c *Client) RegisterWorker(ctx context.Context, in RegisterWorkerRequest) (*RegisterWorkerResponse, error) {
+	var out RegisterWorkerResponse
+	if err := c.sendJSON(ctx, http.MethodPost, "/workers/register", in, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (c *Client) UnregisterWorker(ctx context.Context, in RegisterWorkerRequest) error {
+	return c.sendJSON(ctx, http.MethodPost, "/workers/unregister", in, nil)
+}
+
+// Ping keeps the authenticated worker online
+func (c *Client) Ping(ctx context.Context) error {
+	return c.sendJSON(ctx, http.MethodPost, "/workers/ping", nil, nil)
+}
+
+// RotateWorkerKey issues a new API key for a worker, requires the admin token
+func (c *Client) RotateWorkerKey(ctx context.Context, workerID string) (*RotatedKey, error) {
+	var out RotatedKey
+	if err := c.sendJSON(ctx, http.MethodPost, "/admin/workers/"+url.PathEscape(workerID)+"/rotate-key", nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
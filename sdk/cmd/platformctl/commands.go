@@ -0,0 +1,476 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"sdk/client"
+	"sdk/tail"
+)
+
+const (
+	syzbotURL    = "https://syzkaller.appspot.com"
+	pollInterval = 10 * time.Second
+)
+
+var bugHashPattern = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// parseFlags parses the flags of a subcommand and checks the number of positional arguments
+// stringsFlag collects the values of a repeated flag in order
+type stringsFlag []string
+
+func (f *stringsFlag) String() string { return strings.Join(*f, ",") }
+
+func (f *stringsFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+func parseFlags(fs *flag.FlagSet, args []string, positional int) error {
+	fs.SetOutput(io.Discard)
+	if err := fs.Parse(args); err != nil {
+		return usagef("%s: %v", fs.Name(), err)
+	}
+	if fs.NArg() != positional {
+		return usagef("%s expects %d argument(s), got %d", fs.Name(), positional, fs.NArg())
+	}
+	return nil
+}
+
+func submitCommand(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("submit", flag.ContinueOnError)
+	priority := fs.Uint("priority", uint(client.PriorityLow), "task priority, 0-9")
+	campaign := fs.String("campaign", "", "campaign label")
+	bugID := fs.String("bug", "", "syzbot bug ID or URL to fetch the report from")
+	baseTask := fs.String("base", "", "task whose report a patch is applied to")
+	var patchPaths stringsFlag
+	fs.Var(&patchPaths, "patch", "patch file to apply, repeat for a series applied in order")
+	fixVerify := fs.Bool("fix-verify", false, "run the reproducer at the parent of the bug's fix commit and at the fix commit")
+	good := fs.String("good", "", "bisect: commit the reproducer does not crash")
+	bad := fs.String("bad", "", "bisect: commit the reproducer crashes")
+	wait := fs.Bool("wait", false, "wait until the task has finished")
+
+	fs.SetOutput(io.Discard)
+	if err := fs.Parse(args); err != nil {
+		return usagef("submit: %v", err)
+	}
+	if *priority > uint(client.MaxTaskPriority) {
+		return usagef("submit: priority must be between 0 and %d", client.MaxTaskPriority)
+	}
+
+	req := client.CreateTaskRequest{
+		Priority: uint8(*priority),
+		Campaign: *campaign,
+	}
+
+	// a report file or -bug is built as is, verified against its fix commit or bisected
+	reportType := client.TaskTypeKernelBuild
+	bisect := *good != "" || *bad != ""
+	switch {
+	case *fixVerify && bisect:
+		return usagef("submit: -fix-verify and -good/-bad do not go together")
+	case bisect && (*good == "" || *bad == ""):
+		return usagef("submit: bisect needs both -good and -bad")
+	case (*fixVerify || bisect) && len(patchPaths) > 0:
+		return usagef("submit: -patch only applies to patch tasks")
+	case *fixVerify:
+		reportType = client.TaskTypeFixVerify
+	case bisect:
+		reportType = client.TaskTypeBisect
+		req.Bisect = &client.BisectRange{Good: *good, Bad: *bad}
+	}
+
+	switch {
+	case reportType != client.TaskTypeKernelBuild && *baseTask != "":
+		if *bugID != "" || fs.NArg() != 0 {
+			return usagef("submit: give one of a report file, -bug or -base")
+		}
+		req.Type = reportType
+		req.BaseTaskID = *baseTask
+
+	case len(patchPaths) > 0 || *baseTask != "":
+		if len(patchPaths) == 0 || *baseTask == "" || fs.NArg() != 0 {
+			return usagef("submit: a patch task needs -base and at least one -patch")
+		}
+		for _, path := range patchPaths {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			req.Patches = append(req.Patches, client.PatchUpload{Name: filepath.Base(path), Data: data})
+		}
+		req.Type = client.TaskTypePatchApply
+		req.BaseTaskID = *baseTask
+
+	case *bugID != "":
+		if fs.NArg() != 0 {
+			return usagef("submit: give either a report file or -bug, not both")
+		}
+		report, err := fetchSyzbotReport(ctx, *bugID)
+		if err != nil {
+			return err
+		}
+		req.Type = reportType
+		req.Report = report
+
+	case fs.NArg() == 1:
+		report, err := readReport(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		req.Type = reportType
+		req.Report = report
+
+	default:
+		return usagef("submit: a report file, -bug or -base/-patch is required")
+	}
+
+	task, err := a.api.CreateTask(ctx, req)
+	if err != nil {
+		return err
+	}
+	if *wait {
+		return a.waitAndPrint(ctx, task.ID)
+	}
+	return a.printTask(task)
+}
+
+func readReport(path string) (*client.CrashReport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var report client.CrashReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("%s is not a crash report: %w", path, err)
+	}
+	return &report, nil
+}
+
+// fetchSyzbotReport downloads the JSON export of a syzbot bug; id is either
+// the bug hash, the extid used in syzbot emails, or a bug URL
+func fetchSyzbotReport(ctx context.Context, id string) (*client.CrashReport, error) {
+	var reportURL string
+	switch {
+	case strings.HasPrefix(id, "http://") || strings.HasPrefix(id, "https://"):
+		u, err := url.Parse(id)
+		if err != nil {
+			return nil, usagef("submit: invalid bug URL %q", id)
+		}
+		query := u.Query()
+		query.Set("json", "1")
+		u.RawQuery = query.Encode()
+		reportURL = u.String()
+	case bugHashPattern.MatchString(id):
+		reportURL = syzbotURL + "/bug?json=1&id=" + url.QueryEscape(id)
+	default:
+		reportURL = syzbotURL + "/bug?json=1&extid=" + url.QueryEscape(id)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reportURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch bug %s: %w", id, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, &client.APIError{StatusCode: resp.StatusCode, Message: "syzbot bug " + id + " not found"}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch bug %s: syzbot responded with %d", id, resp.StatusCode)
+	}
+
+	var report client.CrashReport
+	if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+		return nil, fmt.Errorf("failed to parse bug %s: %w", id, err)
+	}
+	if len(report.Crashes) == 0 {
+		return nil, fmt.Errorf("bug %s has no crashes to reproduce", id)
+	}
+	return &report, nil
+}
+
+func listCommand(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("list", flag.ContinueOnError)
+	status := fs.String("status", "", "only tasks with this status")
+	campaign := fs.String("campaign", "", "only tasks of this campaign")
+	failure := fs.String("failure", "", "only failed tasks of this failure category")
+	verdict := fs.String("verdict", "", "only tasks with this verdict: reproduced, different_crash or not_reproduced")
+	sort := fs.String("sort", "", "created_at (newest first) or priority (dispatch order)")
+	if err := parseFlags(fs, args, 0); err != nil {
+		return err
+	}
+
+	tasks, err := a.api.ListTasks(ctx, client.ListTasksOptions{
+		Status:          client.TaskStatus(*status),
+		Campaign:        *campaign,
+		FailureCategory: client.FailureCategory(*failure),
+		Verdict:         client.Verdict(*verdict),
+		Sort:            *sort,
+	})
+	if err != nil {
+		return err
+	}
+	return a.printTasks(tasks)
+}
+
+func showCommand(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("show", flag.ContinueOnError)
+	wait := fs.Bool("wait", false, "wait until the task has finished")
+	if err := parseFlags(fs, args, 1); err != nil {
+		return err
+	}
+
+	if *wait {
+		return a.waitAndPrint(ctx, fs.Arg(0))
+	}
+	task, err := a.api.GetTask(ctx, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	if a.output == "json" || task.Status != client.StatusRunning {
+		return a.printTask(task)
+	}
+
+	progress, err := a.api.GetTaskProgress(ctx, task.ID)
+	if err != nil {
+		return err
+	}
+	if err := a.printTask(task); err != nil {
+		return err
+	}
+	return printProgress(progress)
+}
+
+func timelineCommand(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("timeline", flag.ContinueOnError)
+	if err := parseFlags(fs, args, 1); err != nil {
+		return err
+	}
+
+	timeline, err := a.api.GetTaskTimeline(ctx, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	return a.printTimeline(timeline)
+}
+
+func failuresCommand(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("failures", flag.ContinueOnError)
+	campaign := fs.String("campaign", "", "only failures of this campaign")
+	if err := parseFlags(fs, args, 0); err != nil {
+		return err
+	}
+
+	stats, err := a.api.GetFailureStats(ctx, *campaign)
+	if err != nil {
+		return err
+	}
+	return a.printFailureStats(stats)
+}
+
+func consoleCommand(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("console", flag.ContinueOnError)
+	if err := parseFlags(fs, args, 1); err != nil {
+		return err
+	}
+
+	task, err := a.api.GetTask(ctx, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	if task.Console == nil {
+		return fmt.Errorf("no kernel report found in the console of task %s", task.ID)
+	}
+	return a.printConsoleReport(task.Console)
+}
+
+func compareCommand(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("compare", flag.ContinueOnError)
+	if err := parseFlags(fs, args, 2); err != nil {
+		return err
+	}
+
+	comparison, err := a.api.CompareTasks(ctx, fs.Arg(0), fs.Arg(1))
+	if err != nil {
+		return err
+	}
+	return a.printComparison(comparison)
+}
+
+// waitForTask polls a task until it reaches a terminal status
+func (a *app) waitForTask(ctx context.Context, taskID string) (*client.Task, error) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		task, err := a.api.GetTask(ctx, taskID)
+		if err != nil {
+			return nil, err
+		}
+		if task.Status.Finished() {
+			return task, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// waitAndPrint prints the finished task and fails unless it succeeded
+func (a *app) waitAndPrint(ctx context.Context, taskID string) error {
+	task, err := a.waitForTask(ctx, taskID)
+	if err != nil {
+		return err
+	}
+	if err := a.printTask(task); err != nil {
+		return err
+	}
+	if task.Status != client.StatusSuccess {
+		return taskFailedError{task: task}
+	}
+	return nil
+}
+
+func logsCommand(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("logs", flag.ContinueOnError)
+	follow := fs.Bool("f", false, "follow the output until the task's upload ends")
+	from := fs.Uint64("from", 1, "first line to print")
+	if err := parseFlags(fs, args, 1); err != nil {
+		return err
+	}
+	taskID := fs.Arg(0)
+
+	// resolve the task first so that an unknown ID exits with exitNotFound
+	if _, err := a.api.GetTask(ctx, taskID); err != nil {
+		return err
+	}
+
+	err := tail.Logs(ctx, a.grpcAddr, tail.Request{TaskID: taskID, FromSequence: *from, Follow: *follow}, func(line tail.Line) error {
+		if a.output == "json" {
+			return a.printJSON(line)
+		}
+		_, err := fmt.Println(line.Message)
+		return err
+	})
+	if errors.Is(err, tail.ErrNotFound) {
+		return &client.APIError{StatusCode: http.StatusNotFound, Message: err.Error()}
+	}
+	if err != nil || !*follow {
+		return err
+	}
+
+	// the worker reports the status right after its upload ends
+	task, err := a.waitForTask(ctx, taskID)
+	if err != nil {
+		return err
+	}
+	if task.Status != client.StatusSuccess {
+		return taskFailedError{task: task}
+	}
+	return nil
+}
+
+func cancelCommand(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("cancel", flag.ContinueOnError)
+	if err := parseFlags(fs, args, 1); err != nil {
+		return err
+	}
+
+	task, err := a.api.CancelTask(ctx, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	return a.printTask(task)
+}
+
+func deleteCommand(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("delete", flag.ContinueOnError)
+	if err := parseFlags(fs, args, 1); err != nil {
+		return err
+	}
+
+	taskID := fs.Arg(0)
+	if err := a.api.DeleteTask(ctx, taskID); err != nil {
+		return err
+	}
+	if a.output == "json" {
+		return a.printJSON(map[string]any{"task_id": taskID, "deleted": true})
+	}
+	fmt.Printf("deleted %s\n", taskID)
+	return nil
+}
+
+func artifactsCommand(ctx context.Context, a *app, args []string) error {
+	if len(args) == 0 || args[0] != "pull" {
+		return usagef("artifacts: only 'pull' is supported")
+	}
+
+	fs := flag.NewFlagSet("artifacts pull", flag.ContinueOnError)
+	dir := fs.String("dir", ".", "directory to save the artifact in")
+	if err := parseFlags(fs, args[1:], 1); err != nil {
+		return err
+	}
+	taskID := fs.Arg(0)
+
+	body, fileName, err := a.api.DownloadArtifact(ctx, taskID)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	fileName = filepath.Base(fileName)
+	if fileName == "." || fileName == "/" || fileName == "" {
+		fileName = taskID + ".artifact"
+	}
+	path := filepath.Join(*dir, fileName)
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	size, err := io.Copy(file, body)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+		return fmt.Errorf("failed to download artifact: %w", err)
+	}
+
+	if a.output == "json" {
+		return a.printJSON(map[string]any{"task_id": taskID, "path": path, "size": size})
+	}
+	fmt.Printf("saved %s (%d bytes)\n", path, size)
+	return nil
+}
+
+func workersCommand(ctx context.Context, a *app, args []string) error {
+	if len(args) != 1 || args[0] != "ls" {
+		return usagef("workers: only 'ls' is supported")
+	}
+
+	workers, err := a.api.ListWorkers(ctx)
+	if err != nil {
+		return err
+	}
+	return a.printWorkers(workers)
+}
@@ -0,0 +1,174 @@
+// platformctl drives the platform from a terminal or a CI job: submit tasks,
+// follow their logs and fetch their artifacts.
+//
+// Exit codes: 0 success, 1 error, 2 usage error, 3 not found, 4 task failed.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"sdk/client"
+)
+
+const defaultGRPCPort = "50051"
+
+const (
+	exitOK         = 0
+	exitError      = 1
+	exitUsage      = 2
+	exitNotFound   = 3
+	exitTaskFailed = 4
+)
+
+const usageText = `usage: platformctl [global flags] <command> [flags] [args]
+
+commands:
+  submit [-priority N] [-campaign NAME] [-wait] (REPORT.json | -bug ID)
+  submit -base TASK_ID -patch FILE [-patch FILE ...] [-priority N] [-wait]
+  submit -fix-verify [-priority N] [-campaign NAME] [-wait] (REPORT.json | -bug ID | -base TASK_ID)
+  submit -good COMMIT -bad COMMIT [-priority N] [-campaign NAME] [-wait] (REPORT.json | -bug ID | -base TASK_ID)
+  list [-status S] [-campaign NAME] [-failure CATEGORY] [-verdict V] [-sort created_at|priority]
+  show [-wait] TASK_ID
+  timeline TASK_ID
+  failures [-campaign NAME]
+  console TASK_ID
+  compare TASK_A TASK_B
+  logs [-f] [-from N] TASK_ID
+  cancel TASK_ID
+  delete TASK_ID
+  artifacts pull [-dir DIR] TASK_ID
+  workers ls
+`
+
+// usageError makes main print the usage and exit with exitUsage
+type usageError struct{ msg string }
+
+func (e usageError) Error() string { return e.msg }
+
+func usagef(format string, args ...any) error {
+	return usageError{msg: fmt.Sprintf(format, args...)}
+}
+
+// taskFailedError reports a task that finished without success
+type taskFailedError struct{ task *client.Task }
+
+func (e taskFailedError) Error() string {
+	return fmt.Sprintf("task %s finished with status %s: %s", e.task.ID, e.task.Status, resultMessage(e.task.Result))
+}
+
+type app struct {
+	api      *client.Client
+	grpcAddr string
+	output   string
+}
+
+type command func(ctx context.Context, a *app, args []string) error
+
+var commands = map[string]command{
+	"submit":    submitCommand,
+	"list":      listCommand,
+	"show":      showCommand,
+	"timeline":  timelineCommand,
+	"failures":  failuresCommand,
+	"console":   consoleCommand,
+	"compare":   compareCommand,
+	"logs":      logsCommand,
+	"cancel":    cancelCommand,
+	"delete":    deleteCommand,
+	"artifacts": artifactsCommand,
+	"workers":   workersCommand,
+}
+
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+func main() {
+	global := flag.NewFlagSet("platformctl", flag.ContinueOnError)
+	server := global.String("server", envOr("PLATFORM_SERVER", "http://localhost:8080"), "server address, defaults to $PLATFORM_SERVER")
+	grpcAddr := global.String("grpc", os.Getenv("PLATFORM_GRPC"), "gRPC address for logs, defaults to $PLATFORM_GRPC or the server host on port 50051")
+	token := global.String("token", os.Getenv("PLATFORM_TOKEN"), "bearer token, defaults to $PLATFORM_TOKEN")
+	output := global.String("output", "table", "output format: table or json")
+	global.StringVar(output, "o", "table", "shorthand for --output")
+	global.Usage = func() {
+		fmt.Fprint(os.Stderr, usageText+"\nglobal flags:\n")
+		global.PrintDefaults()
+	}
+
+	if err := global.Parse(os.Args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(exitOK)
+		}
+		os.Exit(exitUsage)
+	}
+	if *output != "table" && *output != "json" {
+		fmt.Fprintf(os.Stderr, "platformctl: unknown output format %q\n", *output)
+		os.Exit(exitUsage)
+	}
+
+	args := global.Args()
+	if len(args) == 0 {
+		global.Usage()
+		os.Exit(exitUsage)
+	}
+	run, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "platformctl: unknown command %q\n", args[0])
+		global.Usage()
+		os.Exit(exitUsage)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if *grpcAddr == "" {
+		u, err := url.Parse(*server)
+		if err != nil || u.Hostname() == "" {
+			fmt.Fprintf(os.Stderr, "platformctl: invalid server address %q\n", *server)
+			os.Exit(exitUsage)
+		}
+		*grpcAddr = net.JoinHostPort(u.Hostname(), defaultGRPCPort)
+	}
+
+	a := &app{
+		api:      client.New(*server, client.WithToken(*token)),
+		grpcAddr: *grpcAddr,
+		output:   *output,
+	}
+	os.Exit(exitCode(run(ctx, a, args[1:])))
+}
+
+func exitCode(err error) int {
+	if err == nil {
+		return exitOK
+	}
+
+	fmt.Fprintf(os.Stderr, "platformctl: %v\n", err)
+
+	var usageErr usageError
+	var failedErr taskFailedError
+	switch {
+	case errors.As(err, &usageErr):
+		fmt.Fprint(os.Stderr, usageText)
+		return exitUsage
+	case errors.Is(err, flag.ErrHelp):
+		return exitUsage
+	case client.IsNotFound(err):
+		return exitNotFound
+	case errors.As(err, &failedErr):
+		return exitTaskFailed
+	default:
+		return exitError
+	}
+}
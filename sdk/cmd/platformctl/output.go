@@ -0,0 +1,564 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"sdk/client"
+)
+
+func (a *app) printJSON(value any) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+// printTable writes rows under a header, aligned in columns
+func printTable(header []string, rows [][]string) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	return w.Flush()
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04:05")
+}
+
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+func (a *app) printTasks(tasks []client.Task) error {
+	if a.output == "json" {
+		return a.printJSON(tasks)
+	}
+
+	rows := make([][]string, 0, len(tasks))
+	for _, task := range tasks {
+		rows = append(rows, []string{
+			task.ID,
+			string(task.Type),
+			string(task.Status),
+			fmt.Sprint(task.Priority),
+			orDash(task.Campaign),
+			orDash(task.WorkerID),
+			formatTime(&task.CreatedAt),
+			orDash(task.Payload.Title),
+		})
+	}
+	return printTable([]string{"ID", "TYPE", "STATUS", "PRIO", "CAMPAIGN", "WORKER", "CREATED", "TITLE"}, rows)
+}
+
+func (a *app) printTask(task *client.Task) error {
+	if a.output == "json" {
+		return a.printJSON(task)
+	}
+
+	commit := "-"
+	if len(task.Payload.Crashes) > 0 {
+		commit = orDash(task.Payload.Crashes[0].KernelSourceCommit)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	for _, field := range [][2]string{
+		{"ID", task.ID},
+		{"Type", string(task.Type)},
+		{"Status", string(task.Status)},
+		{"Priority", fmt.Sprint(task.Priority)},
+		{"Campaign", orDash(task.Campaign)},
+		{"Bug", orDash(task.Payload.ID)},
+		{"Title", orDash(task.Payload.Title)},
+		{"Commit", commit},
+		{"Worker", orDash(task.WorkerID)},
+		{"Created", formatTime(&task.CreatedAt)},
+		{"Started", formatTime(task.StartedAt)},
+		{"Finished", formatTime(task.FinishedAt)},
+		{"Result", orDash(resultMessage(task.Result))},
+		{"Verdict", formatVerdict(task.Result)},
+		{"Accel", orDash(resultAccel(task.Result))},
+		{"Failure", formatFailure(task.Failure)},
+		{"Console", consoleTitle(task.Console)},
+		{"Artifact", orDash(task.ArtifactName)},
+		{"Patch", formatPatch(task.PatchSummary)},
+	} {
+		fmt.Fprintf(w, "%s:\t%s\n", field[0], field[1])
+	}
+	for _, line := range patchLines(task) {
+		fmt.Fprintf(w, "  %s\t%s\n", line[0], line[1])
+	}
+	if task.Result != nil && task.Result.FixVerify != nil {
+		verification := task.Result.FixVerify
+		fmt.Fprintf(w, "Fix:\t%s\n", formatFixed(verification.Fixed))
+		fmt.Fprintf(w, "  parent %s\t%s\n", verification.ParentCommit, formatFixRun(verification.Parent))
+		fmt.Fprintf(w, "  fix %s\t%s\n", verification.FixCommit, formatFixRun(verification.Fix))
+	}
+	if task.Result != nil && task.Result.Bisect != nil {
+		bisect := task.Result.Bisect
+		fmt.Fprintf(w, "Bisect:\t%s\n", formatCulprit(bisect))
+		for i, step := range bisect.Steps {
+			fmt.Fprintf(w, "  #%d %s\t%s\n", i+1, step.Verdict, formatBisectStep(step))
+		}
+	}
+	if task.Result != nil && len(task.Result.Runs) > 0 {
+		fmt.Fprintf(w, "Runs:\t%s\n", formatCrashRate(task.Result))
+		for _, run := range task.Result.Runs {
+			fmt.Fprintf(w, "  #%d %s\t%s\n", run.Index, (time.Duration(run.DurationMs) * time.Millisecond).Round(time.Second), formatReproRun(run))
+		}
+	}
+	return w.Flush()
+}
+
+// formatCrashRate renders how many of the started runs crashed, e.g.
+// "3/5 crashed (60%)"
+func formatCrashRate(result *client.TaskResult) string {
+	started, crashed := 0, 0
+	for _, run := range result.Runs {
+		if run.Error != "" {
+			continue
+		}
+		started++
+		if run.Crashed {
+			crashed++
+		}
+	}
+	if result.CrashRate == nil {
+		return fmt.Sprintf("none of %s started", plural(len(result.Runs), "run"))
+	}
+	return fmt.Sprintf("%d/%d crashed (%.0f%%)", crashed, started, *result.CrashRate*100)
+}
+
+// formatReproRun renders one run of the reproducer like a verdict, or why it
+// did not start
+func formatReproRun(run client.ReproRun) string {
+	if run.Error != "" {
+		return "failed: " + run.Error
+	}
+	return formatVerdict(&client.TaskResult{
+		CrashTitle:     run.CrashTitle,
+		VmcoreCaptured: run.VmcoreCaptured,
+		Verdict:        run.Verdict,
+	})
+}
+
+// formatCulprit renders the outcome of a bisection, e.g.
+// "1234abcd (mm: fix foo)" or "one of 2 skipped commits: 1234abcd 5678ef90"
+func formatCulprit(bisect *client.BisectResult) string {
+	switch {
+	case bisect.Culprit != "":
+		if bisect.CulpritTitle == "" {
+			return bisect.Culprit
+		}
+		return fmt.Sprintf("%s (%s)", bisect.Culprit, bisect.CulpritTitle)
+	case len(bisect.Suspects) > 0:
+		return fmt.Sprintf("one of %s: %s", plural(len(bisect.Suspects), "skipped commit"), strings.Join(bisect.Suspects, " "))
+	default:
+		return "no culprit"
+	}
+}
+
+// formatBisectStep renders a tested commit with its crash, or why it was skipped
+func formatBisectStep(step client.BisectStep) string {
+	if step.Verdict == client.BisectSkip {
+		return fmt.Sprintf("%s: %s", step.Commit, step.Reason)
+	}
+	return fmt.Sprintf("%s %s", step.Commit, formatVerdict(&client.TaskResult{
+		CrashTitle:     step.CrashTitle,
+		VmcoreCaptured: step.VmcoreCaptured,
+		Verdict:        step.Reproduction,
+	}))
+}
+
+func formatFixed(fixed *bool) string {
+	switch {
+	case fixed == nil:
+		return "undecided"
+	case *fixed:
+		return "fixed"
+	default:
+		return "not fixed"
+	}
+}
+
+// formatFixRun renders one run of a fix-verify task like a verdict
+func formatFixRun(run *client.FixRun) string {
+	if run == nil {
+		return "did not run"
+	}
+	return formatVerdict(&client.TaskResult{
+		CrashTitle:     run.CrashTitle,
+		VmcoreCaptured: run.VmcoreCaptured,
+		Verdict:        run.Verdict,
+	})
+}
+
+// patchLines lists the files of a patch task, then the patches of a series with
+// how each applied once the worker has reported it
+func patchLines(task *client.Task) [][2]string {
+	if task.PatchSummary == nil {
+		return nil
+	}
+	var lines [][2]string
+	for _, file := range task.PatchSummary.Files {
+		lines = append(lines, [2]string{string(file.Status), formatPatchFile(file)})
+	}
+
+	if task.Result != nil && len(task.Result.Patches) > 0 {
+		for _, patch := range task.Result.Patches {
+			text := patch.Subject
+			if patch.Error != "" {
+				text += ": " + patch.Error
+			}
+			lines = append(lines, [2]string{fmt.Sprintf("#%d %s", patch.Index, patch.Status), text})
+		}
+		return lines
+	}
+	for i, patch := range task.PatchSummary.Series {
+		lines = append(lines, [2]string{fmt.Sprintf("#%d", i+1), patch.Subject})
+	}
+	return lines
+}
+
+// formatPatch renders a patch summary as "2 files, 3 hunks, +10 -4"
+func formatPatch(summary *client.PatchSummary) string {
+	if summary == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%s, %s, +%d -%d", plural(len(summary.Files), "file"), plural(summary.Hunks, "hunk"),
+		summary.Additions, summary.Deletions)
+}
+
+// formatPatchFile renders a file of a patch as "mm/slub.c (1 hunk, +2 -1)"
+func formatPatchFile(file client.PatchFile) string {
+	path := file.Path
+	if file.OldPath != "" {
+		path = file.OldPath + " -> " + file.Path
+	}
+	if file.Hunks == 0 {
+		return path
+	}
+	return fmt.Sprintf("%s (%s, +%d -%d)", path, plural(file.Hunks, "hunk"), file.Additions, file.Deletions)
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// formatFailure renders a failure as "compiler_error in MakeKernel: msg", with
+// the source location for compiler errors
+func formatFailure(failure *client.TaskFailure) string {
+	if failure == nil {
+		return "-"
+	}
+	text := string(failure.Category)
+	if failure.Step != "" {
+		text += " in " + failure.Step
+	}
+	if failure.File != "" {
+		text += fmt.Sprintf(" at %s:%d", failure.File, failure.Line)
+	}
+	if failure.Message != "" {
+		text += ": " + failure.Message
+	}
+	return text
+}
+
+func resultMessage(result *client.TaskResult) string {
+	if result == nil {
+		return ""
+	}
+	return result.Message
+}
+
+func resultAccel(result *client.TaskResult) string {
+	if result == nil {
+		return ""
+	}
+	return result.Accel
+}
+
+// formatVerdict renders the verdict with the crash found in the VM, e.g.
+// "different_crash (WARNING in foo, vmcore captured)" or for a patch
+// "not_reproduced (crash gone)"
+func formatVerdict(result *client.TaskResult) string {
+	if result == nil || result.Verdict == "" {
+		return "-"
+	}
+	var details []string
+	if result.CrashTitle != "" {
+		details = append(details, result.CrashTitle)
+	}
+	if result.VmcoreCaptured {
+		details = append(details, "vmcore captured")
+	}
+	if result.CrashGone != nil {
+		if *result.CrashGone {
+			details = append(details, "crash gone")
+		} else {
+			details = append(details, "crash persists")
+		}
+	}
+	if len(details) == 0 {
+		return string(result.Verdict)
+	}
+	return fmt.Sprintf("%s (%s)", result.Verdict, strings.Join(details, ", "))
+}
+
+func consoleTitle(report *client.ConsoleReport) string {
+	if report == nil {
+		return "-"
+	}
+	return report.Title
+}
+
+// formatFrame renders a frame the way the kernel prints it,
+// e.g. "kfree+0x8b/0x250 [ext4] mm/slub.c:4380"
+func formatFrame(frame client.StackFrame) string {
+	text := frame.Function
+	if frame.Size > 0 {
+		text += fmt.Sprintf("+0x%x/0x%x", frame.Offset, frame.Size)
+	}
+	if frame.Module != "" {
+		text += " [" + frame.Module + "]"
+	}
+	if frame.File != "" {
+		text += fmt.Sprintf(" %s:%d", frame.File, frame.Line)
+	}
+	if frame.Inline {
+		text += " [inline]"
+	}
+	return text
+}
+
+// printConsoleReport prints the title, the bad access and the stacks of a
+// kernel report
+func (a *app) printConsoleReport(report *client.ConsoleReport) error {
+	if a.output == "json" {
+		return a.printJSON(report)
+	}
+
+	fmt.Printf("Title:  %s\n", report.Title)
+	fmt.Printf("Kind:   %s\n", report.Kind)
+	if report.Access != "" {
+		fmt.Printf("Access: %s of size %d at %s\n", report.Access, report.Size, orDash(report.Address))
+	}
+	for _, stack := range []struct {
+		name   string
+		frames []client.StackFrame
+	}{
+		{"Call trace", report.Frames},
+		{"Allocated by", report.AllocStack},
+		{"Freed by", report.FreeStack},
+	} {
+		if len(stack.frames) == 0 {
+			continue
+		}
+		fmt.Printf("\n%s:\n", stack.name)
+		for _, frame := range stack.frames {
+			fmt.Printf("  %s\n", formatFrame(frame))
+		}
+	}
+	if len(report.OtherTitles) > 0 {
+		fmt.Println("\nFollowed by:")
+		for _, title := range report.OtherTitles {
+			fmt.Printf("  %s\n", title)
+		}
+	}
+	return nil
+}
+
+func formatMs(ms *int64) string {
+	if ms == nil {
+		return "-"
+	}
+	return (time.Duration(*ms) * time.Millisecond).String()
+}
+
+// printComparison prints the sections of a comparison that differ, A on the
+// left and B on the right
+func (a *app) printComparison(cmp *client.TaskComparison) error {
+	if a.output == "json" {
+		return a.printJSON(cmp)
+	}
+
+	rows := [][]string{
+		{"Task", cmp.A.ID, cmp.B.ID},
+		{"Type", string(cmp.A.Type), string(cmp.B.Type)},
+		{"Status", string(cmp.A.Status), string(cmp.B.Status)},
+		{"Commit", orDash(cmp.A.Commit), orDash(cmp.B.Commit)},
+		{"Compiler", orDash(cmp.A.Compiler), orDash(cmp.B.Compiler)},
+		{"Verdict", orDash(string(cmp.A.Verdict)), orDash(string(cmp.B.Verdict))},
+		{"Crash", orDash(cmp.A.CrashTitle), orDash(cmp.B.CrashTitle)},
+	}
+	if err := printTable([]string{"", "A", "B"}, rows); err != nil {
+		return err
+	}
+
+	for _, section := range []struct {
+		name  string
+		diffs []client.ValueDiff
+	}{
+		{"Toolchain", cmp.Toolchain},
+		{"Config", cmp.Config},
+	} {
+		if len(section.diffs) == 0 {
+			continue
+		}
+		fmt.Printf("\n%s (%d differences):\n", section.name, len(section.diffs))
+		rows := make([][]string, 0, len(section.diffs))
+		for _, diff := range section.diffs {
+			rows = append(rows, []string{"  " + diff.Name, orDash(diff.A), orDash(diff.B)})
+		}
+		if err := printTable([]string{"  OPTION", "A", "B"}, rows); err != nil {
+			return err
+		}
+	}
+
+	if len(cmp.Steps) > 0 {
+		fmt.Println("\nSteps:")
+		rows := make([][]string, 0, len(cmp.Steps))
+		for _, step := range cmp.Steps {
+			delta := "-"
+			if step.DeltaMs != nil {
+				delta = formatMs(step.DeltaMs)
+				if *step.DeltaMs > 0 {
+					delta = "+" + delta
+				}
+			}
+			rows = append(rows, []string{"  " + step.Step, formatMs(step.AMs), formatMs(step.BMs), delta})
+		}
+		if err := printTable([]string{"  STEP", "A", "B", "DELTA"}, rows); err != nil {
+			return err
+		}
+	}
+
+	if cmp.Crash != nil {
+		fmt.Println("\nStacks:")
+		for _, stack := range []struct {
+			name string
+			diff client.StackDiff
+		}{
+			{"call trace", cmp.Crash.Frames},
+			{"allocated by", cmp.Crash.AllocStack},
+			{"freed by", cmp.Crash.FreeStack},
+		} {
+			if stack.diff.Same {
+				continue
+			}
+			fmt.Printf("  %s: only A [%s], only B [%s]\n", stack.name,
+				strings.Join(stack.diff.OnlyA, ", "), strings.Join(stack.diff.OnlyB, ", "))
+		}
+	}
+
+	fmt.Printf("\nWarnings and errors (%d in both):\n", cmp.Log.Common)
+	for _, line := range cmp.Log.OnlyA {
+		fmt.Printf("  - %s (x%d)\n", line.Line, line.Count)
+	}
+	for _, line := range cmp.Log.OnlyB {
+		fmt.Printf("  + %s (x%d)\n", line.Line, line.Count)
+	}
+
+	for _, note := range cmp.Notes {
+		fmt.Printf("\nnote: %s\n", note)
+	}
+	return nil
+}
+
+func (a *app) printFailureStats(stats []client.FailureStat) error {
+	if a.output == "json" {
+		return a.printJSON(stats)
+	}
+
+	rows := make([][]string, 0, len(stats))
+	for _, stat := range stats {
+		rows = append(rows, []string{orDash(stat.Campaign), string(stat.Category), fmt.Sprint(stat.Count)})
+	}
+	return printTable([]string{"CAMPAIGN", "CATEGORY", "COUNT"}, rows)
+}
+
+func (a *app) printWorkers(workers []client.WorkerInfo) error {
+	if a.output == "json" {
+		return a.printJSON(workers)
+	}
+
+	rows := make([][]string, 0, len(workers))
+	for _, worker := range workers {
+		online := "no"
+		if worker.Online {
+			online = "yes"
+		}
+		rows = append(rows, []string{worker.WorkerID, orDash(worker.Hostname), worker.Status, online, formatTime(worker.LastSeen)})
+	}
+	return printTable([]string{"WORKER", "HOSTNAME", "STATUS", "ONLINE", "LAST SEEN"}, rows)
+}
+
+// printProgress prints the current step of a running task, e.g.
+// "Progress:  MakeKernel 63% (9500/15000)", followed by its latest warnings
+func printProgress(progress *client.TaskProgress) error {
+	if progress.Step == "" {
+		_, err := fmt.Println("Progress:  -")
+		return err
+	}
+
+	line := progress.Step + " " + progress.StepState
+	if progress.Percent != nil {
+		line = fmt.Sprintf("%s %.0f%%", progress.Step, *progress.Percent)
+	}
+	if progress.Total > 0 {
+		line += fmt.Sprintf(" (%d/%d)", progress.Current, progress.Total)
+	}
+	fmt.Printf("Progress:  %s\n", line)
+	if progress.WarningCount > 0 {
+		fmt.Printf("Warnings:  %d\n", progress.WarningCount)
+		for _, warning := range progress.Warnings {
+			fmt.Printf("  [%s] %s\n", warning.Step, warning.Message)
+		}
+	}
+	return nil
+}
+
+// printTimeline lists the events in order; step finishes show the duration and
+// the error of the step, status events the transition
+func (a *app) printTimeline(timeline *client.TaskTimeline) error {
+	if a.output == "json" {
+		return a.printJSON(timeline)
+	}
+
+	rows := make([][]string, 0, len(timeline.Events))
+	for _, event := range timeline.Events {
+		what, duration := "", "-"
+		switch event.Kind {
+		case client.TaskEventStatus:
+			what = fmt.Sprintf("%s -> %s", orDash(string(event.FromStatus)), event.ToStatus)
+		default:
+			what = event.Step
+			if event.DurationMs != nil {
+				duration = (time.Duration(*event.DurationMs) * time.Millisecond).String()
+			}
+		}
+		rows = append(rows, []string{
+			formatTime(&event.OccurredAt),
+			string(event.Kind),
+			what,
+			duration,
+			orDash(event.Source),
+			orDash(event.Error),
+		})
+	}
+	return printTable([]string{"TIME", "KIND", "EVENT", "DURATION", "SOURCE", "ERROR"}, rows)
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"testing"
+
+	"sdk/client"
+)
+
+func TestFormatCrashRate(t *testing.T) {
+	rate := func(v float64) *float64 { return &v }
+	tests := []struct {
+		result client.TaskResult
+		want   string
+	}{
+		{
+			result: client.TaskResult{
+				Runs:      []client.ReproRun{{Crashed: true}, {}, {Crashed: true}, {}, {Crashed: true}},
+				CrashRate: rate(0.6),
+			},
+			want: "3/5 crashed (60%)",
+		},
+		{
+			result: client.TaskResult{
+				Runs:      []client.ReproRun{{Error: "ssh: handshake failed: EOF"}, {Crashed: true}, {}},
+				CrashRate: rate(0.5),
+			},
+			want: "1/2 crashed (50%)",
+		},
+		{
+			result: client.TaskResult{Runs: []client.ReproRun{{Error: "ssh: handshake failed: EOF"}}},
+			want:   "none of 1 run started",
+		},
+	}
+	for _, tt := range tests {
+		if got := formatCrashRate(&tt.result); got != tt.want {
+			t.Errorf("formatCrashRate = %q, want %q", got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+// Package tail reads task output through the server's TailLogs gRPC stream.
+// It is kept apart from package client so that programs with their own copy
+// of the generated protocol (such as the worker) can still use the client.
+package tail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	pb "sdk/internal/proto"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+// ErrNotFound is returned when the server holds no output for the task
+var ErrNotFound = errors.New("no output stored for task")
+
+// Line is one numbered line of task output
+type Line struct {
+	Sequence  uint64 `json:"sequence"`
+	ClientID  string `json:"client_id"`
+	TaskID    string `json:"task_id"`
+	Timestamp string `json:"timestamp"`
+	Message   string `json:"message"`
+}
+
+type Request struct {
+	TaskID       string
+	FromSequence uint64 // 0 or 1 replays from the first line
+	Follow       bool   // keep streaming until the worker's upload ends
+}
+
+// Logs connects to the gRPC server at addr ("host:port") and calls fn for every
+// line. Resume an interrupted tail with FromSequence set to the last sequence + 1.
+func Logs(ctx context.Context, addr string, req Request, fn func(Line) error) error {
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", addr, err)
+	}
+	defer conn.Close()
+
+	stream, err := pb.NewLogStreamServiceClient(conn).TailLogs(ctx, &pb.TailRequest{
+		TaskId:       req.TaskID,
+		FromSequence: req.FromSequence,
+		Follow:       req.Follow,
+	})
+	if err != nil {
+		return convert(err, req.TaskID)
+	}
+
+	for {
+		line, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return convert(err, req.TaskID)
+		}
+
+		if err := fn(Line{
+			Sequence:  line.Sequence,
+			ClientID:  line.ClientId,
+			TaskID:    line.TaskId,
+			Timestamp: line.Timestamp,
+			Message:   line.Message,
+		}); err != nil {
+			return err
+		}
+	}
+}
+
+func convert(err error, taskID string) error {
+	switch status.Code(err) {
+	case codes.NotFound:
+		return fmt.Errorf("%w %s", ErrNotFound, taskID)
+	case codes.Canceled:
+		return context.Canceled
+	default:
+		return err
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"Server/pkg/manager"
+	"Server/pkg/migrate"
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+const migrateUsage = `usage: server migrate <command> [flags]
+
+commands:
+  status            list known and applied migrations
+  up [-to N]        apply pending migrations, up to version N (default latest)
+  down [-steps N]   roll back the N most recently applied migrations (default 1)
+`
+
+// runMigrate implements `server migrate`, it returns the process exit code
+func runMigrate(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, migrateUsage)
+		return 2
+	}
+
+	flags := flag.NewFlagSet("migrate "+args[0], flag.ContinueOnError)
+	flags.Usage = func() { fmt.Fprint(os.Stderr, migrateUsage) }
+	to := 0
+	steps := 1
+	switch args[0] {
+	case "status":
+	case "up":
+		flags.IntVar(&to, "to", 0, "target version, 0 for the latest")
+	case "down":
+		flags.IntVar(&steps, "steps", 1, "number of migrations to roll back")
+	default:
+		fmt.Fprintf(os.Stderr, "unknown migrate command %q\n\n%s", args[0], migrateUsage)
+		return 2
+	}
+	if err := flags.Parse(args[1:]); err != nil {
+		return 2
+	}
+	if to < 0 || steps < 1 {
+		fmt.Fprintln(os.Stderr, "-to must not be negative and -steps must be at least 1")
+		return 2
+	}
+
+	db, err := manager.Connect()
+	if err != nil {
+		slog.Error("failed to connect to database", "error", err)
+		return 1
+	}
+	migrator, err := migrate.CreateMigrator(db, slog.Default())
+	if err != nil {
+		slog.Error("failed to load migrations", "error", err)
+		return 1
+	}
+
+	ctx := context.Background()
+	switch args[0] {
+	case "status":
+		err = printMigrationStatus(ctx, migrator)
+	case "up":
+		var n int
+		n, err = migrator.Up(ctx, to)
+		fmt.Printf("%d migrations applied\n", n)
+	case "down":
+		var n int
+		n, err = migrator.Down(ctx, steps)
+		fmt.Printf("%d migrations rolled back\n", n)
+	}
+	if err != nil {
+		slog.Error("migrate "+args[0]+" failed", "error", err)
+		return 1
+	}
+	return 0
+}
+
+func printMigrationStatus(ctx context.Context, migrator *migrate.Migrator) error {
+	states, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED")
+	for _, s := range states {
+		applied := "pending"
+		if s.AppliedAt != nil {
+			applied = s.AppliedAt.Local().Format(time.DateTime)
+		}
+		if !s.Known {
+			applied += " (unknown to this server)"
+		}
+		fmt.Fprintf(w, "%04d\t%s\t%s\n", s.Version, s.Name, applied)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	// same check the server runs on startup
+	return migrator.Check(ctx)
+}
@@ -0,0 +1,128 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseErrors(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * 32 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"*/x * * * *",
+		"a * * * *",
+		"* * * foo *",
+		"@weekdays",
+	} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("Parse(%q) succeeded, want error", expr)
+		}
+	}
+}
+
+func TestParseFields(t *testing.T) {
+	tests := []struct {
+		expr   string
+		minute uint64
+		dow    uint64
+	}{
+		{"0 * * * *", 1, 0x7f},
+		{"1,3,5 * * * *", 1<<1 | 1<<3 | 1<<5, 0x7f},
+		{"10-12 * * * *", 1<<10 | 1<<11 | 1<<12, 0x7f},
+		{"50/5 * * * *", 1<<50 | 1<<55, 0x7f},
+		{"0-10/5 * * * *", 1<<0 | 1<<5 | 1<<10, 0x7f},
+		{"0 * * * mon-fri", 1, 0x3e},
+		{"0 * * * 7", 1, 1<<0 | 1<<7},
+		{"0 * * * SUN,sat", 1, 1<<0 | 1<<6},
+	}
+	for _, tt := range tests {
+		s, err := Parse(tt.expr)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.expr, err)
+			continue
+		}
+		if s.minute != tt.minute {
+			t.Errorf("Parse(%q) minute = %b, want %b", tt.expr, s.minute, tt.minute)
+		}
+		if s.dow&0x7f != tt.dow&0x7f {
+			t.Errorf("Parse(%q) day-of-week = %b, want %b", tt.expr, s.dow, tt.dow)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	at := func(s string) time.Time {
+		v, err := time.Parse("2006-01-02 15:04", s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+	tests := []struct {
+		expr string
+		from string
+		want string // empty for never
+	}{
+		// strictly after, seconds are dropped
+		{"* * * * *", "2026-03-10 12:00", "2026-03-10 12:01"},
+		{"30 * * * *", "2026-03-10 12:30", "2026-03-10 13:30"},
+		{"@hourly", "2026-03-10 23:15", "2026-03-11 00:00"},
+		{"@daily", "2026-12-31 08:00", "2027-01-01 00:00"},
+		{"@monthly", "2026-01-31 00:00", "2026-02-01 00:00"},
+		{"@yearly", "2026-01-01 00:00", "2027-01-01 00:00"},
+		// 2026-03-10 is a tuesday
+		{"@weekly", "2026-03-10 00:00", "2026-03-15 00:00"},
+		{"0 9 * * 1-5", "2026-03-13 10:00", "2026-03-16 09:00"},
+		{"*/15 8-10 * * *", "2026-03-10 10:50", "2026-03-11 08:00"},
+		{"*/15 8-10 * * *", "2026-03-10 09:31", "2026-03-10 09:45"},
+		{"0 0 31 * *", "2026-04-01 00:00", "2026-05-31 00:00"},
+		{"0 0 29 2 *", "2026-01-01 00:00", "2028-02-29 00:00"},
+		// both day fields restricted: either one matches
+		{"0 0 13 * 5", "2026-03-10 00:00", "2026-03-13 00:00"},
+		{"0 0 1 * 3", "2026-03-10 00:00", "2026-03-11 00:00"},
+		{"0 0 1 * 3", "2026-03-31 12:00", "2026-04-01 00:00"},
+		// a starred field does not widen the other one
+		{"0 0 * * 5", "2026-03-10 00:00", "2026-03-13 00:00"},
+		{"0 0 */2 * 5", "2026-03-10 00:00", "2026-03-13 00:00"},
+		{"0 0 13 * *", "2026-03-10 00:00", "2026-03-13 00:00"},
+		{"0 0 30 2 *", "2026-01-01 00:00", ""},
+	}
+	for _, tt := range tests {
+		s, err := Parse(tt.expr)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.expr, err)
+			continue
+		}
+		got := s.Next(at(tt.from).Add(30 * time.Second))
+		if tt.want == "" {
+			if !got.IsZero() {
+				t.Errorf("Next(%q, %s) = %s, want never", tt.expr, tt.from, got)
+			}
+			continue
+		}
+		if want := at(tt.want); !got.Equal(want) {
+			t.Errorf("Next(%q, %s) = %s, want %s", tt.expr, tt.from, got.Format("2006-01-02 15:04 Mon"), want.Format("2006-01-02 15:04 Mon"))
+		}
+	}
+}
+
+func TestNextKeepsLocation(t *testing.T) {
+	loc := time.FixedZone("UTC+8", 8*3600)
+	s, err := Parse("0 2 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := s.Next(time.Date(2026, 3, 10, 3, 0, 0, 0, loc))
+	if want := time.Date(2026, 3, 11, 2, 0, 0, 0, loc); !got.Equal(want) || got.Location() != loc {
+		t.Errorf("Next = %s, want %s", got, want)
+	}
+}
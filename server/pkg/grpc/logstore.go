@@ -0,0 +1,429 @@
+package rpc
+
+import (
+	pb "Server/pkg/proto"
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// logMaxBytes caps the output kept per task, later lines are dropped after one truncation notice
+	logMaxBytes = 32 << 20
+	// logMemoryBytes is the tail of a task's output kept in memory, older lines are read back from disk
+	logMemoryBytes = 1 << 20
+	// logMemoryRetention is how long a finished log stays in memory after its upload ended
+	logMemoryRetention = time.Hour
+	// logRetention is how long the output of a task stays on disk after the last line
+	logRetention = 7 * 24 * time.Hour
+	// logIdle is how long an open log may go without a line before the task status is checked
+	logIdle          = 10 * time.Minute
+	logSweepInterval = time.Minute
+)
+
+// taskLog is the numbered output of one task. lines holds the most recent lines,
+// numbered from first on; the complete output is in the task's file. changed is
+// closed and replaced on every append or close, which wakes all followers
+// without per-reader buffers.
+type taskLog struct {
+	lines     []*pb.LogLine
+	first     uint64 // sequence of lines[0]
+	next      uint64 // sequence of the next line
+	memBytes  int
+	diskBytes int64
+	truncated bool
+	file      *os.File
+
+	closed    bool
+	closedAt  time.Time
+	updatedAt time.Time
+	changed   chan struct{}
+}
+
+// storedLine is one line of a task's log file
+type storedLine struct {
+	Sequence  uint64 `json:"seq"`
+	ClientID  string `json:"client_id"`
+	Timestamp string `json:"timestamp"`
+	Message   string `json:"message"`
+	// set on the notice replacing the line that exceeded logMaxBytes
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// LogStore keeps task output so that it can be replayed and followed. Every line
+// is appended to <dir>/<task id>.log as well, logs evicted from memory or lost in
+// a restart are read back from there.
+type LogStore struct {
+	dir string
+	// active reports whether a task is still pending or running, so that
+	// followers of tasks without output are refused or ended
+	active func(taskID string) bool
+
+	mu    sync.Mutex
+	tasks map[string]*taskLog
+}
+
+func NewLogStore(dir string, active func(taskID string) bool) (*LogStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	store := &LogStore{
+		dir:    dir,
+		active: active,
+		tasks:  make(map[string]*taskLog),
+	}
+
+	go store.loop()
+
+	return store, nil
+}
+
+func (s *LogStore) path(taskID string) string {
+	return filepath.Join(s.dir, taskID+".log")
+}
+
+// validTaskID keeps task IDs from escaping the log directory
+func validTaskID(taskID string) bool {
+	return taskID != "" && !strings.ContainsAny(taskID, `/\`) && taskID != "." && taskID != ".."
+}
+
+func lineSize(line *pb.LogLine) int {
+	return len(line.Message) + len(line.ClientId) + len(line.Timestamp) + 64
+}
+
+func (tl *taskLog) notify() {
+	close(tl.changed)
+	tl.changed = make(chan struct{})
+}
+
+// keep adds a line to the in-memory tail, dropping the oldest lines beyond logMemoryBytes
+func (tl *taskLog) keep(line *pb.LogLine) {
+	tl.lines = append(tl.lines, line)
+	tl.memBytes += lineSize(line)
+	for tl.memBytes > logMemoryBytes && len(tl.lines) > 1 {
+		tl.memBytes -= lineSize(tl.lines[0])
+		tl.lines[0] = nil
+		tl.lines = tl.lines[1:]
+		tl.first++
+	}
+}
+
+// restore reads the log of a task back from disk; without a file an empty log is
+// returned for active tasks and nil otherwise. Callers hold mu.
+func (s *LogStore) restore(taskID string, active bool) (*taskLog, error) {
+	now := time.Now()
+	tl := &taskLog{
+		first:     1,
+		next:      1,
+		updatedAt: now,
+		changed:   make(chan struct{}),
+	}
+	// the upload of a finished task ended, possibly before a restart
+	if !active {
+		tl.closed = true
+		tl.closedAt = now
+	}
+
+	f, err := os.Open(s.path(taskID))
+	if errors.Is(err, fs.ErrNotExist) {
+		if !active {
+			return nil, nil
+		}
+		s.tasks[taskID] = tl
+		return tl, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	err = scanLines(f, func(stored storedLine, size int) bool {
+		line := &pb.LogLine{
+			Sequence:  stored.Sequence,
+			ClientId:  stored.ClientID,
+			TaskId:    taskID,
+			Timestamp: stored.Timestamp,
+			Message:   stored.Message,
+		}
+		if len(tl.lines) == 0 {
+			tl.first = line.Sequence
+		}
+		tl.keep(line)
+		tl.next = line.Sequence + 1
+		tl.diskBytes += int64(size)
+		tl.truncated = tl.truncated || stored.Truncated
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read log of task %s: %w", taskID, err)
+	}
+	s.tasks[taskID] = tl
+	return tl, nil
+}
+
+// scanLines calls fn for every stored line of a log file until it returns false
+func scanLines(f *os.File, fn func(line storedLine, size int) bool) error {
+	scanner := bufio.NewScanner(f)
+	// a line carries at most one gRPC message
+	scanner.Buffer(make([]byte, 64*1024), 8<<20)
+	for scanner.Scan() {
+		var stored storedLine
+		if err := json.Unmarshal(scanner.Bytes(), &stored); err != nil {
+			// a line cut short by a crash
+			continue
+		}
+		if !fn(stored, len(scanner.Bytes())+1) {
+			return nil
+		}
+	}
+	return scanner.Err()
+}
+
+// write appends a line to the task's file; callers hold mu
+func (s *LogStore) write(taskID string, tl *taskLog, line *pb.LogLine) error {
+	if tl.file == nil {
+		f, err := os.OpenFile(s.path(taskID), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return err
+		}
+		tl.file = f
+	}
+	data, err := json.Marshal(storedLine{
+		Sequence:  line.Sequence,
+		ClientID:  line.ClientId,
+		Timestamp: line.Timestamp,
+		Message:   line.Message,
+		Truncated: tl.truncated,
+	})
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	if _, err := tl.file.Write(data); err != nil {
+		return err
+	}
+	tl.diskBytes += int64(len(data))
+	return nil
+}
+
+// Append stores a line and returns it with its sequence number, or nil when the
+// task's output exceeded logMaxBytes. A new upload for a task whose previous
+// upload ended reopens its log.
+func (s *LogStore) Append(msg *pb.LogMessage) (*pb.LogLine, error) {
+	if !validTaskID(msg.TaskId) {
+		return nil, fmt.Errorf("invalid task id %q", msg.TaskId)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tl := s.tasks[msg.TaskId]
+	if tl == nil {
+		var err error
+		if tl, err = s.restore(msg.TaskId, true); err != nil {
+			return nil, err
+		}
+	}
+	if tl.truncated {
+		return nil, nil
+	}
+
+	line := &pb.LogLine{
+		Sequence:  tl.next,
+		ClientId:  msg.ClientId,
+		TaskId:    msg.TaskId,
+		Timestamp: msg.Timestamp,
+		Message:   msg.Message,
+	}
+	if tl.diskBytes+int64(lineSize(line)) > logMaxBytes {
+		tl.truncated = true
+		line.Message = fmt.Sprintf("[output truncated: more than %d bytes, later lines are dropped]", logMaxBytes)
+	}
+
+	if err := s.write(msg.TaskId, tl, line); err != nil {
+		return nil, err
+	}
+	if len(tl.lines) == 0 {
+		tl.first = line.Sequence
+	}
+	tl.keep(line)
+	tl.next++
+	tl.updatedAt = time.Now()
+	tl.closed = false
+	tl.notify()
+	return line, nil
+}
+
+// Close marks the output of a task complete and ends its followers
+func (s *LogStore) Close(taskID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if tl := s.tasks[taskID]; tl != nil {
+		s.close(tl)
+	}
+}
+
+// close ends a log; callers hold mu
+func (s *LogStore) close(tl *taskLog) {
+	if tl.file != nil {
+		if err := tl.file.Close(); err != nil {
+			log.Warnf("failed to close task log: %v", err)
+		}
+		tl.file = nil
+	}
+	if !tl.closed {
+		tl.closed = true
+		tl.closedAt = time.Now()
+		tl.notify()
+	}
+}
+
+// Read returns stored lines from sequence from on, whether the log is closed, and
+// a channel closed on the next change. Lines older than the in-memory tail are
+// read from disk, at most about logMemoryBytes per call. A task without output
+// yields ok == false unless it is still pending or running.
+func (s *LogStore) Read(taskID string, from uint64) (lines []*pb.LogLine, closed bool, changed <-chan struct{}, ok bool, err error) {
+	if !validTaskID(taskID) {
+		return nil, false, nil, false, nil
+	}
+
+	s.mu.Lock()
+	tl := s.tasks[taskID]
+	s.mu.Unlock()
+
+	if tl == nil {
+		// checked without the lock, it queries the database
+		active := s.active(taskID)
+
+		s.mu.Lock()
+		if tl = s.tasks[taskID]; tl == nil {
+			tl, err = s.restore(taskID, active)
+		}
+		s.mu.Unlock()
+		if err != nil || tl == nil {
+			return nil, false, nil, false, err
+		}
+	}
+
+	if from == 0 {
+		from = 1
+	}
+
+	s.mu.Lock()
+	first, next := tl.first, tl.next
+	closed, changed = tl.closed, tl.changed
+	if from >= first && from < next {
+		lines = slices.Clone(tl.lines[from-first:])
+	}
+	s.mu.Unlock()
+
+	if from < first {
+		lines, err = s.readFile(taskID, from, first)
+		if err != nil {
+			return nil, false, nil, false, err
+		}
+	}
+	return lines, closed, changed, true, nil
+}
+
+// readFile reads lines [from, until) of a task from disk
+func (s *LogStore) readFile(taskID string, from, until uint64) ([]*pb.LogLine, error) {
+	f, err := os.Open(s.path(taskID))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var lines []*pb.LogLine
+	size := 0
+	err = scanLines(f, func(stored storedLine, n int) bool {
+		if stored.Sequence < from {
+			return true
+		}
+		if stored.Sequence >= until || size >= logMemoryBytes {
+			return false
+		}
+		lines = append(lines, &pb.LogLine{
+			Sequence:  stored.Sequence,
+			ClientId:  stored.ClientID,
+			TaskId:    taskID,
+			Timestamp: stored.Timestamp,
+			Message:   stored.Message,
+		})
+		size += n
+		return true
+	})
+	return lines, err
+}
+
+func (s *LogStore) loop() {
+	ticker := time.NewTicker(logSweepInterval)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		s.sweep(now)
+	}
+}
+
+// sweep ends open logs of tasks that finished without the upload ending, e.g.
+// when the worker died, drops finished logs from memory after logMemoryRetention
+// and removes log files untouched for logRetention
+func (s *LogStore) sweep(now time.Time) {
+	s.mu.Lock()
+	var idle []string
+	for id, tl := range s.tasks {
+		switch {
+		case !tl.closed && now.Sub(tl.updatedAt) > logIdle:
+			idle = append(idle, id)
+		case tl.closed && now.Sub(tl.closedAt) > logMemoryRetention:
+			delete(s.tasks, id)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, id := range idle {
+		if s.active(id) {
+			continue
+		}
+		s.mu.Lock()
+		if tl := s.tasks[id]; tl != nil && !tl.closed && now.Sub(tl.updatedAt) > logIdle {
+			s.close(tl)
+		}
+		s.mu.Unlock()
+	}
+
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		log.Errorf("failed to list task logs in %s: %v", s.dir, err)
+		return
+	}
+	for _, entry := range entries {
+		taskID, isLog := strings.CutSuffix(entry.Name(), ".log")
+		if !isLog {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || now.Sub(info.ModTime()) <= logRetention {
+			continue
+		}
+		s.mu.Lock()
+		if tl := s.tasks[taskID]; tl == nil || tl.closed {
+			delete(s.tasks, taskID)
+			if err := os.Remove(filepath.Join(s.dir, entry.Name())); err != nil {
+				log.Warnf("failed to remove log of task %s: %v", taskID, err)
+			}
+		}
+		s.mu.Unlock()
+	}
+}
@@ -0,0 +1,235 @@
+package rpc
+
+import (
+	pb "Server/pkg/proto"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestStore(t *testing.T, dir string, active bool) *LogStore {
+	t.Helper()
+	store, err := NewLogStore(dir, func(string) bool { return active })
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func appendLine(t *testing.T, store *LogStore, taskID, message string) *pb.LogLine {
+	t.Helper()
+	line, err := store.Append(&pb.LogMessage{ClientId: "worker-1", TaskId: taskID, Message: message})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return line
+}
+
+func readAll(t *testing.T, store *LogStore, taskID string, from uint64) (messages []string, closed bool) {
+	t.Helper()
+	for {
+		lines, isClosed, _, ok, err := store.Read(taskID, from)
+		if err != nil || !ok {
+			t.Fatalf("Read(%s, %d) = ok %v, error %v", taskID, from, ok, err)
+		}
+		if len(lines) == 0 {
+			return messages, isClosed
+		}
+		for _, line := range lines {
+			if line.Sequence != from {
+				t.Fatalf("got sequence %d, want %d", line.Sequence, from)
+			}
+			messages = append(messages, line.Message)
+			from++
+		}
+	}
+}
+
+func TestReadUnknownTask(t *testing.T) {
+	finished := newTestStore(t, t.TempDir(), false)
+	if _, _, _, ok, err := finished.Read("task", 1); ok || err != nil {
+		t.Errorf("task without output that is not active: ok %v, error %v, want NotFound", ok, err)
+	}
+	if _, _, _, ok, _ := finished.Read("../task", 1); ok {
+		t.Error("task id with a path separator was accepted")
+	}
+
+	pending := newTestStore(t, t.TempDir(), true)
+	lines, closed, _, ok, err := pending.Read("task", 1)
+	if !ok || err != nil || closed || len(lines) != 0 {
+		t.Errorf("pending task: lines %d, closed %v, ok %v, error %v, want an open empty log", len(lines), closed, ok, err)
+	}
+}
+
+func TestReplayAfterRestart(t *testing.T) {
+	dir := t.TempDir()
+	store := newTestStore(t, dir, true)
+	for _, message := range []string{"one", "two", "three"} {
+		appendLine(t, store, "task", message)
+	}
+	store.Close("task")
+
+	restarted := newTestStore(t, dir, false)
+	messages, closed := readAll(t, restarted, "task", 1)
+	if strings.Join(messages, ",") != "one,two,three" || !closed {
+		t.Errorf("after restart got %v, closed %v", messages, closed)
+	}
+
+	// a new upload continues the numbering
+	if line := appendLine(t, restarted, "task", "four"); line.Sequence != 4 {
+		t.Errorf("sequence after restart = %d, want 4", line.Sequence)
+	}
+}
+
+func TestOlderLinesReadFromDisk(t *testing.T) {
+	store := newTestStore(t, t.TempDir(), true)
+	chunk := strings.Repeat("x", 100*1024)
+	for i := 0; i < 30; i++ {
+		appendLine(t, store, "task", chunk)
+	}
+
+	store.mu.Lock()
+	first, memBytes := store.tasks["task"].first, store.tasks["task"].memBytes
+	store.mu.Unlock()
+	if first == 1 || memBytes > logMemoryBytes {
+		t.Fatalf("memory tail starts at %d with %d bytes, want older lines dropped below %d bytes", first, memBytes, logMemoryBytes)
+	}
+
+	messages, closed := readAll(t, store, "task", 1)
+	if len(messages) != 30 || closed {
+		t.Errorf("read %d lines, closed %v, want 30 open", len(messages), closed)
+	}
+}
+
+func TestTruncation(t *testing.T) {
+	store := newTestStore(t, t.TempDir(), true)
+	chunk := strings.Repeat("x", 1<<20)
+
+	var stored int
+	for i := 0; i < logMaxBytes>>20+8; i++ {
+		if line := appendLine(t, store, "task", chunk); line != nil {
+			stored++
+		}
+	}
+	if stored > logMaxBytes>>20+1 {
+		t.Errorf("stored %d lines of 1 MiB, want at most %d", stored, logMaxBytes>>20+1)
+	}
+
+	store.mu.Lock()
+	tl := store.tasks["task"]
+	last, diskBytes := tl.lines[len(tl.lines)-1], tl.diskBytes
+	store.mu.Unlock()
+	if !strings.HasPrefix(last.Message, "[output truncated") {
+		t.Errorf("last line %.40q is not the truncation notice", last.Message)
+	}
+	if diskBytes > logMaxBytes {
+		t.Errorf("%d bytes on disk, cap is %d", diskBytes, logMaxBytes)
+	}
+}
+
+func TestSweep(t *testing.T) {
+	running := newTestStore(t, t.TempDir(), true)
+	appendLine(t, running, "task", "booting")
+	now := time.Now()
+
+	running.sweep(now.Add(2 * logIdle))
+	if _, closed, _, _, _ := running.Read("task", 1); closed {
+		t.Error("idle log of a running task was closed")
+	}
+
+	// the worker died: the task is no longer active but its upload never ended
+	finished := newTestStore(t, t.TempDir(), false)
+	appendLine(t, finished, "task", "booting")
+	_, _, changed, _, _ := finished.Read("task", 1)
+	finished.sweep(now.Add(2 * logIdle))
+	select {
+	case <-changed:
+	default:
+		t.Fatal("followers of an idle log of a finished task were not woken")
+	}
+	if _, closed, _, _, _ := finished.Read("task", 1); !closed {
+		t.Error("idle log of a finished task is still open")
+	}
+
+	finished.sweep(now.Add(2*logIdle + logMemoryRetention + time.Minute))
+	finished.mu.Lock()
+	_, inMemory := finished.tasks["task"]
+	finished.mu.Unlock()
+	if inMemory {
+		t.Error("finished log was not evicted from memory")
+	}
+	if messages, _ := readAll(t, finished, "task", 1); len(messages) != 1 {
+		t.Errorf("evicted log replays %d lines from disk, want 1", len(messages))
+	}
+}
+
+// TestFollowDuringEviction follows a log while appends evict lines from the
+// in-memory tail; the batches handed to the follower must stay intact. Run it with -race
+func TestFollowDuringEviction(t *testing.T) {
+	store := newTestStore(t, t.TempDir(), true)
+	chunk := strings.Repeat("x", 64*1024)
+	const total = 200
+
+	done := make(chan [][]*pb.LogLine)
+	go func() {
+		// the batches are kept, like lines still queued for a slow client
+		var batches [][]*pb.LogLine
+		next := uint64(1)
+		for {
+			lines, closed, changed, ok, err := store.Read("task", next)
+			if err != nil || !ok {
+				t.Errorf("Read(task, %d) = ok %v, error %v", next, ok, err)
+				break
+			}
+			if len(lines) > 0 {
+				batches = append(batches, lines)
+				next = lines[len(lines)-1].Sequence + 1
+				continue
+			}
+			if closed {
+				break
+			}
+			<-changed
+		}
+		done <- batches
+	}()
+
+	// a follower that fell behind reads from the oldest line in memory, the next
+	// append evicts it
+	behind := make(map[uint64][]*pb.LogLine)
+	for i := 0; i < total; i++ {
+		appendLine(t, store, "task", chunk)
+		store.mu.Lock()
+		first := store.tasks["task"].first
+		store.mu.Unlock()
+		lines, _, _, _, err := store.Read("task", first)
+		if err != nil {
+			t.Fatal(err)
+		}
+		behind[first] = lines
+	}
+	store.Close("task")
+
+	want := uint64(1)
+	for _, batch := range <-done {
+		for _, line := range batch {
+			if line == nil {
+				t.Fatalf("line %d of a batch was cleared by a later eviction", want)
+			}
+			if line.Sequence != want {
+				t.Fatalf("got sequence %d, want %d", line.Sequence, want)
+			}
+			want++
+		}
+	}
+	if want != total+1 {
+		t.Errorf("follower got %d lines, want %d", want-1, total)
+	}
+	for from, batch := range behind {
+		for i, line := range batch {
+			if line == nil || line.Sequence != from+uint64(i) {
+				t.Fatalf("batch read from %d was changed by a later eviction: line %d is %v", from, i, line)
+			}
+		}
+	}
+}
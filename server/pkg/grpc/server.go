@@ -1,15 +1,20 @@
 package rpc
 
 import (
+	"Server/pkg/manager"
+	"Server/pkg/model"
 	pb "Server/pkg/proto"
 	"Server/pkg/websocket"
 	"encoding/json"
 	"fmt"
 	"io"
-	"sync"
 	"time"
 
+	"github.com/google/uuid"
 	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
 )
 
 type LogMessageForJSON struct {
@@ -20,16 +25,43 @@ type LogMessageForJSON struct {
 
 type LogStreamServer struct {
 	pb.UnimplementedLogStreamServiceServer
-	mu   sync.RWMutex
-	logs []*pb.LogMessage
+	store    *LogStore
+	progress *manager.ProgressTracker
 
 	Hub *websocket.Hub
 }
 
-func NewLogStreamServer(hub *websocket.Hub) *LogStreamServer {
+// logDir keeps the output of every task for replay, across restarts
+const logDir = "./task-logs"
+
+func NewLogStreamServer(db *gorm.DB, hub *websocket.Hub, progress *manager.ProgressTracker) (*LogStreamServer, error) {
+	store, err := NewLogStore(logDir, taskActive(db))
+	if err != nil {
+		return nil, err
+	}
 	return &LogStreamServer{
-		logs: make([]*pb.LogMessage, 0),
-		Hub:  hub,
+		store:    store,
+		progress: progress,
+		Hub:      hub,
+	}, nil
+}
+
+// taskActive reports whether a task is pending or running, unknown IDs are not
+func taskActive(db *gorm.DB) func(taskID string) bool {
+	return func(taskID string) bool {
+		id, err := uuid.Parse(taskID)
+		if err != nil {
+			return false
+		}
+		var count int64
+		err = db.Model(&model.Task{}).
+			Where("id = ? AND status IN ?", id, []model.TaskStatus{model.StatusPending, model.StatusRunning}).
+			Count(&count).Error
+		if err != nil {
+			log.Errorf("failed to look up task %s: %v", taskID, err)
+			return false
+		}
+		return count > 0
 	}
 }
 
@@ -38,6 +70,14 @@ func (s *LogStreamServer) UploadLogs(stream pb.LogStreamService_UploadLogsServer
 	var count int64
 	var client string
 
+	// whichever way the upload ends, followers of its tasks must not wait forever
+	tasks := make(map[string]bool)
+	defer func() {
+		for taskID := range tasks {
+			s.store.Close(taskID)
+		}
+	}()
+
 	for {
 		logMsg, err := stream.Recv()
 		if err == io.EOF {
@@ -52,9 +92,11 @@ func (s *LogStreamServer) UploadLogs(stream pb.LogStreamService_UploadLogsServer
 			return err
 		}
 
-		s.mu.Lock()
-		s.logs = append(s.logs, logMsg)
-		s.mu.Unlock()
+		if _, err := s.store.Append(logMsg); err != nil {
+			log.Errorf("Failed to store log of task %s: %v", logMsg.TaskId, err)
+		} else {
+			tasks[logMsg.TaskId] = true
+		}
 
 		client = logMsg.ClientId
 		count++
@@ -86,11 +128,92 @@ func (s *LogStreamServer) UploadLogs(stream pb.LogStreamService_UploadLogsServer
 	}
 }
 
-func (s *LogStreamServer) GetStoredLogs() []*pb.LogMessage {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+// TailLogs sends stored lines and then waits for new ones. Every line is read
+// from the store and sent with a blocking Send, so a slow client is throttled
+// by gRPC flow control instead of losing lines. Tasks without output are
+// NotFound unless they are still pending or running.
+func (s *LogStreamServer) TailLogs(req *pb.TailRequest, stream pb.LogStreamService_TailLogsServer) error {
+	if req.TaskId == "" {
+		return status.Error(codes.InvalidArgument, "task_id is required")
+	}
+
+	next := req.FromSequence
+	if next == 0 {
+		next = 1
+	}
+
+	for {
+		lines, closed, changed, ok, err := s.store.Read(req.TaskId, next)
+		if err != nil {
+			return status.Errorf(codes.Internal, "failed to read output of task %s: %v", req.TaskId, err)
+		}
+		if !ok {
+			return status.Errorf(codes.NotFound, "no output stored for task %s", req.TaskId)
+		}
+
+		for _, line := range lines {
+			if err := stream.Send(line); err != nil {
+				return err
+			}
+			next = line.Sequence + 1
+		}
+
+		if len(lines) > 0 {
+			continue
+		}
+		if closed || !req.Follow {
+			return nil
+		}
+
+		select {
+		case <-changed:
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		}
+	}
+}
+
+// UploadProgress records the structured progress of kernel-builder as forwarded
+// by the worker; only the latest state per task is kept
+func (s *LogStreamServer) UploadProgress(stream pb.LogStreamService_UploadProgressServer) error {
+	var count int64
+	var client string
 
-	logs := make([]*pb.LogMessage, len(s.logs))
-	copy(logs, s.logs)
-	return logs
+	for {
+		record, err := stream.Recv()
+		if err == io.EOF {
+			return stream.SendAndClose(&pb.UploadLogsResponse{
+				Success: true,
+				Message: fmt.Sprintf("successfully received %d progress records from client %s", count, client),
+			})
+		}
+		if err != nil {
+			log.Infof("Error receiving progress: %v", err)
+			return err
+		}
+		if record.TaskId == "" {
+			return status.Error(codes.InvalidArgument, "task_id is required")
+		}
+
+		occurredAt, err := time.Parse(time.RFC3339Nano, record.Timestamp)
+		if err != nil {
+			occurredAt = time.Now()
+		}
+		event := manager.ProgressEvent{
+			Kind:       record.Kind,
+			Step:       record.Step,
+			Current:    record.Current,
+			Total:      record.Total,
+			Message:    record.Message,
+			OccurredAt: occurredAt.UTC(),
+		}
+		if record.HasPercent {
+			percent := record.Percent
+			event.Percent = &percent
+		}
+		s.progress.Record(record.TaskId, event)
+
+		client = record.ClientId
+		count++
+	}
 }
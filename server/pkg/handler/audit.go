@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"Server/pkg/model"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetAuditLogHandler queries the audit log, newest first. Every filter is
+// optional: actor_type, actor_id, action, resource_type, resource_id, and
+// since/until as RFC 3339 timestamps.
+func GetAuditLogHandler(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+		if err != nil || limit <= 0 || limit > 1000 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 1000"})
+			return
+		}
+		offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+		if err != nil || offset < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "offset must be a non-negative integer"})
+			return
+		}
+
+		query := db.Model(&model.AuditEntry{})
+		for _, column := range []string{"actor_type", "actor_id", "action", "resource_type", "resource_id"} {
+			if value := c.Query(column); value != "" {
+				query = query.Where(column+" = ?", value)
+			}
+		}
+		for param, condition := range map[string]string{"since": "created_at >= ?", "until": "created_at < ?"} {
+			value := c.Query(param)
+			if value == "" {
+				continue
+			}
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "'" + param + "' must be an RFC 3339 timestamp"})
+				return
+			}
+			query = query.Where(condition, t)
+		}
+
+		var entries []model.AuditEntry
+		if err := query.Order("created_at desc").Limit(limit).Offset(offset).Find(&entries).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch audit log"})
+			return
+		}
+		c.JSON(http.StatusOK, entries)
+	}
+}
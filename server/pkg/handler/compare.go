@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"Server/pkg/manager"
+	"Server/pkg/model"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CompareTasksHandler compares the runs ?a= and ?b= side by side, typically a
+// kernel-build task with its patch-apply rerun or the same bug built with
+// another compiler
+func CompareTasksHandler(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var tasks [2]model.Task
+		for i, param := range []string{"a", "b"} {
+			taskID, err := uuid.Parse(c.Query(param))
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Query parameters 'a' and 'b' must be task IDs"})
+				return
+			}
+			if err := db.First(&tasks[i], "id = ?", taskID).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					c.JSON(http.StatusNotFound, gin.H{"error": "Task not found: " + taskID.String()})
+				} else {
+					c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+				}
+				return
+			}
+		}
+
+		comparison, err := manager.CompareTasks(db, &tasks[0], &tasks[1])
+		if err != nil {
+			slog.Error("failed to compare tasks", "a", tasks[0].ID, "b", tasks[1].ID, "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compare tasks"})
+			return
+		}
+		c.JSON(http.StatusOK, comparison)
+	}
+}
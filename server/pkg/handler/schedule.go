@@ -0,0 +1,197 @@
+package handler
+
+import (
+	"Server/pkg/manager"
+	"Server/pkg/model"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ScheduleRequest struct {
+	Name         string `json:"name" binding:"required"`
+	Cron         string `json:"cron" binding:"required"`
+	Enabled      *bool  `json:"enabled"`
+	Priority     uint8  `json:"priority"`
+	Subsystem    string `json:"subsystem"`
+	Campaign     string `json:"campaign"`
+	BugStatus    string `json:"bug_status"`
+	KernelCommit string `json:"kernel_commit"`
+}
+
+// apply validates the request and copies it onto the schedule, recomputing the next run
+func (req *ScheduleRequest) apply(schedule *model.Schedule) error {
+	if req.Priority > model.MaxTaskPriority {
+		return fmt.Errorf("priority must be between 0 and %d", model.MaxTaskPriority)
+	}
+	if req.Subsystem == "" && req.Campaign == "" && req.BugStatus == "" {
+		return errors.New("at least one of 'subsystem', 'campaign' or 'bug_status' is required")
+	}
+
+	next, err := manager.NextRun(req.Cron, time.Now())
+	if err != nil {
+		return fmt.Errorf("invalid cron expression: %w", err)
+	}
+
+	schedule.Name = req.Name
+	schedule.Cron = req.Cron
+	schedule.Priority = req.Priority
+	schedule.Subsystem = req.Subsystem
+	schedule.Campaign = req.Campaign
+	schedule.BugStatus = req.BugStatus
+	schedule.KernelCommit = req.KernelCommit
+	schedule.NextRunAt = next
+	if req.Enabled != nil {
+		schedule.Enabled = *req.Enabled
+	}
+	return nil
+}
+
+func findSchedule(c *gin.Context, db *gorm.DB) (*model.Schedule, bool) {
+	scheduleID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid schedule ID format"})
+		return nil, false
+	}
+
+	var schedule model.Schedule
+	if err := db.First(&schedule, "id = ?", scheduleID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Schedule not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		}
+		return nil, false
+	}
+	return &schedule, true
+}
+
+func CreateScheduleHandler(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req ScheduleRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+			return
+		}
+
+		schedule := model.Schedule{
+			ID:      uuid.New(),
+			Enabled: true,
+		}
+		if err := req.apply(&schedule); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if err := db.Create(&schedule).Error; err != nil {
+			slog.Error("failed to create schedule", "name", schedule.Name, "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save schedule"})
+			return
+		}
+
+		slog.Info("schedule created", "schedule_id", schedule.ID, "name", schedule.Name, "cron", schedule.Cron)
+		c.JSON(http.StatusCreated, schedule)
+	}
+}
+
+func GetSchedulesHandler(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var schedules []model.Schedule
+		if err := db.Order("name asc").Find(&schedules).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch schedules"})
+			return
+		}
+		c.JSON(http.StatusOK, schedules)
+	}
+}
+
+func GetScheduleByIDHandler(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		schedule, ok := findSchedule(c, db)
+		if !ok {
+			return
+		}
+		c.JSON(http.StatusOK, schedule)
+	}
+}
+
+func UpdateScheduleHandler(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		schedule, ok := findSchedule(c, db)
+		if !ok {
+			return
+		}
+
+		var req ScheduleRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+			return
+		}
+		if err := req.apply(schedule); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if err := db.Save(schedule).Error; err != nil {
+			slog.Error("failed to update schedule", "schedule_id", schedule.ID, "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update schedule"})
+			return
+		}
+
+		c.JSON(http.StatusOK, schedule)
+	}
+}
+
+func DeleteScheduleHandler(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		scheduleID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid schedule ID format"})
+			return
+		}
+
+		result := db.Delete(&model.Schedule{}, "id = ?", scheduleID)
+		if result.Error != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete schedule"})
+			return
+		}
+		if result.RowsAffected == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Schedule not found"})
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// RunScheduleHandler triggers a schedule immediately without moving its next cron run
+func RunScheduleHandler(db *gorm.DB, scheduler *manager.Scheduler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		schedule, ok := findSchedule(c, db)
+		if !ok {
+			return
+		}
+
+		tasks, err := scheduler.Run(c.Request.Context(), schedule.ID, false)
+		if err != nil {
+			slog.Error("manual schedule run failed", "schedule_id", schedule.ID, "error", err)
+			switch {
+			case errors.Is(err, manager.ErrScheduleRunning), errors.Is(err, manager.ErrScheduleLocked):
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			case errors.Is(err, manager.ErrScheduleNotFound):
+				c.JSON(http.StatusNotFound, gin.H{"error": "Schedule not found"})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "tasks": tasks})
+			}
+			return
+		}
+
+		c.JSON(http.StatusAccepted, gin.H{"schedule_id": schedule.ID, "tasks": tasks})
+	}
+}
@@ -2,8 +2,8 @@ package handler
 
 import (
 	"Server/pkg/manager"
+	"Server/pkg/middleware"
 	"Server/pkg/model"
-	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -11,6 +11,8 @@ import (
 	"log"
 	"log/slog"
 	"net/http"
+	"regexp"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -19,43 +21,127 @@ import (
 	"gorm.io/gorm/clause"
 )
 
-func CreateTaskHandler(db *gorm.DB, rmqClient *manager.RabbitMQClient) gin.HandlerFunc {
+// commitPattern matches a full or abbreviated git commit hash
+var commitPattern = regexp.MustCompile(`^[0-9a-f]{7,40}$`)
+
+var (
+	errTaskNotFound   = errors.New("task not found")
+	errTaskNotPending = errors.New("task is no longer pending")
+	errStaleRevision  = errors.New("task has been re-dispatched, stale queue message")
+	errTaskFinished   = errors.New("task has already finished")
+	errTaskCancelled  = errors.New("task has been cancelled")
+)
+
+// parsePriority reads an optional priority value, falling back to PriorityLow
+func parsePriority(value string) (uint8, error) {
+	if value == "" {
+		return model.PriorityLow, nil
+	}
+	priority, err := strconv.ParseUint(value, 10, 8)
+	if err != nil || uint8(priority) > model.MaxTaskPriority {
+		return 0, fmt.Errorf("priority must be an integer between 0 and %d", model.MaxTaskPriority)
+	}
+	return uint8(priority), nil
+}
+
+// readPatchUploads reads the "patch" files of the form in the order they were
+// sent, which is the order the series is applied in
+func readPatchUploads(c *gin.Context) ([]manager.PatchUpload, error) {
+	form, err := c.MultipartForm()
+	if err != nil {
+		return nil, err
+	}
+	files := form.File["patch"]
+	if len(files) == 0 {
+		return nil, http.ErrMissingFile
+	}
+
+	uploads := make([]manager.PatchUpload, 0, len(files))
+	for _, file := range files {
+		opened, err := file.Open()
+		if err != nil {
+			return nil, err
+		}
+		data, err := io.ReadAll(opened)
+		opened.Close()
+		if err != nil {
+			return nil, err
+		}
+		uploads = append(uploads, manager.PatchUpload{Name: file.Filename, Data: data})
+	}
+	return uploads, nil
+}
+
+// readReportFile decodes the crash report uploaded as the "report" file of the
+// form, returning the status to answer with when it cannot
+func readReportFile(c *gin.Context) (model.CrashReport, int, error) {
+	var report model.CrashReport
+
+	file, err := c.FormFile("report")
+	if err != nil {
+		return report, http.StatusBadRequest, errors.New("Failed to get file from form: " + err.Error())
+	}
+
+	openedFile, err := file.Open()
+	if err != nil {
+		return report, http.StatusInternalServerError, errors.New("Failed to open uploaded file: " + err.Error())
+	}
+	defer func() {
+		if err := openedFile.Close(); err != nil {
+			log.Println(err)
+		}
+	}()
+
+	if err = json.NewDecoder(openedFile).Decode(&report); err != nil {
+		return report, http.StatusBadRequest, errors.New("Failed to parse JSON content from file: " + err.Error())
+	}
+	return report, 0, nil
+}
+
+// readTaskReport takes the bug of a task either from the uploaded report or,
+// when the form names one with "id", from an earlier task without its patches
+func readTaskReport(c *gin.Context, db *gorm.DB) (model.CrashReport, int, error) {
+	existingTaskUUID := c.PostForm("id")
+	if existingTaskUUID == "" {
+		return readReportFile(c)
+	}
+
+	var existingTask model.Task
+	if err := db.First(&existingTask, "id = ?", existingTaskUUID).Error; err != nil {
+		return model.CrashReport{}, http.StatusNotFound, fmt.Errorf("Task with UUID '%s' not found: %v", existingTaskUUID, err)
+	}
+	report := existingTask.Payload
+	report.Patch = ""
+	report.Patches = nil
+	report.PatchModified = nil
+	report.Bisect = nil
+	return report, 0, nil
+}
+
+func CreateTaskHandler(db *gorm.DB, rmqClient *manager.RabbitMQClient, hooks *manager.WebhookDispatcher) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		taskTypeStr := c.PostForm("task_type")
 		taskType := model.TaskType(taskTypeStr)
 
+		priority, err := parsePriority(c.PostForm("priority"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
 		var task *model.Task
-		var err error
 
 		switch taskType {
 		case model.TaskTypeKernelBuild:
 			slog.Info("handling 'kernel-build' task...")
-			var report model.CrashReport
-
-			file, err := c.FormFile("report")
+			report, status, err := readReportFile(c)
 			if err != nil {
-				c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to get file from form: " + err.Error()})
+				c.JSON(status, gin.H{"error": err.Error()})
 				return
 			}
 
-			openedFile, err := file.Open()
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to open uploaded file: " + err.Error()})
-				return
-			}
-			defer func() {
-				if err := openedFile.Close(); err != nil {
-					log.Println(err)
-				}
-			}()
-
-			if err = json.NewDecoder(openedFile).Decode(&report); err != nil {
-				c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to parse JSON content from file: " + err.Error()})
-				return
-			}
-
-			task = model.CreateTask(taskType, report)
-			slog.Info("new 'kernel-build' task created with ID: %s", task.ID)
+			task = model.CreateTask(taskType, report, priority)
+			slog.Info("new 'kernel-build' task created", "task_id", task.ID, "priority", task.Priority)
 
 		case model.TaskTypePatchApply:
 			slog.Info("handling 'patch-apply' task...")
@@ -72,78 +158,132 @@ func CreateTaskHandler(db *gorm.DB, rmqClient *manager.RabbitMQClient) gin.Handl
 				c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("Task with UUID '%s' not found: %v", existingTaskUUID, err)})
 				return
 			}
-			slog.Info("Found existing task %s to apply patch.", existingTask.ID)
+			slog.Info("found existing task to apply patch", "task_id", existingTask.ID)
 
-			patchFile, err := c.FormFile("patch")
+			uploads, err := readPatchUploads(c)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to get patch files from form: " + err.Error()})
+				return
+			}
+			series, summary, err := manager.ParsePatchSeries(uploads)
 			if err != nil {
-				c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to get patch file from form: " + err.Error()})
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid patch: " + err.Error()})
 				return
 			}
 
-			openedPatchFile, err := patchFile.Open()
+			newReport := existingTask.Payload
+			newReport.Patch = manager.JoinSeries(series)
+			newReport.Patches = series
+			newReport.PatchModified = manager.PatchModifiedFiles(summary)
+
+			task = model.CreateTask(taskType, newReport, priority)
+			task.PatchSummary = summary
+			slog.Info("new 'patch-apply' task created", "task_id", task.ID, "base_task_id", existingTask.ID, "priority", task.Priority,
+				"patches", len(series), "files", len(summary.Files), "hunks", summary.Hunks)
+
+		case model.TaskTypeFixVerify:
+			slog.Info("handling 'fix-verify' task...")
+
+			report, status, err := readTaskReport(c, db)
 			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to open patch file: " + err.Error()})
+				c.JSON(status, gin.H{"error": err.Error()})
 				return
 			}
-			defer func() {
-				if err := openedPatchFile.Close(); err != nil {
-					log.Println(err)
-				}
-			}()
 
-			patchBytes, err := io.ReadAll(openedPatchFile)
+			if len(report.Crashes) == 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "fix-verify needs a report with at least one crash"})
+				return
+			}
+			if report.ParentOfFixCommit == "" || report.FixCommit() == "" {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "fix-verify needs a report with parent_of_fix_commit and a fix commit hash"})
+				return
+			}
+
+			task = model.CreateTask(taskType, report, priority)
+			slog.Info("new 'fix-verify' task created", "task_id", task.ID, "priority", task.Priority,
+				"parent_commit", report.ParentOfFixCommit, "fix_commit", report.FixCommit())
+
+		case model.TaskTypeBisect:
+			slog.Info("handling 'bisect' task...")
+
+			report, status, err := readTaskReport(c, db)
 			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read patch file content: " + err.Error()})
+				c.JSON(status, gin.H{"error": err.Error()})
 				return
 			}
-			patchContent := string(patchBytes)
 
-			newReport := existingTask.Payload
-			newReport.Patch = patchContent
+			bisect := model.BisectRange{Good: c.PostForm("good"), Bad: c.PostForm("bad")}
+			if !commitPattern.MatchString(bisect.Good) || !commitPattern.MatchString(bisect.Bad) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "bisect needs 'good' and 'bad' commit hashes"})
+				return
+			}
+			if bisect.Good == bisect.Bad {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "'good' and 'bad' must be different commits"})
+				return
+			}
+			if len(report.Crashes) == 0 || (report.Crashes[0].CReproducer == "" && report.Crashes[0].SyzReproducer == "") {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "bisect needs a report with a C or syz reproducer"})
+				return
+			}
+			report.Bisect = &bisect
 
-			task = model.CreateTask(taskType, newReport)
-			slog.Info("new 'patch-apply' task created with ID: %s based on old task %s", task.ID, existingTask.ID)
+			task = model.CreateTask(taskType, report, priority)
+			slog.Info("new 'bisect' task created", "task_id", task.ID, "priority", task.Priority,
+				"good", bisect.Good, "bad", bisect.Bad)
 
 		default:
-			errorMsg := fmt.Sprintf("Invalid task type: '%s'. Must be one of '%s' or '%s'",
-				taskTypeStr, model.TaskTypeKernelBuild, model.TaskTypePatchApply)
+			errorMsg := fmt.Sprintf("Invalid task type: '%s'. Must be one of '%s', '%s', '%s' or '%s'",
+				taskTypeStr, model.TaskTypeKernelBuild, model.TaskTypePatchApply, model.TaskTypeFixVerify, model.TaskTypeBisect)
 			c.JSON(http.StatusBadRequest, gin.H{"error": errorMsg})
 			return
 		}
 
-		if err = db.Create(&task).Error; err != nil {
-			slog.Info("failed to save task to DB: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save task to database"})
-			return
-		}
-		slog.Info("task %s successfully saved to database.", task.ID)
+		task.Campaign = c.PostForm("campaign")
 
-		taskJSON, err := json.Marshal(task)
-		if err != nil {
-			slog.Info("error marshalling task to JSON: %s", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to serialize task object"})
+		if err = manager.EnqueueTask(c.Request.Context(), db, rmqClient, task, middleware.ActorFromContext(c)); err != nil {
+			slog.Error("failed to enqueue task", "task_id", task.ID, "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to submit task"})
 			return
 		}
 
-		ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
-		defer cancel()
-
-		if err = rmqClient.Publish(ctx, string(taskJSON)); err != nil {
-			slog.Info("failed to publish a message: %s", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to submit task to queue"})
-			return
-		}
-
-		slog.Info("successfully published task %s to queue", task.ID)
+		slog.Info("successfully published task to queue", "task_id", task.ID, "priority", task.Priority)
+		hooks.Emit(model.EventTaskCreated, task)
 
 		c.JSON(http.StatusAccepted, task)
 	}
 }
 
+// GetTasksHandler lists tasks, newest first. With ?sort=priority the tasks are
+// returned in dispatch order instead: highest priority first, FIFO within one
+// priority, which is the order RabbitMQ hands pending tasks to workers.
 func GetTasksHandler(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		query := db.Model(&model.Task{})
+		if status := c.Query("status"); status != "" {
+			query = query.Where("status = ?", status)
+		}
+		if campaign := c.Query("campaign"); campaign != "" {
+			query = query.Where("campaign = ?", campaign)
+		}
+		if category := c.Query("failure_category"); category != "" {
+			query = query.Where("failure->>'category' = ?", category)
+		}
+		if verdict := c.Query("verdict"); verdict != "" {
+			query = query.Where("result->>'verdict' = ?", verdict)
+		}
+
+		switch c.DefaultQuery("sort", "created_at") {
+		case "created_at":
+			query = query.Order("created_at desc")
+		case "priority":
+			query = query.Order("priority desc").Order("created_at asc")
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "sort must be one of 'created_at' or 'priority'"})
+			return
+		}
+
 		var tasks []model.Task
-		if err := db.Order("created_at desc").Find(&tasks).Error; err != nil {
+		if err := query.Find(&tasks).Error; err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tasks"})
 			return
 		}
@@ -178,15 +318,29 @@ func DeleteTaskHandler(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
-		result := db.Delete(&model.Task{}, "id = ?", taskID)
+		// the audit entry keeps the deleted row, so it is written in the same transaction
+		err = db.Transaction(func(tx *gorm.DB) error {
+			var task model.Task
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&task, "id = ?", taskID).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return errTaskNotFound
+				}
+				return err
+			}
 
-		if result.Error != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete task"})
-			return
-		}
+			if err := tx.Delete(&task).Error; err != nil {
+				return err
+			}
+			return manager.Audit(tx, middleware.ActorFromContext(c), model.AuditTaskDelete, "task", task.ID.String(), task, nil)
+		})
 
-		if result.RowsAffected == 0 {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+		if err != nil {
+			if errors.Is(err, errTaskNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+			} else {
+				slog.Error("failed to delete task", "task_id", taskID, "error", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete task"})
+			}
 			return
 		}
 
@@ -194,11 +348,12 @@ func DeleteTaskHandler(db *gorm.DB) gin.HandlerFunc {
 	}
 }
 
-func AcceptTaskHandler(db *gorm.DB) gin.HandlerFunc {
+func AcceptTaskHandler(db *gorm.DB, hooks *manager.WebhookDispatcher) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		type RequestBody struct {
 			ID       string `form:"id"`
 			WorkerID string `form:"worker_id"`
+			Revision *int   `form:"revision"`
 		}
 
 		var req RequestBody
@@ -215,7 +370,13 @@ func AcceptTaskHandler(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
-		workerID := req.WorkerID
+		// the task goes to the authenticated worker, a worker_id in the form is only
+		// checked against it so that one worker cannot accept tasks for another
+		workerID := c.MustGet("worker").(*model.Worker).WorkerID
+		if req.WorkerID != "" && req.WorkerID != workerID {
+			c.JSON(http.StatusForbidden, gin.H{"error": "worker_id does not match the authenticated worker"})
+			return
+		}
 
 		var updatedTask model.Task
 
@@ -224,15 +385,19 @@ func AcceptTaskHandler(db *gorm.DB) gin.HandlerFunc {
 
 			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&task, "id = ?", taskID).Error; err != nil {
 				if errors.Is(err, gorm.ErrRecordNotFound) {
-					return fmt.Errorf("task not found")
+					return errTaskNotFound
 				}
 				return err
 			}
 
-			if task.WorkerID != "" && task.Status == model.StatusSuccess {
-				return fmt.Errorf("task already accepted by worker %s", task.WorkerID)
+			if task.Status != model.StatusPending {
+				return fmt.Errorf("%w: status is %s, worker %s", errTaskNotPending, task.Status, task.WorkerID)
+			}
+			if req.Revision != nil && *req.Revision != task.Revision {
+				return fmt.Errorf("%w: message revision %d, current %d", errStaleRevision, *req.Revision, task.Revision)
 			}
 
+			before := task
 			task.WorkerID = workerID
 			task.Status = model.StatusRunning
 			now := time.Now()
@@ -241,6 +406,13 @@ func AcceptTaskHandler(db *gorm.DB) gin.HandlerFunc {
 			if err := tx.Save(&task).Error; err != nil {
 				return err
 			}
+			actor := middleware.ActorFromContext(c)
+			if err := manager.RecordStatus(tx, actor, task.ID, before.Status, task.Status); err != nil {
+				return err
+			}
+			if err := manager.Audit(tx, actor, model.AuditTaskAccept, "task", task.ID.String(), before, task); err != nil {
+				return err
+			}
 
 			updatedTask = task
 			return nil
@@ -249,9 +421,9 @@ func AcceptTaskHandler(db *gorm.DB) gin.HandlerFunc {
 		if err != nil {
 			slog.Error("failed to accept task", "task_id", taskID, "worker_id", workerID, "error", err)
 
-			if err.Error() == "task not found" {
+			if errors.Is(err, errTaskNotFound) {
 				c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
-			} else if len(err.Error()) > 20 && err.Error()[:20] == "task already accepted" {
+			} else if errors.Is(err, errTaskNotPending) || errors.Is(err, errStaleRevision) {
 				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
 			} else {
 				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update task in database"})
@@ -260,15 +432,22 @@ func AcceptTaskHandler(db *gorm.DB) gin.HandlerFunc {
 		}
 
 		slog.Info("task accepted successfully", "task_id", updatedTask.ID, "worker_id", updatedTask.WorkerID)
+		hooks.Emit(model.EventTaskStarted, updatedTask)
 		c.JSON(http.StatusOK, updatedTask)
 	}
 }
 
-func UpdateTaskStatusHandler(db *gorm.DB) gin.HandlerFunc {
+func UpdateTaskStatusHandler(db *gorm.DB, hooks *manager.WebhookDispatcher) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		type RequestBody struct {
-			Status model.TaskStatus `json:"status" binding:"required"`
-			Result string           `json:"result"`
+			Status  model.TaskStatus     `json:"status" binding:"required"`
+			Result  model.TaskResult     `json:"result"`
+			Failure *model.TaskFailure   `json:"failure"`
+			Console *model.ConsoleReport `json:"console_report"`
+
+			// recorded apart from the task, see model.TaskBuild
+			KernelConfig string   `json:"kernel_config"`
+			Diagnostics  []string `json:"diagnostics"`
 		}
 
 		idStr := c.Param("id")
@@ -290,23 +469,71 @@ func UpdateTaskStatusHandler(db *gorm.DB) gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, gin.H{"error": errorMsg})
 			return
 		}
+		if reqBody.Failure != nil {
+			if reqBody.Status != model.StatusFailed {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "failure is only accepted with status 'failed'"})
+				return
+			}
+			if !reqBody.Failure.Category.Valid() {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("unknown failure category '%s'", reqBody.Failure.Category)})
+				return
+			}
+		}
+		if reqBody.Console != nil {
+			if !reqBody.Console.Kind.Valid() {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("unknown console report kind '%s'", reqBody.Console.Kind)})
+				return
+			}
+			if reqBody.Console.Title == "" {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "console report requires a title"})
+				return
+			}
+		}
 
+		worker := c.MustGet("worker").(*model.Worker)
 		var updatedTask model.Task
 
 		err = db.Transaction(func(tx *gorm.DB) error {
 			var task model.Task
 
-			if err := tx.First(&task, "id = ?", taskID).Error; err != nil {
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&task, "id = ?", taskID).Error; err != nil {
 				if errors.Is(err, gorm.ErrRecordNotFound) {
-					return fmt.Errorf("task not found")
+					return errTaskNotFound
 				}
 				return err
 			}
 
+			// a cancelled task keeps its status, the worker's late report is refused
+			if task.Status == model.StatusCancelled {
+				return errTaskCancelled
+			}
+			// only the worker the task was dispatched to reports its outcome
+			if task.WorkerID != worker.WorkerID {
+				return fmt.Errorf("%w: worker %s", errTaskNotAssigned, task.WorkerID)
+			}
+
+			before := task
+
+			result := reqBody.Result
+			manager.JudgeResult(&result, reqBody.Status, task.Payload.Title, reqBody.Console)
+			switch {
+			case task.Type == model.TaskTypePatchApply:
+				manager.JudgePatch(&result)
+			case task.Type == model.TaskTypeFixVerify && result.FixVerify != nil:
+				manager.JudgeFix(result.FixVerify, task.Payload.Title)
+			case task.Type == model.TaskTypeBisect && result.Bisect != nil:
+				manager.JudgeBisect(result.Bisect, task.Payload.Title)
+			}
+			if len(result.Runs) > 0 {
+				manager.JudgeRuns(&result, task.Payload.Title)
+			}
+
 			updateFields := map[string]any{
-				"status":      reqBody.Status,
-				"result":      reqBody.Result,
-				"finished_at": time.Now().UTC(),
+				"status":         reqBody.Status,
+				"result":         &result,
+				"failure":        reqBody.Failure,
+				"console_report": reqBody.Console,
+				"finished_at":    time.Now().UTC(),
 			}
 
 			if err := tx.Model(&task).Updates(updateFields).Error; err != nil {
@@ -316,17 +543,37 @@ func UpdateTaskStatusHandler(db *gorm.DB) gin.HandlerFunc {
 			updatedTask = task
 
 			updatedTask.Status = reqBody.Status
-			updatedTask.Result = reqBody.Result
+			updatedTask.Result = &result
+			updatedTask.Failure = reqBody.Failure
+			updatedTask.Console = reqBody.Console
 			now := time.Now().UTC()
 			updatedTask.FinishedAt = &now
 
-			return nil
+			if reqBody.KernelConfig != "" || len(reqBody.Diagnostics) > 0 {
+				build := model.TaskBuild{
+					TaskID:       task.ID,
+					KernelConfig: reqBody.KernelConfig,
+					Diagnostics:  reqBody.Diagnostics,
+					CreatedAt:    now,
+				}
+				if err := tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(&build).Error; err != nil {
+					return err
+				}
+			}
+
+			actor := middleware.ActorFromContext(c)
+			if err := manager.RecordStatus(tx, actor, task.ID, before.Status, updatedTask.Status); err != nil {
+				return err
+			}
+			return manager.Audit(tx, actor, model.AuditTaskStatusUpdate, "task", task.ID.String(), before, updatedTask)
 		})
 
 		if err != nil {
 			slog.Error("failed to update task status", "task_id", taskID, "error", err)
-			if err.Error() == "task not found" {
+			if errors.Is(err, errTaskNotFound) {
 				c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			} else if errors.Is(err, errTaskCancelled) || errors.Is(err, errTaskNotAssigned) {
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
 			} else {
 				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update task in database"})
 			}
@@ -334,6 +581,195 @@ func UpdateTaskStatusHandler(db *gorm.DB) gin.HandlerFunc {
 		}
 
 		slog.Info("task status updated successfully", "task_id", updatedTask.ID, "new_status", updatedTask.Status)
+		if updatedTask.Status == model.StatusSuccess {
+			hooks.Emit(model.EventTaskSucceeded, updatedTask)
+		} else {
+			hooks.Emit(model.EventTaskFailed, updatedTask)
+		}
 		c.JSON(http.StatusOK, updatedTask)
 	}
 }
+
+// UpdateTaskPriorityHandler re-prioritizes a pending task. RabbitMQ cannot
+// reorder messages already in the queue, so the task's revision is bumped and
+// it is published again with the new priority; workers that receive the old
+// message are refused by AcceptTaskHandler and drop it.
+func UpdateTaskPriorityHandler(db *gorm.DB, rmqClient *manager.RabbitMQClient) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		type RequestBody struct {
+			Priority *uint8 `json:"priority" binding:"required"`
+		}
+
+		taskID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID format"})
+			return
+		}
+
+		var reqBody RequestBody
+		if err := c.ShouldBindJSON(&reqBody); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+			return
+		}
+		if *reqBody.Priority > model.MaxTaskPriority {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("priority must be between 0 and %d", model.MaxTaskPriority)})
+			return
+		}
+
+		var updatedTask model.Task
+		var previousPriority uint8
+
+		err = db.Transaction(func(tx *gorm.DB) error {
+			var task model.Task
+
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&task, "id = ?", taskID).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return errTaskNotFound
+				}
+				return err
+			}
+
+			if task.Status != model.StatusPending {
+				return fmt.Errorf("%w: status is %s", errTaskNotPending, task.Status)
+			}
+
+			before := task
+			previousPriority = task.Priority
+			task.Priority = *reqBody.Priority
+			task.Revision++
+
+			updates := map[string]any{
+				"priority": task.Priority,
+				"revision": task.Revision,
+			}
+			if err := tx.Model(&task).Updates(updates).Error; err != nil {
+				return err
+			}
+			if err := manager.Audit(tx, middleware.ActorFromContext(c), model.AuditTaskPriorityUpdate, "task", task.ID.String(), before, task); err != nil {
+				return err
+			}
+
+			updatedTask = task
+			return nil
+		})
+
+		if err == nil {
+			// publish after commit so that a worker receiving the new message sees
+			// the new revision; if the broker refuses it, undo the bump so the
+			// message already in the queue stays valid
+			err = manager.PublishTask(c.Request.Context(), rmqClient, &updatedTask)
+			if err != nil {
+				db.Model(&model.Task{}).
+					Where("id = ? AND revision = ?", updatedTask.ID, updatedTask.Revision).
+					Updates(map[string]any{"priority": previousPriority, "revision": updatedTask.Revision - 1})
+			}
+		}
+
+		if err != nil {
+			slog.Error("failed to update task priority", "task_id", taskID, "error", err)
+			if errors.Is(err, errTaskNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			} else if errors.Is(err, errTaskNotPending) {
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update task priority"})
+			}
+			return
+		}
+
+		slog.Info("task re-prioritized", "task_id", updatedTask.ID, "priority", updatedTask.Priority, "revision", updatedTask.Revision)
+		c.JSON(http.StatusOK, updatedTask)
+	}
+}
+
+// CancelTaskHandler stops a task that has not finished yet. A pending task is
+// refused by AcceptTaskHandler when its queue message arrives, a running task
+// keeps the cancelled status when its worker reports back.
+func CancelTaskHandler(db *gorm.DB, hooks *manager.WebhookDispatcher) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		taskID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID format"})
+			return
+		}
+
+		var cancelledTask model.Task
+
+		err = db.Transaction(func(tx *gorm.DB) error {
+			var task model.Task
+
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&task, "id = ?", taskID).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return errTaskNotFound
+				}
+				return err
+			}
+
+			if task.Status != model.StatusPending && task.Status != model.StatusRunning {
+				return fmt.Errorf("%w: status is %s", errTaskFinished, task.Status)
+			}
+
+			before := task
+			now := time.Now().UTC()
+			task.Status = model.StatusCancelled
+			task.FinishedAt = &now
+
+			updates := map[string]any{
+				"status":      task.Status,
+				"finished_at": task.FinishedAt,
+			}
+			if err := tx.Model(&task).Updates(updates).Error; err != nil {
+				return err
+			}
+			actor := middleware.ActorFromContext(c)
+			if err := manager.RecordStatus(tx, actor, task.ID, before.Status, task.Status); err != nil {
+				return err
+			}
+			if err := manager.Audit(tx, actor, model.AuditTaskCancel, "task", task.ID.String(), before, task); err != nil {
+				return err
+			}
+
+			cancelledTask = task
+			return nil
+		})
+
+		if err != nil {
+			slog.Error("failed to cancel task", "task_id", taskID, "error", err)
+			if errors.Is(err, errTaskNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			} else if errors.Is(err, errTaskFinished) {
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel task"})
+			}
+			return
+		}
+
+		slog.Info("task cancelled", "task_id", cancelledTask.ID)
+		hooks.Emit(model.EventTaskCancelled, cancelledTask)
+		c.JSON(http.StatusOK, cancelledTask)
+	}
+}
+
+// GetFailureStatsHandler counts failed tasks per campaign and failure category,
+// optionally for a single ?campaign=. Failed tasks reported by workers without
+// classification are counted as unknown.
+func GetFailureStatsHandler(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		query := db.Model(&model.Task{}).
+			Select("campaign, COALESCE(failure->>'category', ?) AS category, COUNT(*) AS count", model.FailureUnknown).
+			Where("status = ?", model.StatusFailed)
+		if campaign, ok := c.GetQuery("campaign"); ok {
+			query = query.Where("campaign = ?", campaign)
+		}
+
+		stats := []model.FailureStat{}
+		err := query.Group("campaign, category").Order("campaign, count desc, category").Scan(&stats).Error
+		if err != nil {
+			slog.Error("failed to aggregate task failures", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to aggregate task failures"})
+			return
+		}
+		c.JSON(http.StatusOK, stats)
+	}
+}
@@ -0,0 +1,157 @@
+package handler
+
+import (
+	"Server/pkg/manager"
+	"Server/pkg/model"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var errTaskNotAssigned = errors.New("task is not running on this worker")
+
+func GetTaskTimelineHandler(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		taskID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID format"})
+			return
+		}
+
+		var task model.Task
+		if err := db.First(&task, "id = ?", taskID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			}
+			return
+		}
+
+		timeline, err := manager.Timeline(db, &task)
+		if err != nil {
+			slog.Error("failed to load task timeline", "task_id", taskID, "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch task timeline"})
+			return
+		}
+		c.JSON(http.StatusOK, timeline)
+	}
+}
+
+// GetTaskProgressHandler returns the live progress of a task, e.g. the percent
+// of objects compiled. Tasks that have not reported any progress yet get an
+// empty progress without step.
+func GetTaskProgressHandler(db *gorm.DB, tracker *manager.ProgressTracker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		taskID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID format"})
+			return
+		}
+
+		var task model.Task
+		if err := db.Select("id").First(&task, "id = ?", taskID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			}
+			return
+		}
+
+		progress, ok := tracker.Get(task.ID.String())
+		if !ok {
+			progress = model.TaskProgress{TaskID: task.ID.String()}
+		}
+		if progress.Warnings == nil {
+			progress.Warnings = []model.ProgressWarning{}
+		}
+		c.JSON(http.StatusOK, progress)
+	}
+}
+
+// ReportTaskEventHandler records a workflow step boundary reported by the
+// worker running the task. Status transitions are recorded by the server itself
+// and cannot be reported here.
+func ReportTaskEventHandler(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		type RequestBody struct {
+			Kind       model.TaskEventKind `json:"kind" binding:"required"`
+			Step       string              `json:"step" binding:"required"`
+			OccurredAt *time.Time          `json:"occurred_at"`
+			DurationMs *int64              `json:"duration_ms"`
+			Error      string              `json:"error"`
+		}
+
+		taskID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID format"})
+			return
+		}
+
+		var reqBody RequestBody
+		if err := c.ShouldBindJSON(&reqBody); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+			return
+		}
+		if reqBody.Kind != model.TaskEventStepStart && reqBody.Kind != model.TaskEventStepFinish {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("kind must be '%s' or '%s'", model.TaskEventStepStart, model.TaskEventStepFinish)})
+			return
+		}
+		if reqBody.DurationMs != nil && *reqBody.DurationMs < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "duration_ms must not be negative"})
+			return
+		}
+
+		worker := c.MustGet("worker").(*model.Worker)
+		event := model.TaskEvent{
+			TaskID:     taskID,
+			Kind:       reqBody.Kind,
+			Step:       reqBody.Step,
+			DurationMs: reqBody.DurationMs,
+			Error:      reqBody.Error,
+			Source:     worker.WorkerID,
+		}
+		if reqBody.OccurredAt != nil {
+			event.OccurredAt = reqBody.OccurredAt.UTC()
+		}
+
+		err = db.Transaction(func(tx *gorm.DB) error {
+			// shared lock, a concurrent cancel or status report waits for the event
+			var task model.Task
+			if err := tx.Clauses(clause.Locking{Strength: "SHARE"}).First(&task, "id = ?", taskID).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return errTaskNotFound
+				}
+				return err
+			}
+			if task.Status != model.StatusRunning || task.WorkerID != worker.WorkerID {
+				return fmt.Errorf("%w: status is %s, worker %s", errTaskNotAssigned, task.Status, task.WorkerID)
+			}
+			return manager.RecordStep(tx, &event)
+		})
+
+		if err != nil {
+			slog.Error("failed to record task event", "task_id", taskID, "step", reqBody.Step, "error", err)
+			if errors.Is(err, errTaskNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			} else if errors.Is(err, errTaskNotAssigned) {
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			} else if errors.Is(err, manager.ErrStepNotStarted) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record task event"})
+			}
+			return
+		}
+
+		c.JSON(http.StatusCreated, event)
+	}
+}
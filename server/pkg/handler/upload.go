@@ -1,6 +1,8 @@
 package handler
 
 import (
+	"Server/pkg/manager"
+	"Server/pkg/middleware"
 	"Server/pkg/model"
 	"fmt"
 	"io" // 导入 io 包以使用 io.Copy
@@ -16,7 +18,7 @@ import (
 const artifactDir = "./artifacts"
 
 // UploadTaskArtifactStreamHandler 处理 Worker 上传的产物文件（流式）
-func UploadTaskArtifactHandler(db *gorm.DB) gin.HandlerFunc {
+func UploadTaskArtifactHandler(db *gorm.DB, hooks *manager.WebhookDispatcher) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		taskID := c.Param("id")
 
@@ -26,6 +28,12 @@ func UploadTaskArtifactHandler(db *gorm.DB) gin.HandlerFunc {
 			c.JSON(http.StatusNotFound, gin.H{"error": "任务不存在"})
 			return
 		}
+		// 只有执行该任务的 Worker 可以上传产物
+		worker := c.MustGet("worker").(*model.Worker)
+		if task.WorkerID != worker.WorkerID {
+			c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("%v: worker %s", errTaskNotAssigned, task.WorkerID)})
+			return
+		}
 
 		// --- 流式处理核心改动 ---
 		// 2. 直接从请求中获取 multipart reader，而不是一次性解析整个表单
@@ -72,7 +80,8 @@ func UploadTaskArtifactHandler(db *gorm.DB) gin.HandlerFunc {
 
 		// 6. 【核心】使用 io.Copy 将文件内容从请求体直接流式传输到目标文件
 		// 数据以块(chunk)的形式被读取和写入，内存占用非常小。
-		if _, err := io.Copy(dst, part); err != nil {
+		size, err := io.Copy(dst, part)
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "保存产物文件失败: " + err.Error()})
 			return
 		}
@@ -83,12 +92,25 @@ func UploadTaskArtifactHandler(db *gorm.DB) gin.HandlerFunc {
 			"artifact_path": artifactPath,
 			"artifact_name": fileName,
 		}
-		if err := db.Model(&task).Updates(updates).Error; err != nil {
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(&task).Updates(updates).Error; err != nil {
+				return err
+			}
+			after := gin.H{"artifact_name": fileName, "size": size}
+			return manager.Audit(tx, middleware.ActorFromContext(c), model.AuditArtifactUpload, "task", task.ID.String(), nil, after)
+		})
+		if err != nil {
+			slog.Error("更新任务产物信息失败", "task_id", taskID, "error", err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "更新任务数据库失败"})
 			return
 		}
 
 		slog.Info("产物流式上传成功", "task_id", taskID, "path", artifactPath)
+		hooks.Emit(model.EventArtifactUploaded, gin.H{
+			"task_id":       task.ID,
+			"artifact_name": fileName,
+			"size":          size,
+		})
 		c.JSON(http.StatusOK, gin.H{"message": "产物上传成功"})
 	}
 }
@@ -131,6 +153,12 @@ func DownloadTaskArtifactHandler(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
+		// 记录产物访问，审计失败不影响下载
+		accessed := gin.H{"artifact_name": task.ArtifactName, "size": fileInfo.Size()}
+		if err := manager.Audit(db, middleware.ActorFromContext(c), model.AuditArtifactDownload, "task", task.ID.String(), nil, accessed); err != nil {
+			slog.Error("记录产物访问失败", "task_id", taskID, "error", err)
+		}
+
 		// 4. 设置响应头 (逻辑不变，但增加了 Content-Length)
 		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", task.ArtifactName))
 		c.Header("Content-Type", "application/octet-stream")
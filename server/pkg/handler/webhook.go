@@ -0,0 +1,233 @@
+package handler
+
+import (
+	"Server/pkg/manager"
+	"Server/pkg/middleware"
+	"Server/pkg/model"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type WebhookRequest struct {
+	URL    string   `json:"url" binding:"required"`
+	Events []string `json:"events" binding:"required"`
+	Secret string   `json:"secret"`
+	Active *bool    `json:"active"`
+}
+
+func (req *WebhookRequest) validate() error {
+	u, err := url.Parse(req.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid webhook url %q", req.URL)
+	}
+	if len(req.Events) == 0 {
+		return errors.New("at least one event is required")
+	}
+	for _, event := range req.Events {
+		if event != model.EventAll && !slices.Contains(model.WebhookEvents, event) {
+			return fmt.Errorf("unknown event %q, must be one of %v or %q", event, model.WebhookEvents, model.EventAll)
+		}
+	}
+	return nil
+}
+
+func findWebhook(c *gin.Context, db *gorm.DB) (*model.Webhook, bool) {
+	webhookID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook ID format"})
+		return nil, false
+	}
+
+	var webhook model.Webhook
+	if err := db.First(&webhook, "id = ?", webhookID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		}
+		return nil, false
+	}
+	return &webhook, true
+}
+
+// CreateWebhookHandler registers a receiver. The signing secret is generated
+// unless given and is only returned in this response.
+func CreateWebhookHandler(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req WebhookRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+			return
+		}
+		if err := req.validate(); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		secret := req.Secret
+		if secret == "" {
+			secret = middleware.GenerateSecureAPIKey()
+		}
+
+		webhook := model.Webhook{
+			ID:     uuid.New(),
+			URL:    req.URL,
+			Secret: secret,
+			Events: req.Events,
+			Active: req.Active == nil || *req.Active,
+		}
+		if err := db.Create(&webhook).Error; err != nil {
+			slog.Error("failed to create webhook", "url", webhook.URL, "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save webhook"})
+			return
+		}
+
+		slog.Info("webhook registered", "webhook_id", webhook.ID, "url", webhook.URL, "events", webhook.Events)
+		c.JSON(http.StatusCreated, gin.H{
+			"webhook": webhook,
+			"secret":  secret,
+			"message": "Webhook created. Please save the signing secret securely.",
+		})
+	}
+}
+
+func GetWebhooksHandler(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var webhooks []model.Webhook
+		if err := db.Order("created_at asc").Find(&webhooks).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch webhooks"})
+			return
+		}
+		c.JSON(http.StatusOK, webhooks)
+	}
+}
+
+func GetWebhookByIDHandler(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		webhook, ok := findWebhook(c, db)
+		if !ok {
+			return
+		}
+		c.JSON(http.StatusOK, webhook)
+	}
+}
+
+func UpdateWebhookHandler(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		webhook, ok := findWebhook(c, db)
+		if !ok {
+			return
+		}
+
+		var req WebhookRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+			return
+		}
+		if err := req.validate(); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		webhook.URL = req.URL
+		webhook.Events = req.Events
+		if req.Secret != "" {
+			webhook.Secret = req.Secret
+		}
+		if req.Active != nil {
+			webhook.Active = *req.Active
+		}
+
+		if err := db.Save(webhook).Error; err != nil {
+			slog.Error("failed to update webhook", "webhook_id", webhook.ID, "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update webhook"})
+			return
+		}
+		c.JSON(http.StatusOK, webhook)
+	}
+}
+
+func DeleteWebhookHandler(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		webhookID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook ID format"})
+			return
+		}
+
+		result := db.Delete(&model.Webhook{}, "id = ?", webhookID)
+		if result.Error != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete webhook"})
+			return
+		}
+		if result.RowsAffected == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found"})
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// GetWebhookDeliveriesHandler returns the delivery log of a webhook, newest first
+func GetWebhookDeliveriesHandler(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		webhook, ok := findWebhook(c, db)
+		if !ok {
+			return
+		}
+
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+		if err != nil || limit <= 0 || limit > 1000 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 1000"})
+			return
+		}
+
+		query := db.Where("webhook_id = ?", webhook.ID)
+		if status := c.Query("status"); status != "" {
+			query = query.Where("status = ?", status)
+		}
+
+		var deliveries []model.WebhookDelivery
+		if err := query.Order("created_at desc").Limit(limit).Find(&deliveries).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch deliveries"})
+			return
+		}
+		c.JSON(http.StatusOK, deliveries)
+	}
+}
+
+func RedeliverWebhookHandler(hooks *manager.WebhookDispatcher) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		webhookID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook ID format"})
+			return
+		}
+		deliveryID, err := uuid.Parse(c.Param("delivery_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid delivery ID format"})
+			return
+		}
+
+		delivery, err := hooks.Redeliver(webhookID, deliveryID)
+		if err != nil {
+			if errors.Is(err, manager.ErrDeliveryNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to queue redelivery"})
+			}
+			return
+		}
+		c.JSON(http.StatusAccepted, delivery)
+	}
+}
@@ -19,6 +19,19 @@ type RegisterWorkerRequest struct {
 	Hostname string `json:"hostname"`
 }
 
+// updateWorkerAudited applies updates to a worker that has proven its identity
+// with its API key and records the change
+func updateWorkerAudited(c *gin.Context, db *gorm.DB, worker *model.Worker, updates map[string]any, action string) error {
+	actor := model.Actor{Type: model.ActorWorker, ID: worker.WorkerID, IP: c.ClientIP()}
+	before := *worker
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(worker).Updates(updates).Error; err != nil {
+			return err
+		}
+		return manager.Audit(tx, actor, action, "worker", worker.WorkerID, before, *worker)
+	})
+}
+
 func RegisterWorkerHandler(db *gorm.DB, mgr *manager.WorkerManager) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var req RegisterWorkerRequest
@@ -47,7 +60,15 @@ func RegisterWorkerHandler(db *gorm.DB, mgr *manager.WorkerManager) gin.HandlerF
 				LastSeen: func() *time.Time { t := time.Now(); return &t }(),
 			}
 
-			if err := db.Create(&newWorker).Error; err != nil {
+			actor := model.Actor{Type: model.ActorWorker, ID: req.WorkerID, IP: c.ClientIP()}
+			err = db.Transaction(func(tx *gorm.DB) error {
+				if err := tx.Create(&newWorker).Error; err != nil {
+					return err
+				}
+				return manager.Audit(tx, actor, model.AuditWorkerRegister, "worker", newWorker.WorkerID, nil, newWorker)
+			})
+			if err != nil {
+				slog.Error("failed to create worker", "worker_id", req.WorkerID, "error", err)
 				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create new worker record"})
 				return
 			}
@@ -65,7 +86,7 @@ func RegisterWorkerHandler(db *gorm.DB, mgr *manager.WorkerManager) gin.HandlerF
 				c.JSON(http.StatusUnauthorized, gin.H{"error": "API key is required for existing worker"})
 				return
 			}
-			slog.Info(req.APIKey, worker.APIKey)
+			slog.Debug("verifying API key for existing worker", "worker_id", worker.WorkerID)
 			if !middleware.CheckAPIKeyHash(req.APIKey, worker.APIKey) {
 				c.JSON(http.StatusForbidden, gin.H{"error": "Invalid API key"})
 				return
@@ -77,7 +98,8 @@ func RegisterWorkerHandler(db *gorm.DB, mgr *manager.WorkerManager) gin.HandlerF
 				"last_seen": &now,
 				"hostname":  req.Hostname,
 			}
-			if err := db.Model(&worker).Updates(updates).Error; err != nil {
+			if err := updateWorkerAudited(c, db, &worker, updates, model.AuditWorkerRegister); err != nil {
+				slog.Error("failed to update worker", "worker_id", worker.WorkerID, "error", err)
 				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update worker status"})
 				return
 			}
@@ -142,7 +164,8 @@ func UnregisterWorkerHandler(db *gorm.DB, mgr *manager.WorkerManager) gin.Handle
 				"last_seen": &now,
 				"hostname":  req.Hostname,
 			}
-			if err := db.Model(&worker).Updates(updates).Error; err != nil {
+			if err := updateWorkerAudited(c, db, &worker, updates, model.AuditWorkerUnregister); err != nil {
+				slog.Error("failed to update worker", "worker_id", worker.WorkerID, "error", err)
 				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update worker status"})
 				return
 			}
@@ -160,3 +183,79 @@ func UnregisterWorkerHandler(db *gorm.DB, mgr *manager.WorkerManager) gin.Handle
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error: " + result.Error.Error()})
 	}
 }
+
+// RotateWorkerKeyHandler issues a new API key for a worker, invalidating the old
+// one. The worker is taken offline and has to register again with the new key.
+func RotateWorkerKeyHandler(db *gorm.DB, mgr *manager.WorkerManager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		workerID := c.Param("worker_id")
+
+		var worker model.Worker
+		if err := db.Where("worker_id = ?", workerID).First(&worker).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Worker not found"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			}
+			return
+		}
+
+		newApiKey := middleware.GenerateSecureAPIKey()
+		hashedApiKey, err := middleware.HashAPIKey(newApiKey)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash API key"})
+			return
+		}
+
+		err = db.Transaction(func(tx *gorm.DB) error {
+			updates := map[string]any{
+				"api_key": hashedApiKey,
+				"status":  "offline",
+			}
+			if err := tx.Model(&worker).Updates(updates).Error; err != nil {
+				return err
+			}
+			// the key itself never enters the audit log, the entry only records that it changed
+			return manager.Audit(tx, middleware.ActorFromContext(c), model.AuditWorkerKeyRotate, "worker", worker.WorkerID, nil, nil)
+		})
+		if err != nil {
+			slog.Error("failed to rotate worker API key", "worker_id", worker.WorkerID, "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rotate API key"})
+			return
+		}
+		mgr.Unregister(worker.WorkerID)
+
+		slog.Info("worker API key rotated", "worker_id", worker.WorkerID)
+		c.JSON(http.StatusOK, gin.H{
+			"status":    "rotated",
+			"message":   "API key rotated. The worker must register again with the new key.",
+			"worker_id": worker.WorkerID,
+			"api_key":   newApiKey,
+		})
+	}
+}
+
+// GetWorkersHandler lists registered workers; online reflects the heartbeat
+// tracking of the worker manager rather than the last status stored
+func GetWorkersHandler(db *gorm.DB, mgr *manager.WorkerManager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var workers []model.Worker
+		if err := db.Order("worker_id asc").Find(&workers).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch workers"})
+			return
+		}
+
+		infos := make([]model.WorkerInfo, 0, len(workers))
+		for _, worker := range workers {
+			infos = append(infos, model.WorkerInfo{
+				WorkerID:  worker.WorkerID,
+				Hostname:  worker.Hostname,
+				Status:    worker.Status,
+				Online:    mgr.IsOnline(worker.WorkerID),
+				LastSeen:  worker.LastSeen,
+				CreatedAt: worker.CreatedAt,
+			})
+		}
+		c.JSON(http.StatusOK, infos)
+	}
+}
@@ -0,0 +1,96 @@
+package manager
+
+import (
+	"Server/pkg/model"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Audit appends an entry to the audit log. Pass the transaction making the
+// audited change so that the change and its record commit or roll back together.
+// before is nil for creations, after is nil for deletions.
+func Audit(tx *gorm.DB, actor model.Actor, action, resourceType, resourceID string, before, after any) error {
+	entry := model.AuditEntry{
+		ID:           uuid.New(),
+		ActorType:    actor.Type,
+		ActorID:      actor.ID,
+		IP:           actor.IP,
+		Action:       action,
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		CreatedAt:    time.Now().UTC(),
+	}
+
+	switch {
+	case before != nil && after != nil:
+		diff, err := diffJSON(before, after)
+		if err != nil {
+			return fmt.Errorf("failed to diff audited %s: %w", resourceType, err)
+		}
+		entry.Diff = diff
+	case before != nil:
+		snapshot, err := json.Marshal(before)
+		if err != nil {
+			return fmt.Errorf("failed to serialize audited %s: %w", resourceType, err)
+		}
+		entry.Before = snapshot
+	case after != nil:
+		snapshot, err := json.Marshal(after)
+		if err != nil {
+			return fmt.Errorf("failed to serialize audited %s: %w", resourceType, err)
+		}
+		entry.After = snapshot
+	}
+
+	if err := tx.Create(&entry).Error; err != nil {
+		return fmt.Errorf("failed to write audit entry: %w", err)
+	}
+	return nil
+}
+
+// diffJSON compares the top-level fields of two values by their JSON form
+func diffJSON(before, after any) (model.RawJSON, error) {
+	from, err := toJSONObject(before)
+	if err != nil {
+		return nil, err
+	}
+	to, err := toJSONObject(after)
+	if err != nil {
+		return nil, err
+	}
+
+	type change struct {
+		From any `json:"from"`
+		To   any `json:"to"`
+	}
+	diff := make(map[string]change)
+	for key, value := range from {
+		if !reflect.DeepEqual(value, to[key]) {
+			diff[key] = change{From: value, To: to[key]}
+		}
+	}
+	for key, value := range to {
+		if _, ok := from[key]; !ok {
+			diff[key] = change{To: value}
+		}
+	}
+
+	return json.Marshal(diff)
+}
+
+func toJSONObject(value any) (map[string]any, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	object := make(map[string]any)
+	if err := json.Unmarshal(data, &object); err != nil {
+		return nil, err
+	}
+	return object, nil
+}
@@ -0,0 +1,367 @@
+package manager
+
+import (
+	"Server/pkg/model"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// maxDiffLines bounds each side of the log diff
+const maxDiffLines = 200
+
+// toolchainOptions are set by Kconfig from the toolchain that built the kernel,
+// they are compared as toolchain differences rather than config changes
+var toolchainOptions = []string{
+	"CONFIG_CC_VERSION_TEXT",
+	"CONFIG_CC_IS_GCC",
+	"CONFIG_GCC_VERSION",
+	"CONFIG_CC_IS_CLANG",
+	"CONFIG_CLANG_VERSION",
+	"CONFIG_LD_VERSION",
+	"CONFIG_LD_IS_BFD",
+	"CONFIG_LD_IS_LLD",
+	"CONFIG_LLD_VERSION",
+	"CONFIG_AS_IS_GNU",
+	"CONFIG_AS_IS_LLVM",
+	"CONFIG_AS_VERSION",
+	"CONFIG_RUSTC_VERSION",
+	"CONFIG_RUSTC_VERSION_TEXT",
+	"CONFIG_RUSTC_LLVM_VERSION",
+	"CONFIG_BINDGEN_VERSION_TEXT",
+	"CONFIG_PAHOLE_VERSION",
+}
+
+// toolchainProbes are options derived from probing the toolchain for features
+var toolchainProbes = regexp.MustCompile(`^CONFIG_(?:CC_HAS_|CC_CAN_|AS_HAS_|LD_CAN_|LD_HAS_|RUSTC_HAS_|TOOLS_SUPPORT_|CC_IMPLICIT_|GCC_ASM_|GCC\d+_NO_)`)
+
+var (
+	configSet   = regexp.MustCompile(`^(CONFIG_\w+)=(.*)$`)
+	configUnset = regexp.MustCompile(`^# (CONFIG_\w+) is not set$`)
+)
+
+// diagnostics are normalized so that the same warning of two runs compares
+// equal even when built at other commits, in other trees or at other addresses
+var diagnosticRewrites = []struct {
+	pattern *regexp.Regexp
+	replace string
+}{
+	{regexp.MustCompile(`\S*/build/[0-9a-f]+/linux-[0-9a-f]+/`), ""},
+	{regexp.MustCompile(`\[\s*\d+\.\d+\]`), ""},
+	{regexp.MustCompile(`0x[0-9a-fA-F]+`), "0x?"},
+	{regexp.MustCompile(`\b[0-9a-f]{12,40}\b`), "<hash>"},
+	{regexp.MustCompile(`(\.(?:c|h|S|rs|lds)):\d+(?::\d+)?`), "$1"},
+	{regexp.MustCompile(`\b\d+(?:\.\d+)?(ns|µs|us|ms|s|m|h)\b`), "N$1"},
+	{regexp.MustCompile(`\s+`), " "},
+}
+
+// CompareTasks compares two task runs side by side: the effective kernel config
+// and toolchain recorded by the worker, the step durations, the verdicts, the
+// stacks of the console reports and the warning and error lines of the output
+func CompareTasks(db *gorm.DB, a, b *model.Task) (*model.TaskComparison, error) {
+	cmp := &model.TaskComparison{
+		A:         summarize(a),
+		B:         summarize(b),
+		Config:    []model.ValueDiff{},
+		Toolchain: []model.ValueDiff{},
+		Notes:     []string{},
+	}
+
+	buildA, err := loadBuild(db, a)
+	if err != nil {
+		return nil, err
+	}
+	buildB, err := loadBuild(db, b)
+	if err != nil {
+		return nil, err
+	}
+	for _, side := range []struct {
+		name  string
+		build *model.TaskBuild
+	}{{"a", buildA}, {"b", buildB}} {
+		if side.build.KernelConfig == "" {
+			cmp.Notes = append(cmp.Notes, fmt.Sprintf("no kernel config recorded for task %s", side.name))
+		}
+	}
+
+	configA := parseConfig(buildA.KernelConfig)
+	configB := parseConfig(buildB.KernelConfig)
+	cmp.A.Compiler = compilerOf(a, configA)
+	cmp.B.Compiler = compilerOf(b, configB)
+	cmp.Toolchain = diffToolchain(a, b, configA, configB)
+	if buildA.KernelConfig != "" && buildB.KernelConfig != "" {
+		cmp.Config = diffConfig(configA, configB)
+	}
+
+	timelineA, err := Timeline(db, a)
+	if err != nil {
+		return nil, err
+	}
+	timelineB, err := Timeline(db, b)
+	if err != nil {
+		return nil, err
+	}
+	cmp.Steps = diffSteps(timelineA.Steps, timelineB.Steps)
+
+	cmp.SameVerdict = cmp.A.Verdict == cmp.B.Verdict
+	if a.Console != nil || b.Console != nil {
+		cmp.Crash = diffCrash(a.Console, b.Console)
+	}
+
+	cmp.Log = diffDiagnostics(buildA.Diagnostics, buildB.Diagnostics)
+	return cmp, nil
+}
+
+func summarize(task *model.Task) model.TaskSummary {
+	summary := model.TaskSummary{
+		ID:       task.ID,
+		Type:     task.Type,
+		Status:   task.Status,
+		Campaign: task.Campaign,
+		BugID:    task.Payload.ID,
+		Title:    task.Payload.Title,
+	}
+	if len(task.Payload.Crashes) > 0 {
+		summary.Commit = task.Payload.Crashes[0].KernelSourceCommit
+	}
+	if task.Result != nil {
+		summary.Verdict = task.Result.Verdict
+		summary.CrashTitle = task.Result.CrashTitle
+	}
+	return summary
+}
+
+// loadBuild returns the recorded build of a task, empty if the worker didn't
+// record one
+func loadBuild(db *gorm.DB, task *model.Task) (*model.TaskBuild, error) {
+	var build model.TaskBuild
+	err := db.First(&build, "task_id = ?", task.ID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &model.TaskBuild{TaskID: task.ID}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &build, nil
+}
+
+// parseConfig reads a .config into option -> value, "n" for options that are
+// explicitly not set
+func parseConfig(text string) map[string]string {
+	config := make(map[string]string)
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if m := configSet.FindStringSubmatch(line); m != nil {
+			config[m[1]] = strings.Trim(m[2], `"`)
+		} else if m := configUnset.FindStringSubmatch(line); m != nil {
+			config[m[1]] = "n"
+		}
+	}
+	return config
+}
+
+func isToolchainOption(option string) bool {
+	return slices.Contains(toolchainOptions, option) || toolchainProbes.MatchString(option)
+}
+
+// compilerOf prefers the compiler that actually built the kernel over the one
+// named by the syzbot report
+func compilerOf(task *model.Task, config map[string]string) string {
+	if text := config["CONFIG_CC_VERSION_TEXT"]; text != "" {
+		return text
+	}
+	if len(task.Payload.Crashes) > 0 {
+		return task.Payload.Crashes[0].CompilerDescription
+	}
+	return ""
+}
+
+func diffConfig(a, b map[string]string) []model.ValueDiff {
+	diffs := []model.ValueDiff{}
+	for _, option := range unionKeys(a, b) {
+		if isToolchainOption(option) || a[option] == b[option] {
+			continue
+		}
+		diffs = append(diffs, model.ValueDiff{Name: option, A: a[option], B: b[option]})
+	}
+	return diffs
+}
+
+// diffToolchain lists the differing compiler and architecture of the reports,
+// then the differing toolchain versions and probed features of the configs
+func diffToolchain(taskA, taskB *model.Task, a, b map[string]string) []model.ValueDiff {
+	diffs := []model.ValueDiff{}
+	reported := func(task *model.Task) (string, string) {
+		if len(task.Payload.Crashes) == 0 {
+			return "", ""
+		}
+		return task.Payload.Crashes[0].CompilerDescription, task.Payload.Crashes[0].Architecture
+	}
+	compilerA, archA := reported(taskA)
+	compilerB, archB := reported(taskB)
+	if compilerA != compilerB {
+		diffs = append(diffs, model.ValueDiff{Name: "compiler-description", A: compilerA, B: compilerB})
+	}
+	if archA != archB {
+		diffs = append(diffs, model.ValueDiff{Name: "architecture", A: archA, B: archB})
+	}
+
+	for _, option := range toolchainOptions {
+		if a[option] != b[option] {
+			diffs = append(diffs, model.ValueDiff{Name: option, A: a[option], B: b[option]})
+		}
+	}
+	for _, option := range unionKeys(a, b) {
+		if toolchainProbes.MatchString(option) && a[option] != b[option] {
+			diffs = append(diffs, model.ValueDiff{Name: option, A: a[option], B: b[option]})
+		}
+	}
+	return diffs
+}
+
+func unionKeys(a, b map[string]string) []string {
+	keys := make([]string, 0, len(a))
+	for key := range a {
+		keys = append(keys, key)
+	}
+	for key := range b {
+		if _, ok := a[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// diffSteps pairs the steps of both runs in the order they ran; a step that ran
+// more than once is represented by its last run
+func diffSteps(a, b []model.StepSpan) []model.StepDelta {
+	last := func(spans []model.StepSpan) (map[string]model.StepSpan, []string) {
+		byStep := make(map[string]model.StepSpan)
+		var order []string
+		for _, span := range spans {
+			if _, ok := byStep[span.Step]; !ok {
+				order = append(order, span.Step)
+			}
+			byStep[span.Step] = span
+		}
+		return byStep, order
+	}
+	stepsA, order := last(a)
+	stepsB, orderB := last(b)
+	for _, step := range orderB {
+		if !slices.Contains(order, step) {
+			order = append(order, step)
+		}
+	}
+
+	deltas := make([]model.StepDelta, 0, len(order))
+	for _, step := range order {
+		delta := model.StepDelta{Step: step}
+		if span, ok := stepsA[step]; ok {
+			delta.AMs, delta.AError = span.DurationMs, span.Error
+		}
+		if span, ok := stepsB[step]; ok {
+			delta.BMs, delta.BError = span.DurationMs, span.Error
+		}
+		if delta.AMs != nil && delta.BMs != nil {
+			d := *delta.BMs - *delta.AMs
+			delta.DeltaMs = &d
+		}
+		deltas = append(deltas, delta)
+	}
+	return deltas
+}
+
+func diffCrash(a, b *model.ConsoleReport) *model.CrashDiff {
+	var framesA, framesB, allocA, allocB, freeA, freeB []model.StackFrame
+	diff := &model.CrashDiff{}
+	if a != nil {
+		diff.ATitle = a.Title
+		framesA, allocA, freeA = a.Frames, a.AllocStack, a.FreeStack
+	}
+	if b != nil {
+		diff.BTitle = b.Title
+		framesB, allocB, freeB = b.Frames, b.AllocStack, b.FreeStack
+	}
+	diff.SameTitle = a != nil && b != nil && NormalizeTitle(a.Title) == NormalizeTitle(b.Title)
+	diff.Frames = diffStack(framesA, framesB)
+	diff.AllocStack = diffStack(allocA, allocB)
+	diff.FreeStack = diffStack(freeA, freeB)
+	return diff
+}
+
+// diffStack compares stacks by function, offsets change with every rebuild
+func diffStack(a, b []model.StackFrame) model.StackDiff {
+	functions := func(frames []model.StackFrame) []string {
+		names := make([]string, 0, len(frames))
+		for _, frame := range frames {
+			names = append(names, titleFuncSuffix.ReplaceAllString(frame.Function, ""))
+		}
+		return names
+	}
+	namesA, namesB := functions(a), functions(b)
+
+	diff := model.StackDiff{Same: slices.Equal(namesA, namesB), OnlyA: []string{}, OnlyB: []string{}}
+	for _, name := range namesA {
+		if !slices.Contains(namesB, name) {
+			diff.OnlyA = append(diff.OnlyA, name)
+		}
+	}
+	for _, name := range namesB {
+		if !slices.Contains(namesA, name) {
+			diff.OnlyB = append(diff.OnlyB, name)
+		}
+	}
+	return diff
+}
+
+func normalizeDiagnostic(line string) string {
+	for _, rewrite := range diagnosticRewrites {
+		line = rewrite.pattern.ReplaceAllString(line, rewrite.replace)
+	}
+	return strings.TrimSpace(line)
+}
+
+// countLines counts the normalized lines in the order they first occurred
+func countLines(lines []string) ([]string, map[string]int) {
+	var order []string
+	counts := make(map[string]int)
+	for _, line := range lines {
+		line = normalizeDiagnostic(line)
+		if line == "" {
+			continue
+		}
+		if counts[line] == 0 {
+			order = append(order, line)
+		}
+		counts[line]++
+	}
+	return order, counts
+}
+
+// diffDiagnostics lists the warning and error lines that only one of the runs
+// printed
+func diffDiagnostics(a, b []string) model.LogDiff {
+	orderA, countsA := countLines(a)
+	orderB, countsB := countLines(b)
+
+	diff := model.LogDiff{OnlyA: []model.LineCount{}, OnlyB: []model.LineCount{}}
+	for _, line := range orderA {
+		if countsB[line] > 0 {
+			diff.Common++
+		} else if len(diff.OnlyA) < maxDiffLines {
+			diff.OnlyA = append(diff.OnlyA, model.LineCount{Line: line, Count: countsA[line]})
+		}
+	}
+	for _, line := range orderB {
+		if countsA[line] == 0 && len(diff.OnlyB) < maxDiffLines {
+			diff.OnlyB = append(diff.OnlyB, model.LineCount{Line: line, Count: countsB[line]})
+		}
+	}
+	return diff
+}
@@ -0,0 +1,185 @@
+package manager
+
+import (
+	"Server/pkg/model"
+	"reflect"
+	"testing"
+)
+
+func ms(v int64) *int64 { return &v }
+
+func taskWith(compiler, arch string, result *model.TaskResult) *model.Task {
+	return &model.Task{
+		Payload: model.CrashReport{Crashes: []model.Crash{{
+			KernelSourceCommit:  "b1f40d9ab1e4",
+			CompilerDescription: compiler,
+			Architecture:        arch,
+		}}},
+		Result: result,
+	}
+}
+
+func TestDiffConfig(t *testing.T) {
+	a := parseConfig(`CONFIG_CC_VERSION_TEXT="gcc (Debian 12.2.0-14) 12.2.0"
+CONFIG_GCC_VERSION=120200
+CONFIG_CC_HAS_ASM_GOTO_OUTPUT=y
+CONFIG_KASAN=y
+CONFIG_KCSAN=y
+# CONFIG_DEBUG_INFO_BTF is not set
+CONFIG_NR_CPUS=8
+`)
+	b := parseConfig(`CONFIG_CC_VERSION_TEXT="gcc (Debian 13.2.0-25) 13.2.0"
+CONFIG_GCC_VERSION=130200
+CONFIG_KASAN=y
+# CONFIG_KCSAN is not set
+CONFIG_DEBUG_INFO_BTF=y
+CONFIG_NR_CPUS=8
+CONFIG_PANIC_ON_OOPS=y
+`)
+
+	want := []model.ValueDiff{
+		{Name: "CONFIG_DEBUG_INFO_BTF", A: "n", B: "y"},
+		{Name: "CONFIG_KCSAN", A: "y", B: "n"},
+		{Name: "CONFIG_PANIC_ON_OOPS", A: "", B: "y"},
+	}
+	if got := diffConfig(a, b); !reflect.DeepEqual(got, want) {
+		t.Errorf("config diff = %+v\nwant %+v", got, want)
+	}
+
+	taskA := taskWith("gcc (Debian 12.2.0-14) 12.2.0", "amd64", nil)
+	taskB := taskWith("gcc (Debian 13.2.0-25) 13.2.0", "amd64", nil)
+	want = []model.ValueDiff{
+		{Name: "compiler-description", A: "gcc (Debian 12.2.0-14) 12.2.0", B: "gcc (Debian 13.2.0-25) 13.2.0"},
+		{Name: "CONFIG_CC_VERSION_TEXT", A: "gcc (Debian 12.2.0-14) 12.2.0", B: "gcc (Debian 13.2.0-25) 13.2.0"},
+		{Name: "CONFIG_GCC_VERSION", A: "120200", B: "130200"},
+		{Name: "CONFIG_CC_HAS_ASM_GOTO_OUTPUT", A: "y", B: ""},
+	}
+	if got := diffToolchain(taskA, taskB, a, b); !reflect.DeepEqual(got, want) {
+		t.Errorf("toolchain diff = %+v\nwant %+v", got, want)
+	}
+}
+
+func TestDiffSteps(t *testing.T) {
+	a := []model.StepSpan{
+		{Step: "MakeKernel", DurationMs: ms(600000)},
+		{Step: "BootVM", DurationMs: ms(90000), Error: "ssh: connection refused"},
+		{Step: "BootVM", DurationMs: ms(40000)},
+		{Step: "RunReproducer", DurationMs: ms(60000)},
+	}
+	b := []model.StepSpan{
+		{Step: "MakeKernel", DurationMs: ms(450000)},
+		{Step: "BootVM", DurationMs: ms(45000)},
+		{Step: "GetVmcore", DurationMs: ms(12000)},
+		{Step: "RunReproducer", Error: "exit status 1"},
+	}
+
+	got := diffSteps(a, b)
+	want := []model.StepDelta{
+		{Step: "MakeKernel", AMs: ms(600000), BMs: ms(450000), DeltaMs: ms(-150000)},
+		{Step: "BootVM", AMs: ms(40000), BMs: ms(45000), DeltaMs: ms(5000)},
+		{Step: "RunReproducer", AMs: ms(60000), BError: "exit status 1"},
+		{Step: "GetVmcore", BMs: ms(12000)},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("step deltas:")
+		for _, delta := range got {
+			t.Logf("  %s a=%v b=%v delta=%v a_error=%q b_error=%q", delta.Step, deref(delta.AMs), deref(delta.BMs), deref(delta.DeltaMs), delta.AError, delta.BError)
+		}
+	}
+}
+
+func deref(v *int64) any {
+	if v == nil {
+		return nil
+	}
+	return *v
+}
+
+func TestDiffDiagnostics(t *testing.T) {
+	a := []string{
+		"/srv/build-vmcore/build/0a1b2c3d4e5f/linux-0a1b2c3d4e5f/fs/ext4/inode.c:1234:5: warning: unused variable 'ret' [-Wunused-variable]",
+		"/srv/build-vmcore/build/0a1b2c3d4e5f/linux-0a1b2c3d4e5f/fs/ext4/inode.c:1301:5: warning: unused variable 'ret' [-Wunused-variable]",
+		"[   12.345678] WARNING: CPU: 0 PID: 1 at mm/slub.c:4321 kmem_cache_free+0x1f0/0x220",
+		"ld: warning: arch/x86/boot/compressed/vmlinux has a LOAD segment with RWX permissions",
+	}
+	b := []string{
+		"/home/ci/build-vmcore/build/9f8e7d6c5b4a/linux-9f8e7d6c5b4a/fs/ext4/inode.c:1240:5: warning: unused variable 'ret' [-Wunused-variable]",
+		"[  140.002001] WARNING: CPU: 1 PID: 1 at mm/slub.c:4330 kmem_cache_free+0x1e8/0x220",
+		"drivers/net/tun.c:2100:9: error: implicit declaration of function 'tun_get_socket'",
+	}
+
+	// paths, line numbers, timestamps and offsets are normalized away, the
+	// WARNING lines still differ in the CPU that hit them
+	got := diffDiagnostics(a, b)
+	want := model.LogDiff{
+		OnlyA: []model.LineCount{
+			{Line: "WARNING: CPU: 0 PID: 1 at mm/slub.c kmem_cache_free+0x?/0x?", Count: 1},
+			{Line: "ld: warning: arch/x86/boot/compressed/vmlinux has a LOAD segment with RWX permissions", Count: 1},
+		},
+		OnlyB: []model.LineCount{
+			{Line: "WARNING: CPU: 1 PID: 1 at mm/slub.c kmem_cache_free+0x?/0x?", Count: 1},
+			{Line: "drivers/net/tun.c: error: implicit declaration of function 'tun_get_socket'", Count: 1},
+		},
+		Common: 1,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("log diff = %+v\nwant %+v", got, want)
+	}
+
+	if _, counts := countLines(a); counts["fs/ext4/inode.c: warning: unused variable 'ret' [-Wunused-variable]"] != 2 {
+		t.Errorf("counts = %v, want the unused variable warning counted twice", counts)
+	}
+}
+
+func TestDiffCrash(t *testing.T) {
+	a := &model.ConsoleReport{
+		Title:  "KASAN: slab-use-after-free Read in tun_chr_close",
+		Frames: []model.StackFrame{{Function: "tun_chr_close"}, {Function: "__fput.constprop.0"}, {Function: "task_work_run"}},
+		FreeStack: []model.StackFrame{
+			{Function: "kfree"}, {Function: "tun_free_netdev"},
+		},
+	}
+	b := &model.ConsoleReport{
+		Title:  "KASAN: slab-use-after-free Read in tun_chr_close",
+		Frames: []model.StackFrame{{Function: "tun_chr_close"}, {Function: "__fput"}, {Function: "task_work_run"}},
+		FreeStack: []model.StackFrame{
+			{Function: "kfree"}, {Function: "netdev_run_todo"},
+		},
+	}
+
+	diff := diffCrash(a, b)
+	if !diff.SameTitle || !diff.Frames.Same {
+		t.Errorf("same crash compared as title %v, frames %+v", diff.SameTitle, diff.Frames)
+	}
+	if diff.FreeStack.Same || !reflect.DeepEqual(diff.FreeStack.OnlyA, []string{"tun_free_netdev"}) || !reflect.DeepEqual(diff.FreeStack.OnlyB, []string{"netdev_run_todo"}) {
+		t.Errorf("free stack diff = %+v", diff.FreeStack)
+	}
+
+	only := diffCrash(a, nil)
+	if only.SameTitle || only.BTitle != "" || !reflect.DeepEqual(only.Frames.OnlyA, []string{"tun_chr_close", "__fput", "task_work_run"}) {
+		t.Errorf("crash only in a = %+v", only)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	task := taskWith("clang version 15.0.6", "amd64", &model.TaskResult{
+		Crashed:    true,
+		CrashTitle: "KASAN: slab-use-after-free Read in tun_chr_close",
+		Verdict:    model.VerdictReproduced,
+	})
+	summary := summarize(task)
+	if summary.Commit != "b1f40d9ab1e4" || summary.Verdict != model.VerdictReproduced || summary.CrashTitle != task.Result.CrashTitle {
+		t.Errorf("summary = %+v", summary)
+	}
+
+	config := parseConfig(`CONFIG_CC_VERSION_TEXT="Debian clang version 15.0.6"`)
+	if got := compilerOf(task, config); got != "Debian clang version 15.0.6" {
+		t.Errorf("compiler = %q, want the one from the effective config", got)
+	}
+	if got := compilerOf(task, nil); got != "clang version 15.0.6" {
+		t.Errorf("compiler without config = %q, want the reported one", got)
+	}
+	if summary := summarize(taskWith("", "", nil)); summary.Verdict != "" {
+		t.Errorf("task without result has verdict %q", summary.Verdict)
+	}
+}
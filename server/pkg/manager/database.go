@@ -1,7 +1,8 @@
 package manager
 
 import (
-	"Server/pkg/model"
+	"Server/pkg/migrate"
+	"context"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"log"
@@ -10,29 +11,38 @@ import (
 
 var DB *gorm.DB
 
-func Init() {
-	dsn := "host=localhost user=admin password=123456 dbname=tasks port=5432 sslmode=disable TimeZone=Asia/Shanghai"
+const dsn = "host=localhost user=admin password=123456 dbname=tasks port=5432 sslmode=disable TimeZone=Asia/Shanghai"
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+// Connect opens the database without touching the schema, used by the migrate subcommand
+func Connect() (*gorm.DB, error) {
+	return gorm.Open(postgres.Open(dsn), &gorm.Config{})
+}
 
+func Init() {
+	db, err := Connect()
 	if err != nil {
-		slog.Error("failed to connect to database: %v", err)
+		slog.Error("failed to connect to database", "error", err)
 		panic("failed to connect to database")
 	}
 
 	log.Println("database connection established.")
 	log.Println("running database migrations...")
 
-	err = db.AutoMigrate(&model.Task{})
+	migrator, err := migrate.CreateMigrator(db, slog.Default())
 	if err != nil {
-		slog.Error("failed to migrate database: %v", err)
-		panic("failed to migrate database")
+		log.Fatalf("failed to load migrations: %v", err)
+	}
+
+	ctx := context.Background()
+	if err := migrator.Check(ctx); err != nil {
+		log.Fatalf("refusing to start: %v", err)
 	}
 
-	err = db.AutoMigrate(&model.Worker{})
+	applied, err := migrator.Up(ctx, 0)
 	if err != nil {
 		log.Fatalf("failed to migrate database: %v", err)
 	}
+	log.Printf("database schema at version %d, %d migrations applied.", migrator.Latest(), applied)
 
 	DB = db
 }
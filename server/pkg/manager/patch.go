@@ -0,0 +1,407 @@
+package manager
+
+import (
+	"Server/pkg/model"
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxPatchSize is far beyond any fix; larger uploads are most likely a whole tree
+const maxPatchSize = 4 << 20
+
+var (
+	gitDiffHeader = regexp.MustCompile(`^diff --git a/(.+) b/(.+)$`)
+	hunkHeader    = regexp.MustCompile(`^@@ -\d+(?:,(\d+))? \+\d+(?:,(\d+))? @@`)
+)
+
+// ParsePatch checks that an uploaded patch is a non-empty textual unified diff
+// and returns it normalized along with a summary of the files and hunks. CRLF
+// line endings become LF; the mail headers, commit message and diffstat that
+// git format-patch puts before the first file and the signature after the last
+// hunk are dropped; empty context lines whose single space was eaten by a mail
+// client are restored.
+func ParsePatch(raw []byte) (string, *model.PatchSummary, error) {
+	if len(raw) > maxPatchSize {
+		return "", nil, fmt.Errorf("patch is larger than %d bytes", maxPatchSize)
+	}
+	if bytes.IndexByte(raw, 0) >= 0 || !utf8.Valid(raw) {
+		return "", nil, errors.New("patch is binary")
+	}
+
+	text := strings.ReplaceAll(string(raw), "\r\n", "\n")
+	p := &patchParser{
+		lines:   strings.Split(strings.TrimSuffix(text, "\n"), "\n"),
+		summary: &model.PatchSummary{Files: []model.PatchFile{}},
+	}
+	if err := p.parse(); err != nil {
+		return "", nil, err
+	}
+	if len(p.summary.Files) == 0 {
+		return "", nil, errors.New("patch is empty: no file changes found")
+	}
+
+	for _, file := range p.summary.Files {
+		p.summary.Hunks += file.Hunks
+		p.summary.Additions += file.Additions
+		p.summary.Deletions += file.Deletions
+	}
+	return strings.Join(p.out, "\n") + "\n", p.summary, nil
+}
+
+var (
+	// mboxSeparator starts every mail of git format-patch output, the date is fixed
+	mboxSeparator = regexp.MustCompile(`(?m)^From [0-9a-f]{40} Mon Sep 17 00:00:00 2001$`)
+	mailHeader    = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9-]*: `)
+	subjectTags   = regexp.MustCompile(`^(?:\[[^\]]*\]\s*)+`)
+)
+
+// PatchUpload is one uploaded patch file
+type PatchUpload struct {
+	Name string
+	Data []byte
+}
+
+// ParsePatchSeries parses an ordered series of uploaded patch files, each one a
+// single patch or several in git format-patch mbox form. Every patch is checked
+// and normalized by ParsePatch; the summary adds up the whole series and keeps
+// the summary of each patch when there is more than one.
+func ParsePatchSeries(uploads []PatchUpload) ([]model.SeriesPatch, *model.PatchSummary, error) {
+	size := 0
+	for _, upload := range uploads {
+		size += len(upload.Data)
+	}
+	if size > maxPatchSize {
+		return nil, nil, fmt.Errorf("patch series is larger than %d bytes", maxPatchSize)
+	}
+
+	var series []model.SeriesPatch
+	var summaries []model.PatchSummary
+	for _, upload := range uploads {
+		mails := splitMbox(upload.Data)
+		for i, mail := range mails {
+			diff, summary, err := ParsePatch(mail)
+			if err != nil {
+				return nil, nil, fmt.Errorf("patch %d (%s): %w", len(series)+1, upload.Name, err)
+			}
+			subject := mailSubject(mail)
+			if subject == "" {
+				subject = upload.Name
+				if len(mails) > 1 {
+					subject = fmt.Sprintf("%s #%d", upload.Name, i+1)
+				}
+			}
+			summary.Subject = subject
+			series = append(series, model.SeriesPatch{Subject: subject, Diff: diff})
+			summaries = append(summaries, *summary)
+		}
+	}
+	if len(series) == 0 {
+		return nil, nil, errors.New("no patch uploaded")
+	}
+	if len(series) == 1 {
+		return series, &summaries[0], nil
+	}
+	return series, mergeSummaries(summaries), nil
+}
+
+// JoinSeries concatenates the diffs of a series into CrashReport.Patch
+func JoinSeries(series []model.SeriesPatch) string {
+	var b strings.Builder
+	for _, patch := range series {
+		b.WriteString(patch.Diff)
+	}
+	return b.String()
+}
+
+// splitMbox splits git format-patch output into its mails; anything else is
+// returned as a single patch
+func splitMbox(data []byte) [][]byte {
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	starts := mboxSeparator.FindAllStringIndex(text, -1)
+	if len(starts) < 2 {
+		return [][]byte{[]byte(text)}
+	}
+
+	mails := make([][]byte, 0, len(starts))
+	for i, start := range starts {
+		end := len(text)
+		if i+1 < len(starts) {
+			end = starts[i+1][0]
+		}
+		mails = append(mails, []byte(text[start[0]:end]))
+	}
+	return mails
+}
+
+// mailSubject returns the Subject header of a mail without its [PATCH n/m] tags,
+// or "" when the patch has no mail headers
+func mailSubject(mail []byte) string {
+	var subject string
+	inSubject := false
+	for i, line := range strings.Split(string(mail), "\n") {
+		switch {
+		case line == "":
+			return strings.TrimSpace(subjectTags.ReplaceAllString(subject, ""))
+		case i == 0 && strings.HasPrefix(line, "From "):
+		case inSubject && (line[0] == ' ' || line[0] == '\t'):
+			subject += " " + strings.TrimSpace(line)
+		case mailHeader.MatchString(line):
+			value, isSubject := strings.CutPrefix(line, "Subject: ")
+			inSubject = isSubject
+			if isSubject {
+				subject = strings.TrimSpace(value)
+			}
+		default:
+			return ""
+		}
+	}
+	return ""
+}
+
+// mergeSummaries adds up the summaries of a series; a file touched by several
+// patches keeps the status of the first one unless a later patch deletes it
+func mergeSummaries(summaries []model.PatchSummary) *model.PatchSummary {
+	total := &model.PatchSummary{Files: []model.PatchFile{}, Series: summaries}
+	index := make(map[string]int)
+	for _, summary := range summaries {
+		for _, file := range summary.Files {
+			i, ok := index[file.Path]
+			if !ok {
+				index[file.Path] = len(total.Files)
+				total.Files = append(total.Files, file)
+				continue
+			}
+			merged := &total.Files[i]
+			merged.Hunks += file.Hunks
+			merged.Additions += file.Additions
+			merged.Deletions += file.Deletions
+			if file.Status == model.PatchFileDeleted {
+				merged.Status = model.PatchFileDeleted
+			}
+		}
+		total.Hunks += summary.Hunks
+		total.Additions += summary.Additions
+		total.Deletions += summary.Deletions
+	}
+	return total
+}
+
+// PatchModifiedFiles lists the paths a patch touches, the old path of a rename
+// included, for CrashReport.PatchModified
+func PatchModifiedFiles(summary *model.PatchSummary) []string {
+	var paths []string
+	for _, file := range summary.Files {
+		if file.OldPath != "" {
+			paths = append(paths, file.OldPath)
+		}
+		paths = append(paths, file.Path)
+	}
+	return paths
+}
+
+type patchParser struct {
+	lines   []string
+	pos     int
+	out     []string
+	summary *model.PatchSummary
+}
+
+func (p *patchParser) parse() error {
+	for p.pos < len(p.lines) {
+		if strings.HasPrefix(p.lines[p.pos], "diff --git ") || p.atFileHeader() {
+			if err := p.parseFile(); err != nil {
+				return err
+			}
+			continue
+		}
+		// mail headers, commit message, diffstat or signature
+		p.pos++
+	}
+	return nil
+}
+
+// atFileHeader reports whether the "--- old" and "+++ new" lines of a file start
+// at the current line
+func (p *patchParser) atFileHeader() bool {
+	return p.pos+1 < len(p.lines) &&
+		strings.HasPrefix(p.lines[p.pos], "--- ") &&
+		strings.HasPrefix(p.lines[p.pos+1], "+++ ")
+}
+
+func (p *patchParser) emit(line string) {
+	p.out = append(p.out, line)
+	p.pos++
+}
+
+func (p *patchParser) parseFile() error {
+	start := p.pos + 1
+	file := model.PatchFile{Status: model.PatchFileModified}
+	var oldPath, newPath string
+	// renames, mode changes and empty new or deleted files come without hunks
+	headerChange := false
+
+	if line := p.lines[p.pos]; strings.HasPrefix(line, "diff --git ") {
+		if m := gitDiffHeader.FindStringSubmatch(line); m != nil {
+			oldPath, newPath = m[1], m[2]
+		}
+		p.emit(line)
+
+	extended:
+		for p.pos < len(p.lines) {
+			line := p.lines[p.pos]
+			switch {
+			case strings.HasPrefix(line, "new file mode "), strings.HasPrefix(line, "copy to "):
+				file.Status = model.PatchFileAdded
+				headerChange = true
+				if to, ok := strings.CutPrefix(line, "copy to "); ok {
+					newPath = to
+				}
+			case strings.HasPrefix(line, "deleted file mode "):
+				file.Status = model.PatchFileDeleted
+				headerChange = true
+			case strings.HasPrefix(line, "rename from "):
+				file.Status = model.PatchFileRenamed
+				oldPath = strings.TrimPrefix(line, "rename from ")
+				headerChange = true
+			case strings.HasPrefix(line, "rename to "):
+				newPath = strings.TrimPrefix(line, "rename to ")
+			case strings.HasPrefix(line, "old mode "), strings.HasPrefix(line, "new mode "):
+				headerChange = true
+			case strings.HasPrefix(line, "index "), strings.HasPrefix(line, "similarity index "),
+				strings.HasPrefix(line, "dissimilarity index "), strings.HasPrefix(line, "copy from "):
+			case strings.HasPrefix(line, "GIT binary patch"), strings.HasPrefix(line, "Binary files "):
+				return fmt.Errorf("binary change to %s is not supported", orUnknown(newPath))
+			default:
+				break extended
+			}
+			p.emit(line)
+		}
+	}
+
+	if p.atFileHeader() {
+		from, to := headerPath(p.lines[p.pos]), headerPath(p.lines[p.pos+1])
+		switch {
+		case from == "/dev/null":
+			file.Status = model.PatchFileAdded
+			newPath = to
+		case to == "/dev/null":
+			file.Status = model.PatchFileDeleted
+			oldPath = from
+		default:
+			oldPath, newPath = from, to
+		}
+		p.emit(p.lines[p.pos])
+		p.emit(p.lines[p.pos])
+
+		for p.pos < len(p.lines) && strings.HasPrefix(p.lines[p.pos], "@@") {
+			if err := p.parseHunk(&file, orUnknown(newPath)); err != nil {
+				return err
+			}
+		}
+	}
+
+	switch file.Status {
+	case model.PatchFileDeleted:
+		file.Path = oldPath
+	case model.PatchFileRenamed:
+		file.Path, file.OldPath = newPath, oldPath
+	default:
+		file.Path = newPath
+	}
+	if file.Hunks == 0 && !headerChange {
+		return fmt.Errorf("file header at line %d has no hunks", start)
+	}
+	if file.Path == "" {
+		return fmt.Errorf("file header at line %d has no path", start)
+	}
+	for _, path := range []string{file.Path, file.OldPath} {
+		if strings.HasPrefix(path, "/") || slices.Contains(strings.Split(path, "/"), "..") {
+			return fmt.Errorf("path %q at line %d leaves the kernel tree", path, start)
+		}
+	}
+
+	p.summary.Files = append(p.summary.Files, file)
+	return nil
+}
+
+// parseHunk consumes one hunk, checking its lines against the counts of its header
+func (p *patchParser) parseHunk(file *model.PatchFile, path string) error {
+	m := hunkHeader.FindStringSubmatch(p.lines[p.pos])
+	if m == nil {
+		return fmt.Errorf("malformed hunk header at line %d: %q", p.pos+1, p.lines[p.pos])
+	}
+	oldCount, newCount := hunkCount(m[1]), hunkCount(m[2])
+	p.emit(p.lines[p.pos])
+
+	for oldCount > 0 || newCount > 0 {
+		if p.pos >= len(p.lines) {
+			return fmt.Errorf("last hunk of %s is truncated", path)
+		}
+		line := p.lines[p.pos]
+		switch {
+		case line == "":
+			line = " "
+			oldCount--
+			newCount--
+		case line[0] == ' ':
+			oldCount--
+			newCount--
+		case line[0] == '-':
+			oldCount--
+			file.Deletions++
+		case line[0] == '+':
+			newCount--
+			file.Additions++
+		case line[0] == '\\':
+			// "\ No newline at end of file"
+		default:
+			return fmt.Errorf("hunk of %s ends early at line %d", path, p.pos+1)
+		}
+		if oldCount < 0 || newCount < 0 {
+			return fmt.Errorf("hunk of %s at line %d doesn't match its header", path, p.pos+1)
+		}
+		p.out = append(p.out, line)
+		p.pos++
+	}
+	if p.pos < len(p.lines) && strings.HasPrefix(p.lines[p.pos], "\\ ") {
+		p.emit(p.lines[p.pos])
+	}
+
+	file.Hunks++
+	return nil
+}
+
+// headerPath extracts the path of a "--- a/path" or "+++ b/path" line, dropping
+// the timestamp of plain diff and the first component like git apply -p1
+func headerPath(line string) string {
+	path, _, _ := strings.Cut(line[4:], "\t")
+	path = strings.TrimSpace(path)
+	if path == "/dev/null" {
+		return path
+	}
+	if _, rest, ok := strings.Cut(path, "/"); ok {
+		return rest
+	}
+	return path
+}
+
+func hunkCount(count string) int {
+	if count == "" {
+		return 1
+	}
+	n, _ := strconv.Atoi(count)
+	return n
+}
+
+func orUnknown(path string) string {
+	if path == "" {
+		return "unknown file"
+	}
+	return path
+}
@@ -0,0 +1,170 @@
+package manager
+
+import (
+	"Server/pkg/model"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func readPatch(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestParsePatch(t *testing.T) {
+	tests := []struct {
+		file  string
+		files []model.PatchFile
+	}{
+		{
+			file: "rename.patch",
+			files: []model.PatchFile{{
+				Path: "drivers/bluetooth/hci_virt.c", OldPath: "drivers/bluetooth/hci_vhci.c",
+				Status: model.PatchFileRenamed, Hunks: 1, Additions: 1, Deletions: 1,
+			}},
+		},
+		{
+			// diff -u output: the timestamps and the first path component are dropped
+			file: "plain-diff.patch",
+			files: []model.PatchFile{{
+				Path: "net/core/sock.c", Status: model.PatchFileModified, Hunks: 1, Additions: 3,
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			raw := readPatch(t, tt.file)
+			diff, summary, err := ParsePatch(raw)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(summary.Files, tt.files) {
+				t.Errorf("files %+v, want %+v", summary.Files, tt.files)
+			}
+			if diff != string(raw) {
+				t.Errorf("a clean patch changed during normalization:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParsePatchNormalizes(t *testing.T) {
+	mails := splitMbox(readPatch(t, "series.mbox"))
+	if len(mails) != 2 {
+		t.Fatalf("split the series into %d mails, want 2", len(mails))
+	}
+	crlf := strings.ReplaceAll(string(mails[0]), "\n", "\r\n")
+
+	for name, raw := range map[string][]byte{"lf": mails[0], "crlf": []byte(crlf)} {
+		t.Run(name, func(t *testing.T) {
+			diff, summary, err := ParsePatch(raw)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// mail headers and commit message before the diff, signature after it
+			if !strings.HasPrefix(diff, "diff --git a/net/bluetooth/hci_conn.c b/net/bluetooth/hci_conn.c\n") {
+				t.Errorf("normalized patch starts with %.40q", diff)
+			}
+			if strings.Contains(diff, "Signed-off-by") || strings.Contains(diff, "2.43.0") || strings.Contains(diff, "\r") {
+				t.Errorf("headers, signature or CR left in the normalized patch:\n%s", diff)
+			}
+			// the mail client ate the space of both empty context lines
+			if n := strings.Count(diff, "\n \n"); n != 2 {
+				t.Errorf("%d empty context lines restored, want 2:\n%s", n, diff)
+			}
+			want := []model.PatchFile{{Path: "net/bluetooth/hci_conn.c", Status: model.PatchFileModified, Hunks: 1, Additions: 3, Deletions: 2}}
+			if !reflect.DeepEqual(summary.Files, want) {
+				t.Errorf("files %+v, want %+v", summary.Files, want)
+			}
+		})
+	}
+}
+
+func TestParsePatchErrors(t *testing.T) {
+	rename := string(readPatch(t, "rename.patch"))
+	tests := []struct {
+		name  string
+		patch string
+		err   string
+	}{
+		{"empty", "", "patch is empty"},
+		{"commit message only", "Subject: [PATCH] fix\n\nNothing to see.\n", "patch is empty"},
+		{"binary", "diff --git a/fw.bin b/fw.bin\nindex 1..2 100644\nGIT binary patch\nliteral 4\nLcmZ?d00001\n", "binary change"},
+		{"nul byte", "--- a/x\n+++ b/x\n@@ -1 +1 @@\n-\x00\n+a\n", "binary"},
+		{"count mismatch", strings.Replace(rename, "@@ -664,7 +664,7 @@", "@@ -664,5 +664,7 @@", 1), "doesn't match its header"},
+		{"truncated", strings.Replace(rename, "@@ -664,7 +664,7 @@", "@@ -664,9 +664,9 @@", 1), "truncated"},
+		{"malformed hunk", strings.Replace(rename, "@@ -664,7 +664,7 @@", "@@ -664 +664 @", 1), "malformed hunk header"},
+		{"no hunks", "--- a/net/core/sock.c\n+++ b/net/core/sock.c\n", "has no hunks"},
+		{"leaves the tree", strings.ReplaceAll(rename, "drivers/bluetooth/hci_virt.c", "../../etc/hci_virt.c"), "leaves the kernel tree"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := ParsePatch([]byte(tt.patch))
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("error %v, want one containing %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestParsePatchSeries(t *testing.T) {
+	series, summary, err := ParsePatchSeries([]PatchUpload{{Name: "series.mbox", Data: readPatch(t, "series.mbox")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	subjects := make([]string, len(series))
+	for i, patch := range series {
+		subjects[i] = patch.Subject
+	}
+	want := []string{
+		"Bluetooth: hci_conn: fix use-after-free in hci_conn_hash_flush",
+		"selftests: bluetooth: add a hci_conn_hash_flush test",
+	}
+	if !reflect.DeepEqual(subjects, want) {
+		t.Errorf("subjects %q, want %q", subjects, want)
+	}
+
+	if len(summary.Series) != 2 || len(summary.Files) != 3 {
+		t.Fatalf("%d patches touching %d files, want 2 and 3", len(summary.Series), len(summary.Files))
+	}
+	if summary.Hunks != 3 || summary.Additions != 8 || summary.Deletions != 3 {
+		t.Errorf("series adds up to %d hunks +%d -%d, want 3 +8 -3", summary.Hunks, summary.Additions, summary.Deletions)
+	}
+	if added := summary.Files[2]; added.Path != "tools/testing/selftests/bluetooth/conn_flush.sh" || added.Status != model.PatchFileAdded {
+		t.Errorf("third file %+v, want the added selftest", added)
+	}
+	if JoinSeries(series) != series[0].Diff+series[1].Diff {
+		t.Error("JoinSeries does not concatenate the diffs in order")
+	}
+}
+
+func TestParsePatchSeriesFiles(t *testing.T) {
+	uploads := []PatchUpload{
+		{Name: "0001-rename.patch", Data: readPatch(t, "rename.patch")},
+		{Name: "0002-sock.patch", Data: readPatch(t, "plain-diff.patch")},
+	}
+	series, summary, err := ParsePatchSeries(uploads)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// patches without mail headers are named after their files
+	if len(series) != 2 || series[0].Subject != "0001-rename.patch" || series[1].Subject != "0002-sock.patch" {
+		t.Errorf("series %+v", series)
+	}
+	if got := PatchModifiedFiles(summary); !reflect.DeepEqual(got, []string{"drivers/bluetooth/hci_vhci.c", "drivers/bluetooth/hci_virt.c", "net/core/sock.c"}) {
+		t.Errorf("modified files %q", got)
+	}
+
+	uploads[1].Data = []byte("not a patch\n")
+	if _, _, err := ParsePatchSeries(uploads); err == nil || !strings.HasPrefix(err.Error(), "patch 2 (0002-sock.patch)") {
+		t.Errorf("error %v does not name the second patch", err)
+	}
+}
@@ -0,0 +1,112 @@
+package manager
+
+import (
+	"Server/pkg/model"
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	// progress of tasks not updated for this long is dropped
+	progressRetention = 24 * time.Hour
+	// warnings kept per task, older ones only count towards WarningCount
+	progressWarnings = 20
+)
+
+// ProgressEvent is one structured progress record forwarded by a worker
+type ProgressEvent struct {
+	Kind       string // model.TaskEventStepStart, model.TaskEventStepFinish, "percent" or "warning"
+	Step       string
+	Percent    *float64
+	Current    int64
+	Total      int64
+	Message    string
+	OccurredAt time.Time
+}
+
+const (
+	ProgressPercent = "percent"
+	ProgressWarning = "warning"
+)
+
+// ProgressTracker keeps the latest progress of every task in memory, it is fed
+// by the UploadProgress stream and read by GET /tasks/:id/progress
+type ProgressTracker struct {
+	mu    sync.Mutex
+	tasks map[string]*model.TaskProgress
+}
+
+func CreateProgressTracker() *ProgressTracker {
+	return &ProgressTracker{tasks: make(map[string]*model.TaskProgress)}
+}
+
+func (t *ProgressTracker) Record(taskID string, event ProgressEvent) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.prune()
+
+	p, ok := t.tasks[taskID]
+	if !ok {
+		p = &model.TaskProgress{TaskID: taskID}
+		t.tasks[taskID] = p
+	}
+
+	switch event.Kind {
+	case string(model.TaskEventStepStart):
+		p.Step = event.Step
+		p.StepState = model.StepRunning
+		p.Percent = nil
+		p.Current, p.Total = 0, 0
+	case string(model.TaskEventStepFinish):
+		p.Step = event.Step
+		p.StepState = model.StepFinished
+		if event.Message != "" {
+			p.StepState = model.StepFailed
+		} else {
+			done := 100.0
+			p.Percent = &done
+		}
+	case ProgressPercent:
+		p.Step = event.Step
+		p.StepState = model.StepRunning
+		p.Percent = event.Percent
+		p.Current, p.Total = event.Current, event.Total
+	case ProgressWarning:
+		p.WarningCount++
+		p.Warnings = append(p.Warnings, model.ProgressWarning{Step: event.Step, Message: event.Message, OccurredAt: event.OccurredAt})
+		if len(p.Warnings) > progressWarnings {
+			p.Warnings = slices.Delete(p.Warnings, 0, len(p.Warnings)-progressWarnings)
+		}
+	default:
+		return
+	}
+
+	now := time.Now().UTC()
+	p.UpdatedAt = &now
+}
+
+// Get returns a copy of the progress of a task
+func (t *ProgressTracker) Get(taskID string) (model.TaskProgress, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	p, ok := t.tasks[taskID]
+	if !ok {
+		return model.TaskProgress{}, false
+	}
+	progress := *p
+	progress.Warnings = slices.Clone(p.Warnings)
+	return progress, true
+}
+
+// prune drops stale tasks; callers hold mu
+func (t *ProgressTracker) prune() {
+	cutoff := time.Now().Add(-progressRetention)
+	for taskID, p := range t.tasks {
+		if p.UpdatedAt != nil && p.UpdatedAt.Before(cutoff) {
+			delete(t.tasks, taskID)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package manager
 
 import (
+	"Server/pkg/model"
 	"context"
 	"errors"
 	"fmt"
@@ -61,7 +62,22 @@ func (client *RabbitMQClient) connect() error {
 		return fmt.Errorf("failed to confirm channel: %w", err)
 	}
 
-	_, err = client.channel.QueueDeclare(client.queueName, true, false, false, false, nil)
+	args := amqp.Table{"x-max-priority": int(model.MaxTaskPriority)}
+	_, err = client.channel.QueueDeclare(client.queueName, true, false, false, false, args)
+	var amqpErr *amqp.Error
+	if errors.As(err, &amqpErr) && amqpErr.Code == amqp.PreconditionFailed {
+		// the queue was declared by an older server without x-max-priority;
+		// the failed declare closed the channel, migrate on a fresh one
+		client.logger.Warn("task queue has no priority support, migrating it", "queue", client.queueName)
+		client.channel, err = client.conn.Channel()
+		if err != nil {
+			return fmt.Errorf("failed to open a channel: %w", err)
+		}
+		if err := client.channel.Confirm(false); err != nil {
+			return fmt.Errorf("failed to confirm channel: %w", err)
+		}
+		err = client.migrateQueue(args)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to declare a queue: %w", err)
 	}
@@ -76,6 +92,66 @@ func (client *RabbitMQClient) connect() error {
 	return nil
 }
 
+// migrateQueue recreates the queue with args, keeping its messages: they are moved to a
+// temporary queue, the old queue is deleted and redeclared, then the messages are moved back
+// with their original priority. Messages a worker holds unacked while the old queue is
+// deleted are dropped with it; their tasks stay pending until re-prioritized, which
+// publishes them again
+func (client *RabbitMQClient) migrateQueue(args amqp.Table) error {
+	tmp := client.queueName + ".migrate"
+	if _, err := client.channel.QueueDeclare(tmp, true, false, false, false, nil); err != nil {
+		return err
+	}
+	moved, err := client.moveMessages(client.queueName, tmp)
+	if err != nil {
+		return err
+	}
+	if _, err := client.channel.QueueDelete(client.queueName, false, false, false); err != nil {
+		return err
+	}
+	if _, err := client.channel.QueueDeclare(client.queueName, true, false, false, false, args); err != nil {
+		return err
+	}
+	if _, err := client.moveMessages(tmp, client.queueName); err != nil {
+		return err
+	}
+	if _, err := client.channel.QueueDelete(tmp, false, false, false); err != nil {
+		return err
+	}
+	client.logger.Info("task queue migrated", "queue", client.queueName, "messages", moved)
+	return nil
+}
+
+// moveMessages republishes every ready message of src to dst and acks it once dst confirmed it
+func (client *RabbitMQClient) moveMessages(src, dst string) (int, error) {
+	moved := 0
+	for {
+		msg, ok, err := client.channel.Get(src, false)
+		if err != nil {
+			return moved, err
+		}
+		if !ok {
+			return moved, nil
+		}
+		confirmation, err := client.channel.PublishWithDeferredConfirmWithContext(context.Background(), "", dst, false, false, amqp.Publishing{
+			ContentType:  msg.ContentType,
+			DeliveryMode: amqp.Persistent,
+			Priority:     msg.Priority,
+			Body:         msg.Body,
+		})
+		if err != nil {
+			return moved, err
+		}
+		if !confirmation.Wait() {
+			return moved, fmt.Errorf("message moved to %s was nacked", dst)
+		}
+		if err := msg.Ack(false); err != nil {
+			return moved, err
+		}
+		moved++
+	}
+}
+
 func (client *RabbitMQClient) handleReconnect() {
 	for range client.reconnectCh {
 		client.closeMtx.Lock()
@@ -110,7 +186,7 @@ func (client *RabbitMQClient) triggerReconnect() {
 	}
 }
 
-func (client *RabbitMQClient) Publish(ctx context.Context, body string) error {
+func (client *RabbitMQClient) Publish(ctx context.Context, body string, priority uint8) error {
 	client.connMtx.Lock()
 	defer client.connMtx.Unlock()
 
@@ -118,9 +194,9 @@ func (client *RabbitMQClient) Publish(ctx context.Context, body string) error {
 		return errors.New("channel is not initialized, possibly disconnected")
 	}
 
-	confirms := client.channel.NotifyPublish(make(chan amqp.Confirmation, 1))
-
-	err := client.channel.PublishWithContext(ctx,
+	// a deferred confirmation belongs to this publish alone; a NotifyPublish listener
+	// per call would stay registered and block the channel once its buffer is full
+	confirmation, err := client.channel.PublishWithDeferredConfirmWithContext(ctx,
 		"",
 		client.queueName,
 		false,
@@ -128,6 +204,7 @@ func (client *RabbitMQClient) Publish(ctx context.Context, body string) error {
 		amqp.Publishing{
 			ContentType:  "application/json",
 			DeliveryMode: amqp.Persistent,
+			Priority:     min(priority, model.MaxTaskPriority),
 			Body:         []byte(body),
 		})
 
@@ -136,8 +213,8 @@ func (client *RabbitMQClient) Publish(ctx context.Context, body string) error {
 	}
 
 	select {
-	case confirm := <-confirms:
-		if !confirm.Ack {
+	case <-confirmation.Done():
+		if !confirmation.Acked() {
 			return fmt.Errorf("failed to publish a message: nacked by server")
 		}
 	case <-ctx.Done():
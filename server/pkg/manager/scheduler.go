@@ -0,0 +1,312 @@
+package manager
+
+// cron scheduler materializing recurring tasks; schedules live in the database so
+// every server instance sees the same state, rows are locked while a run is in progress
+
+import (
+	"Server/pkg/cron"
+	"Server/pkg/model"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrScheduleNotFound = errors.New("schedule not found")
+	ErrScheduleRunning  = errors.New("previous run of schedule still in progress")
+	ErrScheduleLocked   = errors.New("schedule is being run by another server instance")
+)
+
+type Scheduler struct {
+	db        *gorm.DB
+	rmqClient *RabbitMQClient
+	hooks     *WebhookDispatcher
+	logger    *slog.Logger
+	interval  time.Duration
+
+	// serializes runs within this process, the row lock covers other instances
+	runMtx sync.Mutex
+}
+
+func CreateScheduler(db *gorm.DB, rmqClient *RabbitMQClient, hooks *WebhookDispatcher, logger *slog.Logger, interval time.Duration) *Scheduler {
+	scheduler := &Scheduler{
+		db:        db,
+		rmqClient: rmqClient,
+		hooks:     hooks,
+		logger:    logger.With("component", "scheduler"),
+		interval:  interval,
+	}
+
+	go scheduler.loop()
+
+	return scheduler
+}
+
+// NextRun computes the next activation of a cron expression after now
+func NextRun(expr string, now time.Time) (*time.Time, error) {
+	schedule, err := cron.Parse(expr)
+	if err != nil {
+		return nil, err
+	}
+	next := schedule.Next(now.Local())
+	if next.IsZero() {
+		return nil, fmt.Errorf("cron expression %q never fires", expr)
+	}
+	next = next.UTC()
+	return &next, nil
+}
+
+func (s *Scheduler) loop() {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		s.runDueSchedules()
+	}
+}
+
+func (s *Scheduler) runDueSchedules() {
+	var due []model.Schedule
+	err := s.db.Where("enabled = ? AND next_run_at <= ?", true, time.Now().UTC()).Find(&due).Error
+	if err != nil {
+		s.logger.Error("failed to query due schedules", "error", err)
+		return
+	}
+
+	for _, schedule := range due {
+		if _, err := s.Run(context.Background(), schedule.ID, true); err != nil {
+			s.logger.Warn("schedule run did not complete", "schedule", schedule.Name, "error", err)
+		}
+	}
+}
+
+// Run materializes one run of a schedule and returns the created tasks. Runs
+// triggered by the cron loop also advance NextRunAt; manual runs leave it alone.
+// A run is refused while tasks of the previous run are still pending or running.
+func (s *Scheduler) Run(ctx context.Context, scheduleID uuid.UUID, fromCron bool) ([]model.Task, error) {
+	s.runMtx.Lock()
+	defer s.runMtx.Unlock()
+
+	var created []model.Task
+	// the outcome is kept apart from the transaction error so that skipped and
+	// partially failed runs still commit their bookkeeping; materialize inserts every
+	// task under its own savepoint, so one failed insert does not abort the transaction
+	var runErr error
+
+	// "latest" is resolved before the transaction, the row lock is not held across the network
+	latest, latestErr := s.latestCommit(ctx, scheduleID)
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var schedule model.Schedule
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			First(&schedule, "id = ?", scheduleID).Error
+		if err != nil {
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+			// SKIP LOCKED hides a row locked by another instance running it right now,
+			// look again without the lock to tell that from a deleted schedule
+			var exists int64
+			if err := tx.Model(&model.Schedule{}).Where("id = ?", scheduleID).Count(&exists).Error; err != nil {
+				return err
+			}
+			if exists > 0 {
+				return ErrScheduleLocked
+			}
+			return ErrScheduleNotFound
+		}
+
+		now := time.Now().UTC()
+		updates := map[string]any{"last_run_at": now}
+		if fromCron {
+			next, err := NextRun(schedule.Cron, now)
+			if err != nil {
+				return err
+			}
+			updates["next_run_at"] = next
+		}
+
+		var active int64
+		err = tx.Model(&model.Task{}).
+			Where("schedule_id = ? AND status IN ?", schedule.ID, []model.TaskStatus{model.StatusPending, model.StatusRunning}).
+			Count(&active).Error
+		if err != nil {
+			return err
+		}
+
+		if active > 0 {
+			updates["last_run_status"] = fmt.Sprintf("%s: %d tasks of the previous run still active", model.ScheduleRunSkipped, active)
+			updates["last_run_tasks"] = 0
+			runErr = ErrScheduleRunning
+			return tx.Model(&schedule).Updates(updates).Error
+		}
+
+		kernelCommit := schedule.KernelCommit
+		if kernelCommit == model.KernelCommitLatest {
+			kernelCommit = latest
+			if latestErr == nil && latest == "" {
+				latestErr = errors.New("kernel commit of the schedule changed to latest during the run")
+			}
+			if latestErr != nil {
+				runErr = fmt.Errorf("failed to resolve the latest kernel commit: %w", latestErr)
+			}
+		}
+
+		var tasks []model.Task
+		if runErr == nil {
+			tasks, runErr = s.materialize(tx, &schedule, kernelCommit)
+		}
+		created = tasks
+
+		updates["last_run_tasks"] = len(tasks)
+		if runErr != nil {
+			updates["last_run_status"] = fmt.Sprintf("%s: %v", model.ScheduleRunFailed, runErr)
+		} else if schedule.KernelCommit == model.KernelCommitLatest {
+			updates["last_run_status"] = fmt.Sprintf("%s: built at %s", model.ScheduleRunSucceeded, kernelCommit)
+		} else {
+			updates["last_run_status"] = model.ScheduleRunSucceeded
+		}
+		if err := tx.Model(&schedule).Updates(updates).Error; err != nil {
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// the task rows are committed now, workers are able to accept them
+	published := created[:0]
+	for _, task := range created {
+		if err := PublishTask(ctx, s.rmqClient, &task); err != nil {
+			s.logger.Error("failed to publish scheduled task", "task_id", task.ID, "error", err)
+			s.db.Transaction(func(tx *gorm.DB) error {
+				err := tx.Model(&task).Updates(map[string]any{
+					"status":      model.StatusFailed,
+					"result":      &model.TaskResult{Message: "failed to submit scheduled task to queue"},
+					"finished_at": time.Now().UTC(),
+				}).Error
+				if err != nil {
+					return err
+				}
+				actor := model.Actor{Type: model.ActorSystem, ID: "scheduler"}
+				return RecordStatus(tx, actor, task.ID, model.StatusPending, model.StatusFailed)
+			})
+			runErr = errors.Join(runErr, fmt.Errorf("task %s: %w", task.ID, err))
+			continue
+		}
+		published = append(published, task)
+		s.hooks.Emit(model.EventTaskCreated, task)
+	}
+
+	s.logger.Info("schedule run finished", "schedule_id", scheduleID, "tasks", len(published), "error", runErr)
+	return published, runErr
+}
+
+// latestCommit resolves the head of LinuxRepo when the schedule builds at "latest"
+func (s *Scheduler) latestCommit(ctx context.Context, scheduleID uuid.UUID) (string, error) {
+	var schedule model.Schedule
+	if err := s.db.WithContext(ctx).Select("kernel_commit").First(&schedule, "id = ?", scheduleID).Error; err != nil {
+		// reported by the locked read inside the transaction
+		return "", nil
+	}
+	if schedule.KernelCommit != model.KernelCommitLatest {
+		return "", nil
+	}
+	return ResolveLatestCommit(ctx)
+}
+
+// LinuxRepo is the tree "latest" kernel commits are resolved against, the one the
+// kernel-builder downloads source archives from
+var LinuxRepo = "https://github.com/torvalds/linux.git"
+
+var commitPattern = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// ResolveLatestCommit returns the commit HEAD of LinuxRepo points to; a variable so
+// tests can run without network access
+var ResolveLatestCommit = func(ctx context.Context) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, "git", "ls-remote", LinuxRepo, "HEAD").Output()
+	if err != nil {
+		return "", fmt.Errorf("git ls-remote %s: %w", LinuxRepo, err)
+	}
+	commit, _, _ := strings.Cut(strings.TrimSpace(string(out)), "\t")
+	if !commitPattern.MatchString(commit) {
+		return "", fmt.Errorf("unexpected git ls-remote output %q", out)
+	}
+	return commit, nil
+}
+
+// materialize selects the latest kernel-build task of every bug matching the
+// schedule selectors and creates a fresh task for it, built at kernelCommit when
+// set; publishing happens after commit. Every insert runs under its own savepoint:
+// a bug that fails is reported and skipped, the others are still created
+func (s *Scheduler) materialize(tx *gorm.DB, schedule *model.Schedule, kernelCommit string) ([]model.Task, error) {
+	query := tx.Model(&model.Task{}).Where("type = ?", model.TaskTypeKernelBuild)
+	if schedule.Subsystem != "" {
+		query = query.Where("payload->'subsystems' @> ?::jsonb", fmt.Sprintf("[%q]", schedule.Subsystem))
+	}
+	if schedule.Campaign != "" {
+		query = query.Where("campaign = ?", schedule.Campaign)
+	}
+	if schedule.BugStatus != "" {
+		query = query.Where("payload->>'status' = ?", schedule.BugStatus)
+	}
+
+	var candidates []model.Task
+	if err := query.Order("created_at desc").Find(&candidates).Error; err != nil {
+		return nil, err
+	}
+
+	actor := model.Actor{Type: model.ActorSystem, ID: "schedule:" + schedule.Name}
+	seen := make(map[string]bool)
+	var created []model.Task
+	var errs error
+	for _, candidate := range candidates {
+		bugID := candidate.Payload.ID
+		if bugID == "" || seen[bugID] {
+			continue
+		}
+		seen[bugID] = true
+
+		report := candidate.Payload
+		if kernelCommit != "" && len(report.Crashes) > 0 {
+			report.Crashes = append([]model.Crash(nil), report.Crashes...)
+			report.Crashes[0].KernelSourceCommit = kernelCommit
+		}
+
+		task := model.CreateTask(model.TaskTypeKernelBuild, report, schedule.Priority)
+		task.Campaign = schedule.TaskCampaign()
+		task.ScheduleID = &schedule.ID
+
+		err := tx.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(task).Error; err != nil {
+				return err
+			}
+			if err := RecordStatus(tx, actor, task.ID, "", task.Status); err != nil {
+				return err
+			}
+			return Audit(tx, actor, model.AuditTaskCreate, "task", task.ID.String(), nil, task)
+		})
+		if err != nil {
+			errs = errors.Join(errs, fmt.Errorf("bug %s: %w", bugID, err))
+			continue
+		}
+		created = append(created, *task)
+	}
+
+	return created, errs
+}
@@ -0,0 +1,49 @@
+package manager
+
+import (
+	"Server/pkg/model"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// EnqueueTask persists a newly created task together with its audit entry and
+// publishes it to the task queue. Callers creating several tasks in one
+// transaction use PublishTask after commit.
+func EnqueueTask(ctx context.Context, db *gorm.DB, rmqClient *RabbitMQClient, task *model.Task, actor model.Actor) error {
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(task).Error; err != nil {
+			return fmt.Errorf("failed to save task to database: %w", err)
+		}
+		if err := RecordStatus(tx, actor, task.ID, "", task.Status); err != nil {
+			return err
+		}
+		return Audit(tx, actor, model.AuditTaskCreate, "task", task.ID.String(), nil, task)
+	})
+	if err != nil {
+		return err
+	}
+
+	return PublishTask(ctx, rmqClient, task)
+}
+
+// PublishTask hands an already persisted task to the workers. The task row must
+// be committed first, a worker may try to accept it as soon as it is published.
+func PublishTask(ctx context.Context, rmqClient *RabbitMQClient, task *model.Task) error {
+	taskJSON, err := json.Marshal(task)
+	if err != nil {
+		return fmt.Errorf("failed to serialize task: %w", err)
+	}
+
+	publishCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if err := rmqClient.Publish(publishCtx, string(taskJSON), task.Priority); err != nil {
+		return fmt.Errorf("failed to submit task to queue: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,100 @@
+package manager
+
+import (
+	"Server/pkg/model"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var ErrStepNotStarted = errors.New("step finished without being started")
+
+// RecordStatus appends a status transition to the task timeline. Pass the
+// transaction changing the status so that both commit together; from is empty
+// for newly created tasks.
+func RecordStatus(tx *gorm.DB, actor model.Actor, taskID uuid.UUID, from, to model.TaskStatus) error {
+	now := time.Now().UTC()
+	source := actor.ID
+	if source == "" {
+		source = actor.Type
+	}
+	return tx.Create(&model.TaskEvent{
+		ID:         uuid.New(),
+		TaskID:     taskID,
+		Kind:       model.TaskEventStatus,
+		FromStatus: from,
+		ToStatus:   to,
+		Source:     source,
+		OccurredAt: now,
+		CreatedAt:  now,
+	}).Error
+}
+
+// RecordStep appends a step boundary reported by a worker. A finish event
+// without a duration gets one computed from the latest start of the same step.
+func RecordStep(tx *gorm.DB, event *model.TaskEvent) error {
+	now := time.Now().UTC()
+	event.ID = uuid.New()
+	event.CreatedAt = now
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = now
+	}
+
+	if event.Kind == model.TaskEventStepFinish && event.DurationMs == nil {
+		var start model.TaskEvent
+		err := tx.Where("task_id = ? AND kind = ? AND step = ?", event.TaskID, model.TaskEventStepStart, event.Step).
+			Order("occurred_at desc").First(&start).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrStepNotStarted
+			}
+			return err
+		}
+		duration := event.OccurredAt.Sub(start.OccurredAt).Milliseconds()
+		event.DurationMs = &duration
+	}
+
+	return tx.Create(event).Error
+}
+
+// Timeline loads the events of a task in the order they happened and pairs the
+// step boundaries into spans
+func Timeline(db *gorm.DB, task *model.Task) (*model.TaskTimeline, error) {
+	var events []model.TaskEvent
+	err := db.Where("task_id = ?", task.ID).Order("occurred_at, created_at").Find(&events).Error
+	if err != nil {
+		return nil, err
+	}
+
+	timeline := &model.TaskTimeline{
+		TaskID: task.ID,
+		Status: task.Status,
+		Events: events,
+		Steps:  []model.StepSpan{},
+	}
+
+	// index of the open span of every step, a step may run more than once
+	open := make(map[string]int)
+	for _, event := range events {
+		switch event.Kind {
+		case model.TaskEventStepStart:
+			open[event.Step] = len(timeline.Steps)
+			timeline.Steps = append(timeline.Steps, model.StepSpan{Step: event.Step, StartedAt: event.OccurredAt})
+		case model.TaskEventStepFinish:
+			i, ok := open[event.Step]
+			if !ok {
+				continue
+			}
+			delete(open, event.Step)
+			finished := event.OccurredAt
+			span := &timeline.Steps[i]
+			span.FinishedAt = &finished
+			span.DurationMs = event.DurationMs
+			span.Error = event.Error
+		}
+	}
+
+	return timeline, nil
+}
@@ -0,0 +1,170 @@
+package manager
+
+import (
+	"Server/pkg/model"
+	"regexp"
+	"strings"
+)
+
+var (
+	// "[net?] " style subsystem tags of syzbot emails
+	titleTags = regexp.MustCompile(`^(?:\[[^\]]*\]\s*)+`)
+	// " (2)" suffixes syzbot appends to titles seen again after a fix
+	titleDuplicate = regexp.MustCompile(`\s+\(\d+\)$`)
+	// compiler generated suffixes such as foo.constprop.0 or foo.isra.3
+	titleFuncSuffix = regexp.MustCompile(`\.(?:constprop|isra|part|cold|llvm|lto_priv)(?:\.\d+)*\b`)
+	titleSpaces     = regexp.MustCompile(`\s+`)
+)
+
+// titleAliases maps spellings that changed between kernel versions to the one
+// syzbot uses for the same bug
+var titleAliases = strings.NewReplacer(
+	"KASAN: slab-use-after-free ", "KASAN: use-after-free ",
+	"BUG: unable to handle page fault ", "BUG: unable to handle kernel paging request ",
+	"BUG: unable to handle kernel page fault ", "BUG: unable to handle kernel paging request ",
+)
+
+// NormalizeTitle reduces a crash title to the form syzbot dedups on, so that the
+// title parsed from the console matches the title of the report
+func NormalizeTitle(title string) string {
+	title = titleSpaces.ReplaceAllString(strings.TrimSpace(title), " ")
+	title = titleTags.ReplaceAllString(title, "")
+	title = titleDuplicate.ReplaceAllString(title, "")
+	title = titleFuncSuffix.ReplaceAllString(title, "")
+	return titleAliases.Replace(title)
+}
+
+// JudgeResult fills in the crash title and verdict of a finished task. The
+// guest crashed if the worker says so, the console holds a report or a vmcore
+// was captured. The crash reproduces the report if the first console report or
+// any report after it has the same normalized title; a vmcore without a console
+// report can't be told apart and counts as reproduced. A task that failed
+// without a crash gets no verdict since the reproducer may never have run.
+func JudgeResult(result *model.TaskResult, status model.TaskStatus, expected string, console *model.ConsoleReport) {
+	result.Crashed = result.Crashed || result.VmcoreCaptured || console != nil
+	result.ExpectedTitle = expected
+	result.CrashTitle = ""
+	if console != nil {
+		result.CrashTitle = console.Title
+	}
+
+	switch {
+	case !result.Crashed && status != model.StatusSuccess:
+		result.Verdict = ""
+	case !result.Crashed:
+		result.Verdict = model.VerdictNotReproduced
+	case console == nil || expected == "":
+		result.Verdict = model.VerdictReproduced
+	case sameCrash(expected, console):
+		result.Verdict = model.VerdictReproduced
+	default:
+		result.Verdict = model.VerdictDifferentCrash
+	}
+}
+
+// JudgePatch tells from the verdict of a patch-apply task whether the patch made
+// the crash go away. A different crash may hide the original one, so only the
+// reproduced and not reproduced verdicts decide.
+func JudgePatch(result *model.TaskResult) {
+	var gone bool
+	switch result.Verdict {
+	case model.VerdictNotReproduced:
+		gone = true
+	case model.VerdictReproduced:
+		gone = false
+	default:
+		result.CrashGone = nil
+		return
+	}
+	result.CrashGone = &gone
+}
+
+// JudgeFix fills in the verdicts of both runs of a fix-verify task and whether
+// the fix commit made the crash go away. The crash must reproduce at the parent
+// commit for the fix run to tell anything; as with JudgePatch, a different crash
+// decides nothing.
+func JudgeFix(verification *model.FixVerification, expected string) {
+	for _, run := range []*model.FixRun{verification.Parent, verification.Fix} {
+		if run == nil {
+			continue
+		}
+		// a run is only reported once the reproducer ran, so no crash means it was not reproduced
+		result := model.TaskResult{Crashed: run.Crashed, VmcoreCaptured: run.VmcoreCaptured}
+		JudgeResult(&result, model.StatusSuccess, expected, run.Console)
+		run.Crashed = result.Crashed
+		run.CrashTitle = result.CrashTitle
+		run.Verdict = result.Verdict
+	}
+
+	verification.Fixed = nil
+	if verification.Parent == nil || verification.Fix == nil || verification.Parent.Verdict != model.VerdictReproduced {
+		return
+	}
+	var fixed bool
+	switch verification.Fix.Verdict {
+	case model.VerdictNotReproduced:
+		fixed = true
+	case model.VerdictReproduced:
+		fixed = false
+	default:
+		return
+	}
+	verification.Fixed = &fixed
+}
+
+// JudgeRuns fills in the verdict of each reproducer run and the crash rate of
+// the task. Runs that failed to start have no verdict and do not count towards
+// the rate, which stays nil when no run started.
+func JudgeRuns(result *model.TaskResult, expected string) {
+	started, crashed := 0, 0
+	for i := range result.Runs {
+		run := &result.Runs[i]
+		run.CrashTitle, run.Verdict = "", ""
+		if run.Error != "" {
+			continue
+		}
+		judged := model.TaskResult{Crashed: run.Crashed, VmcoreCaptured: run.VmcoreCaptured}
+		JudgeResult(&judged, model.StatusSuccess, expected, run.Console)
+		run.Crashed = judged.Crashed
+		run.CrashTitle = judged.CrashTitle
+		run.Verdict = judged.Verdict
+		started++
+		if run.Crashed {
+			crashed++
+		}
+	}
+
+	result.CrashRate = nil
+	if started > 0 {
+		rate := float64(crashed) / float64(started)
+		result.CrashRate = &rate
+	}
+}
+
+// JudgeBisect fills in the crash title of each step of a bisect task and
+// whether it was the crash of the report. Skipped steps have no verdict.
+func JudgeBisect(bisect *model.BisectResult, expected string) {
+	for i := range bisect.Steps {
+		step := &bisect.Steps[i]
+		result := model.TaskResult{Crashed: step.Crashed, VmcoreCaptured: step.VmcoreCaptured}
+		JudgeResult(&result, model.StatusSuccess, expected, step.Console)
+		step.CrashTitle = result.CrashTitle
+		step.Reproduction = ""
+		if step.Verdict != model.BisectSkip {
+			step.Reproduction = result.Verdict
+		}
+	}
+}
+
+func sameCrash(expected string, console *model.ConsoleReport) bool {
+	want := NormalizeTitle(expected)
+	if NormalizeTitle(console.Title) == want {
+		return true
+	}
+	for _, title := range console.OtherTitles {
+		if NormalizeTitle(title) == want {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,146 @@
+package manager
+
+import (
+	"Server/pkg/model"
+	"testing"
+)
+
+func TestNormalizeTitle(t *testing.T) {
+	tests := []struct {
+		title string
+		want  string
+	}{
+		// titles of syzbot reports and the subjects of their emails
+		{"KASAN: use-after-free Read in hci_conn_hash_flush", "KASAN: use-after-free Read in hci_conn_hash_flush"},
+		{"KASAN: slab-use-after-free Read in hci_conn_hash_flush", "KASAN: use-after-free Read in hci_conn_hash_flush"},
+		{"[syzbot] [bluetooth?] KASAN: slab-use-after-free Read in hci_conn_hash_flush", "KASAN: use-after-free Read in hci_conn_hash_flush"},
+		{"INFO: task hung in rtnl_lock (3)", "INFO: task hung in rtnl_lock"},
+		{"[net?] INFO: task hung in rtnl_lock (12)", "INFO: task hung in rtnl_lock"},
+		{"WARNING in sta_apply_auth_flags.constprop.0", "WARNING in sta_apply_auth_flags"},
+		{"general protection fault in ext4_xattr_inode_dec_ref_all.isra.0.cold", "general protection fault in ext4_xattr_inode_dec_ref_all"},
+		{"BUG: unable to handle page fault in __do_sys_io_uring_enter", "BUG: unable to handle kernel paging request in __do_sys_io_uring_enter"},
+		{"BUG: unable to handle kernel page fault in corrupted", "BUG: unable to handle kernel paging request in corrupted"},
+		{"KCSAN: data-race in __dentry_kill / lookup_fast", "KCSAN: data-race in __dentry_kill / lookup_fast"},
+		{"  WARNING  in\trate_control_rate_init ", "WARNING in rate_control_rate_init"},
+		// the version of a title is only dropped at the end
+		{"KASAN: use-after-free Read in hci_conn_hash_flush (2) fixup", "KASAN: use-after-free Read in hci_conn_hash_flush (2) fixup"},
+	}
+	for _, tt := range tests {
+		if got := NormalizeTitle(tt.title); got != tt.want {
+			t.Errorf("NormalizeTitle(%q) = %q, want %q", tt.title, got, tt.want)
+		}
+	}
+}
+
+func TestJudgeResult(t *testing.T) {
+	const expected = "[syzbot] KASAN: use-after-free Read in hci_conn_hash_flush (2)"
+	kasan := &model.ConsoleReport{Title: "KASAN: slab-use-after-free Read in hci_conn_hash_flush"}
+	warning := &model.ConsoleReport{Title: "WARNING in rate_control_rate_init"}
+	later := &model.ConsoleReport{
+		Title:       "WARNING in rate_control_rate_init",
+		OtherTitles: []string{"KASAN: slab-use-after-free Read in hci_conn_hash_flush"},
+	}
+
+	tests := []struct {
+		name    string
+		status  model.TaskStatus
+		vmcore  bool
+		console *model.ConsoleReport
+		want    model.Verdict
+	}{
+		{"same title", model.StatusSuccess, true, kasan, model.VerdictReproduced},
+		{"different title", model.StatusSuccess, false, warning, model.VerdictDifferentCrash},
+		{"later report matches", model.StatusSuccess, false, later, model.VerdictReproduced},
+		{"vmcore without console report", model.StatusSuccess, true, nil, model.VerdictReproduced},
+		{"no crash", model.StatusSuccess, false, nil, model.VerdictNotReproduced},
+		{"failed before the reproducer", model.StatusFailed, false, nil, ""},
+		{"failed after a crash", model.StatusFailed, false, kasan, model.VerdictReproduced},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := &model.TaskResult{VmcoreCaptured: tt.vmcore}
+			JudgeResult(result, tt.status, expected, tt.console)
+			if result.Verdict != tt.want {
+				t.Errorf("verdict %q, want %q", result.Verdict, tt.want)
+			}
+			if crashed := tt.vmcore || tt.console != nil; result.Crashed != crashed {
+				t.Errorf("crashed %v, want %v", result.Crashed, crashed)
+			}
+		})
+	}
+}
+
+func TestJudgeRuns(t *testing.T) {
+	const expected = "KASAN: slab-use-after-free Read in tun_chr_close"
+	report := &model.ConsoleReport{Title: expected}
+	other := &model.ConsoleReport{Title: "WARNING in netdev_run_todo"}
+
+	tests := []struct {
+		name     string
+		runs     []model.ReproRun
+		rate     *float64
+		verdicts []model.Verdict
+	}{
+		{
+			name: "some runs crashed",
+			runs: []model.ReproRun{
+				{Index: 1, Crashed: true, VmcoreCaptured: true, Console: report},
+				{Index: 2},
+				{Index: 3, Crashed: true, Console: report},
+				{Index: 4},
+				{Index: 5, Crashed: true, Console: other},
+			},
+			rate:     rate(0.6),
+			verdicts: []model.Verdict{model.VerdictReproduced, model.VerdictNotReproduced, model.VerdictReproduced, model.VerdictNotReproduced, model.VerdictDifferentCrash},
+		},
+		{
+			// runs that did not start tell nothing and do not count
+			name: "failed runs",
+			runs: []model.ReproRun{
+				{Index: 1, Error: "ssh: handshake failed: EOF"},
+				{Index: 2, Crashed: true, Console: report},
+				{Index: 3},
+				{Index: 4, Error: "gcc: exit status 1"},
+			},
+			rate:     rate(0.5),
+			verdicts: []model.Verdict{"", model.VerdictReproduced, model.VerdictNotReproduced, ""},
+		},
+		{
+			name: "no run started",
+			runs: []model.ReproRun{
+				{Index: 1, Error: "qemu exited before qmp was available"},
+				{Index: 2, Error: "qemu exited before qmp was available"},
+			},
+			verdicts: []model.Verdict{"", ""},
+		},
+		{
+			// a vmcore without a console report still counts as a crash
+			name: "vmcore only",
+			runs: []model.ReproRun{
+				{Index: 1, VmcoreCaptured: true},
+				{Index: 2},
+			},
+			rate:     rate(0.5),
+			verdicts: []model.Verdict{model.VerdictReproduced, model.VerdictNotReproduced},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := &model.TaskResult{Runs: tt.runs}
+			JudgeRuns(result, expected)
+			switch {
+			case tt.rate == nil && result.CrashRate != nil:
+				t.Errorf("crash rate %v, want none", *result.CrashRate)
+			case tt.rate != nil && (result.CrashRate == nil || *result.CrashRate != *tt.rate):
+				t.Errorf("crash rate %v, want %v", result.CrashRate, *tt.rate)
+			}
+			for i, run := range result.Runs {
+				if run.Verdict != tt.verdicts[i] {
+					t.Errorf("run %d verdict %q, want %q", run.Index, run.Verdict, tt.verdicts[i])
+				}
+			}
+		})
+	}
+}
+
+func rate(v float64) *float64 { return &v }
@@ -0,0 +1,331 @@
+package manager
+
+// outbound webhooks: events are written to webhook_deliveries first and sent by a
+// background loop, so a slow or unreachable receiver never blocks an API handler
+
+import (
+	"Server/pkg/model"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	webhookMaxAttempts = 8
+	webhookBaseBackoff = 30 * time.Second
+	webhookMaxBackoff  = 1 * time.Hour
+	webhookTimeout     = 10 * time.Second
+	// webhookClaim is how long a claimed delivery is hidden from other instances
+	webhookClaim     = 3 * webhookTimeout
+	webhookBatchSize = 20
+
+	// SignatureHeader carries "t=<unix seconds>,v1=<hex hmac-sha256>", the MAC is
+	// computed with the webhook secret over "<unix seconds>.<request body>"
+	SignatureHeader = "X-Platform-Signature"
+	EventHeader     = "X-Platform-Event"
+	DeliveryHeader  = "X-Platform-Delivery"
+)
+
+var ErrDeliveryNotFound = errors.New("webhook delivery not found")
+
+// WebhookEnvelope is the JSON body posted to receivers
+type WebhookEnvelope struct {
+	ID        uuid.UUID `json:"id"`
+	Event     string    `json:"event"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      any       `json:"data"`
+}
+
+type WebhookDispatcher struct {
+	db           *gorm.DB
+	logger       *slog.Logger
+	client       *http.Client
+	pollInterval time.Duration
+	wakeCh       chan struct{}
+}
+
+func CreateWebhookDispatcher(db *gorm.DB, logger *slog.Logger, pollInterval time.Duration) *WebhookDispatcher {
+	dispatcher := &WebhookDispatcher{
+		db:           db,
+		logger:       logger.With("component", "webhook_dispatcher"),
+		client:       &http.Client{Timeout: webhookTimeout},
+		pollInterval: pollInterval,
+		wakeCh:       make(chan struct{}, 1),
+	}
+
+	go dispatcher.loop()
+
+	return dispatcher
+}
+
+// Sign returns the signature header value for a request body
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return fmt.Sprintf("t=%d,v1=%s", timestamp, hex.EncodeToString(mac.Sum(nil)))
+}
+
+// Emit records an event for every subscribed active webhook. Errors are logged
+// only: a failing webhook store must not fail the request that caused the event.
+func (d *WebhookDispatcher) Emit(event string, data any) {
+	if d == nil {
+		return
+	}
+
+	var webhooks []model.Webhook
+	if err := d.db.Where("active = ?", true).Find(&webhooks).Error; err != nil {
+		d.logger.Error("failed to load webhooks", "event", event, "error", err)
+		return
+	}
+
+	now := time.Now().UTC()
+	envelope := WebhookEnvelope{
+		ID:        uuid.New(),
+		Event:     event,
+		CreatedAt: now,
+		Data:      data,
+	}
+	payload, err := json.Marshal(envelope)
+	if err != nil {
+		d.logger.Error("failed to marshal webhook event", "event", event, "error", err)
+		return
+	}
+
+	var deliveries []model.WebhookDelivery
+	for _, webhook := range webhooks {
+		if !webhook.Subscribed(event) {
+			continue
+		}
+		deliveries = append(deliveries, model.WebhookDelivery{
+			ID:            uuid.New(),
+			WebhookID:     webhook.ID,
+			Event:         event,
+			Payload:       model.RawJSON(payload),
+			Status:        model.DeliveryPending,
+			NextAttemptAt: &now,
+		})
+	}
+	if len(deliveries) == 0 {
+		return
+	}
+
+	if err := d.db.Create(&deliveries).Error; err != nil {
+		d.logger.Error("failed to record webhook deliveries", "event", event, "error", err)
+		return
+	}
+	d.wake()
+}
+
+// Redeliver queues a new delivery with the payload of an earlier one, leaving
+// the original entry in the log untouched
+func (d *WebhookDispatcher) Redeliver(webhookID, deliveryID uuid.UUID) (*model.WebhookDelivery, error) {
+	var original model.WebhookDelivery
+	if err := d.db.First(&original, "id = ? AND webhook_id = ?", deliveryID, webhookID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDeliveryNotFound
+		}
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	delivery := model.WebhookDelivery{
+		ID:            uuid.New(),
+		WebhookID:     original.WebhookID,
+		Event:         original.Event,
+		Payload:       original.Payload,
+		Status:        model.DeliveryPending,
+		RedeliveryOf:  &original.ID,
+		NextAttemptAt: &now,
+	}
+	if err := d.db.Create(&delivery).Error; err != nil {
+		return nil, err
+	}
+
+	d.wake()
+	return &delivery, nil
+}
+
+func (d *WebhookDispatcher) wake() {
+	select {
+	case d.wakeCh <- struct{}{}:
+	default:
+	}
+}
+
+func (d *WebhookDispatcher) loop() {
+	ticker := time.NewTicker(d.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-d.wakeCh:
+		}
+
+		drainDeliveries(d.deliverBatch)
+	}
+}
+
+// drainDeliveries keeps delivering while full batches come back. It stops once a batch
+// delivers nothing: its due deliveries are claimed by other instances, and querying
+// them again right away would return the same rows until those claims commit
+func drainDeliveries(deliverBatch func() (due, handled int)) {
+	for {
+		due, handled := deliverBatch()
+		if due < webhookBatchSize || handled == 0 {
+			return
+		}
+	}
+}
+
+// deliverBatch sends up to webhookBatchSize due deliveries and returns how many were
+// due and how many of those this instance handled
+func (d *WebhookDispatcher) deliverBatch() (int, int) {
+	var due []model.WebhookDelivery
+	err := d.db.Select("id").
+		Where("status = ? AND next_attempt_at <= ?", model.DeliveryPending, time.Now().UTC()).
+		Order("next_attempt_at asc").
+		Limit(webhookBatchSize).
+		Find(&due).Error
+	if err != nil {
+		d.logger.Error("failed to query due webhook deliveries", "error", err)
+		return 0, 0
+	}
+
+	handled := 0
+	for _, delivery := range due {
+		ok, err := d.attempt(delivery.ID)
+		if err != nil {
+			d.logger.Error("failed to process webhook delivery", "delivery_id", delivery.ID, "error", err)
+		}
+		if ok {
+			handled++
+		}
+	}
+	return len(due), handled
+}
+
+// attempt claims one delivery, sends it and records the outcome. The claim moves
+// next_attempt_at past the send timeout and commits, so no transaction or row lock is
+// held during the request, and other server instances skip the delivery meanwhile;
+// if this instance dies mid-send the claim expires and the delivery is retried.
+// It reports whether this instance handled the delivery rather than skipping it.
+func (d *WebhookDispatcher) attempt(deliveryID uuid.UUID) (bool, error) {
+	var delivery model.WebhookDelivery
+	var webhook model.Webhook
+	handled, claimed := false, false
+	err := d.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			First(&delivery, "id = ? AND status = ? AND next_attempt_at <= ?", deliveryID, model.DeliveryPending, time.Now().UTC()).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// delivered, or claimed by another instance in the meantime
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := tx.First(&webhook, "id = ?", delivery.WebhookID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				handled = true
+				return tx.Model(&delivery).Updates(map[string]any{
+					"status":          model.DeliveryFailed,
+					"next_attempt_at": nil,
+					"last_error":      "webhook was deleted",
+				}).Error
+			}
+			return err
+		}
+
+		handled, claimed = true, true
+		return tx.Model(&delivery).Update("next_attempt_at", time.Now().UTC().Add(webhookClaim)).Error
+	})
+	if err != nil || !claimed {
+		return handled && err == nil, err
+	}
+
+	code, sendErr := d.send(&webhook, &delivery)
+	attempts := delivery.Attempts + 1
+
+	if sendErr != nil {
+		d.logger.Warn("webhook delivery failed", "delivery_id", delivery.ID, "url", webhook.URL, "attempt", attempts, "error", sendErr)
+	} else {
+		d.logger.Debug("webhook delivered", "delivery_id", delivery.ID, "url", webhook.URL, "attempt", attempts)
+	}
+
+	// the attempts guard keeps a late outcome from overwriting one recorded after the claim expired
+	return true, d.db.Model(&model.WebhookDelivery{}).
+		Where("id = ? AND status = ? AND attempts = ?", delivery.ID, model.DeliveryPending, delivery.Attempts).
+		Updates(deliveryOutcome(attempts, code, sendErr, time.Now().UTC())).Error
+}
+
+// deliveryOutcome is the update recorded after the attempts-th attempt: success, a
+// retry with exponential backoff, or dead-lettering once webhookMaxAttempts is reached
+func deliveryOutcome(attempts, code int, sendErr error, now time.Time) map[string]any {
+	updates := map[string]any{
+		"attempts":      attempts,
+		"response_code": code,
+	}
+
+	switch {
+	case sendErr == nil:
+		updates["status"] = model.DeliverySucceeded
+		updates["delivered_at"] = now
+		updates["next_attempt_at"] = nil
+		updates["last_error"] = ""
+	case attempts >= webhookMaxAttempts:
+		updates["status"] = model.DeliveryFailed
+		updates["next_attempt_at"] = nil
+		updates["last_error"] = sendErr.Error()
+	default:
+		backoff := min(webhookBaseBackoff<<(attempts-1), webhookMaxBackoff)
+		updates["next_attempt_at"] = now.Add(backoff)
+		updates["last_error"] = sendErr.Error()
+	}
+	return updates
+}
+
+func (d *WebhookDispatcher) send(webhook *model.Webhook, delivery *model.WebhookDelivery) (int, error) {
+	body := []byte(delivery.Payload)
+
+	ctx, cancel := context.WithTimeout(context.Background(), webhookTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Platform-Webhook/1")
+	req.Header.Set(EventHeader, delivery.Event)
+	req.Header.Set(DeliveryHeader, delivery.ID.String())
+	req.Header.Set(SignatureHeader, Sign(webhook.Secret, time.Now().Unix(), body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("receiver responded with status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
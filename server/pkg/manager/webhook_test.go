@@ -0,0 +1,215 @@
+package manager
+
+import (
+	"Server/pkg/model"
+	"crypto/hmac"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// verifySignature checks a signature header the way a receiver would
+func verifySignature(t *testing.T, secret, header string, body []byte) {
+	t.Helper()
+	var timestamp, mac string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(part, "=")
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			mac = value
+		}
+	}
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		t.Fatalf("bad timestamp in %q", header)
+	}
+	if d := time.Since(time.Unix(ts, 0)); d < 0 || d > time.Minute {
+		t.Errorf("signature timestamp %d is %s off", ts, d)
+	}
+	want := Sign(secret, ts, body)
+	if !hmac.Equal([]byte(header), []byte(want)) || mac == "" {
+		t.Errorf("signature %q, want %q", header, want)
+	}
+}
+
+func TestSign(t *testing.T) {
+	// reference value: printf '1700000000.{"a":1}' | openssl dgst -sha256 -hmac secret
+	got := Sign("secret", 1700000000, []byte(`{"a":1}`))
+	want := "t=1700000000,v1=49f24e537407743fa4a0242bb63b94b9a47ee99cbbe071ccd8a22550ae411686"
+	if got != want {
+		t.Fatalf("Sign = %q, want %q", got, want)
+	}
+	if Sign("secret", 1700000000, []byte(`{"a":2}`)) == got {
+		t.Error("signature does not depend on the body")
+	}
+	if Sign("other", 1700000000, []byte(`{"a":1}`)) == got {
+		t.Error("signature does not depend on the secret")
+	}
+	if Sign("secret", 1700000001, []byte(`{"a":1}`)) == got {
+		t.Error("signature does not depend on the timestamp")
+	}
+}
+
+func TestSendSignsRequest(t *testing.T) {
+	webhook := &model.Webhook{ID: uuid.New(), Secret: "s3cret"}
+	delivery := &model.WebhookDelivery{
+		ID:      uuid.New(),
+		Event:   model.EventTaskSucceeded,
+		Payload: model.RawJSON(`{"event":"task.succeeded"}`),
+	}
+
+	var got *http.Request
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+	webhook.URL = srv.URL
+
+	d := &WebhookDispatcher{client: srv.Client()}
+	code, err := d.send(webhook, delivery)
+	if err != nil || code != http.StatusNoContent {
+		t.Fatalf("send = %d, %v", code, err)
+	}
+	if got.Method != http.MethodPost || got.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("request %s with content type %q", got.Method, got.Header.Get("Content-Type"))
+	}
+	if got.Header.Get(EventHeader) != delivery.Event || got.Header.Get(DeliveryHeader) != delivery.ID.String() {
+		t.Errorf("event headers %q %q", got.Header.Get(EventHeader), got.Header.Get(DeliveryHeader))
+	}
+	if string(body) != string(delivery.Payload) {
+		t.Errorf("body %q, want %q", body, delivery.Payload)
+	}
+	verifySignature(t, webhook.Secret, got.Header.Get(SignatureHeader), body)
+}
+
+func TestSendFailures(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusBadGateway)
+	}))
+	d := &WebhookDispatcher{client: srv.Client()}
+	delivery := &model.WebhookDelivery{ID: uuid.New(), Payload: model.RawJSON(`{}`)}
+
+	code, err := d.send(&model.Webhook{URL: srv.URL}, delivery)
+	if err == nil || code != http.StatusBadGateway {
+		t.Errorf("send to failing receiver = %d, %v", code, err)
+	}
+
+	srv.Close()
+	code, err = d.send(&model.Webhook{URL: srv.URL}, delivery)
+	if err == nil || code != 0 {
+		t.Errorf("send to closed receiver = %d, %v", code, err)
+	}
+}
+
+func TestDeliveryOutcome(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	failure := errors.New("receiver responded with status 500")
+
+	success := deliveryOutcome(3, 200, nil, now)
+	if success["status"] != model.DeliverySucceeded || success["next_attempt_at"] != nil || success["attempts"] != 3 {
+		t.Errorf("success outcome %v", success)
+	}
+
+	tests := []struct {
+		attempts int
+		backoff  time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{7, 32 * time.Minute},
+	}
+	for _, tt := range tests {
+		retry := deliveryOutcome(tt.attempts, 500, failure, now)
+		if _, ok := retry["status"]; ok {
+			t.Errorf("attempt %d changed status to %v", tt.attempts, retry["status"])
+		}
+		if next := retry["next_attempt_at"].(time.Time); next.Sub(now) != tt.backoff {
+			t.Errorf("attempt %d retries after %s, want %s", tt.attempts, next.Sub(now), tt.backoff)
+		}
+		if retry["last_error"] != failure.Error() || retry["response_code"] != 500 {
+			t.Errorf("attempt %d outcome %v", tt.attempts, retry)
+		}
+	}
+
+	dead := deliveryOutcome(webhookMaxAttempts, 500, failure, now)
+	if dead["status"] != model.DeliveryFailed || dead["next_attempt_at"] != nil {
+		t.Errorf("last attempt outcome %v", dead)
+	}
+}
+
+// TestRetryUntilDeadLetter drives a delivery against a receiver that recovers
+// after a few failures and against one that never does
+func TestRetryUntilDeadLetter(t *testing.T) {
+	run := func(failures int32) (int, model.DeliveryStatus) {
+		var calls atomic.Int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if calls.Add(1) <= failures {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+		}))
+		defer srv.Close()
+
+		d := &WebhookDispatcher{client: srv.Client()}
+		webhook := &model.Webhook{URL: srv.URL, Secret: "s"}
+		delivery := &model.WebhookDelivery{ID: uuid.New(), Payload: model.RawJSON(`{}`), Status: model.DeliveryPending}
+		for delivery.Status == model.DeliveryPending {
+			code, err := d.send(webhook, delivery)
+			outcome := deliveryOutcome(delivery.Attempts+1, code, err, time.Now())
+			delivery.Attempts = outcome["attempts"].(int)
+			if status, ok := outcome["status"]; ok {
+				delivery.Status = status.(model.DeliveryStatus)
+			}
+		}
+		return delivery.Attempts, delivery.Status
+	}
+
+	if attempts, status := run(3); attempts != 4 || status != model.DeliverySucceeded {
+		t.Errorf("recovering receiver: %d attempts, %s", attempts, status)
+	}
+	if attempts, status := run(1000); attempts != webhookMaxAttempts || status != model.DeliveryFailed {
+		t.Errorf("dead receiver: %d attempts, %s", attempts, status)
+	}
+}
+
+func TestDrainDeliveries(t *testing.T) {
+	tests := []struct {
+		name    string
+		batches [][2]int // due, handled
+		calls   int
+	}{
+		{name: "nothing due", batches: [][2]int{{0, 0}}, calls: 1},
+		{name: "full batches until a partial one", batches: [][2]int{{webhookBatchSize, webhookBatchSize}, {webhookBatchSize, 3}, {7, 7}}, calls: 3},
+		// the due rows are claimed by another instance, asking again would return them again
+		{name: "full batch handled elsewhere", batches: [][2]int{{webhookBatchSize, 0}, {webhookBatchSize, 0}}, calls: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			drainDeliveries(func() (int, int) {
+				if calls == len(tt.batches) {
+					t.Fatalf("batch %d requested after the last one", calls+1)
+				}
+				batch := tt.batches[calls]
+				calls++
+				return batch[0], batch[1]
+			})
+			if calls != tt.calls {
+				t.Errorf("delivered %d batches, want %d", calls, tt.calls)
+			}
+		})
+	}
+}
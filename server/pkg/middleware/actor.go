@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"Server/pkg/model"
+	"crypto/subtle"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const adminContextKey = "admin"
+
+// IdentifyActorMiddleware marks requests carrying the admin token so that routes
+// open to everyone still attribute admin actions correctly. It never rejects.
+func IdentifyActorMiddleware(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		bearer, found := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if token != "" && found && subtle.ConstantTimeCompare([]byte(bearer), []byte(token)) == 1 {
+			c.Set(adminContextKey, true)
+		}
+		c.Next()
+	}
+}
+
+// ActorFromContext returns who is performing the request: an authenticated
+// worker, the admin, or an anonymous client identified by its IP only
+func ActorFromContext(c *gin.Context) model.Actor {
+	ip := c.ClientIP()
+	if val, exists := c.Get("worker"); exists {
+		if worker, ok := val.(*model.Worker); ok {
+			return model.Actor{Type: model.ActorWorker, ID: worker.WorkerID, IP: ip}
+		}
+	}
+	if c.GetBool(adminContextKey) {
+		return model.Actor{Type: model.ActorAdmin, ID: model.ActorAdmin, IP: ip}
+	}
+	return model.Actor{Type: model.ActorAnonymous, IP: ip}
+}
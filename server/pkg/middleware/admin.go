@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminAuthMiddleware guards operator-only endpoints with a static bearer token.
+// An empty token disables the admin API entirely.
+func AdminAuthMiddleware(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Admin API is disabled, set PLATFORM_ADMIN_TOKEN to enable it"})
+			return
+		}
+
+		authHeader := c.GetHeader("Authorization")
+		bearer, found := strings.CutPrefix(authHeader, "Bearer ")
+		if !found {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authorization header format must be Bearer {AdminToken}"})
+			return
+		}
+
+		if subtle.ConstantTimeCompare([]byte(bearer), []byte(token)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid admin token"})
+			return
+		}
+
+		c.Set(adminContextKey, true)
+		c.Next()
+	}
+}
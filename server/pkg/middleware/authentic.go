@@ -34,16 +34,15 @@ func WorkerAuthMiddleware(db *gorm.DB, mgr *manager.WorkerManager) gin.HandlerFu
 		workerID := idAndKeyParts[0]
 		apiKey := idAndKeyParts[1]
 
-		var authenticatedWorker *model.Worker
-		db.First(&authenticatedWorker, "worker_id = ?", workerID)
-
-		if authenticatedWorker == nil {
+		var authenticatedWorker model.Worker
+		if err := db.First(&authenticatedWorker, "worker_id = ?", workerID).Error; err != nil {
 			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Invalid API Key"})
 			return
 		}
 
 		if !CheckAPIKeyHash(apiKey, authenticatedWorker.APIKey) {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid API Key"})
+			return
 		}
 
 		if !mgr.IsOnline(authenticatedWorker.WorkerID) {
@@ -51,7 +50,7 @@ func WorkerAuthMiddleware(db *gorm.DB, mgr *manager.WorkerManager) gin.HandlerFu
 			return
 		}
 
-		c.Set("worker", authenticatedWorker)
+		c.Set("worker", &authenticatedWorker)
 		c.Next()
 	}
 }
@@ -0,0 +1,317 @@
+package migrate
+
+// versioned schema migrations embedded in the server binary. Every migration is
+// a pair of migrations/NNNN_name.up.sql and NNNN_name.down.sql files; each one is
+// applied in its own transaction together with its schema_migrations row, under
+// an advisory lock so that several servers starting at once don't race.
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+//go:embed migrations/*.sql
+var files embed.FS
+
+// advisory lock key shared by all servers migrating the same database
+const lockKey = 0x6d696772617465
+
+var ErrSchemaTooNew = errors.New("database schema is newer than this server")
+
+type Migration struct {
+	Version int
+	Name    string
+	up      string
+	down    string
+}
+
+// State is one row of the migration status, Known is false for versions applied
+// by a newer server that this binary doesn't have
+type State struct {
+	Version   int
+	Name      string
+	Known     bool
+	AppliedAt *time.Time
+}
+
+type appliedMigration struct {
+	Version   int `gorm:"primaryKey;autoIncrement:false"`
+	Name      string
+	AppliedAt time.Time
+}
+
+func (appliedMigration) TableName() string {
+	return "schema_migrations"
+}
+
+type Migrator struct {
+	db         *gorm.DB
+	logger     *slog.Logger
+	migrations []Migration
+}
+
+func CreateMigrator(db *gorm.DB, logger *slog.Logger) (*Migrator, error) {
+	migrations, err := load(files)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{
+		db:         db,
+		logger:     logger.With("component", "migrate"),
+		migrations: migrations,
+	}, nil
+}
+
+// load collects the embedded migrations ordered by version; every version needs
+// both directions so that any applied migration can be rolled back
+func load(fsys fs.FS) ([]Migration, error) {
+	names, err := fs.Glob(fsys, "migrations/*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, name := range names {
+		base := path.Base(name)
+		stem, direction, ok := strings.Cut(strings.TrimSuffix(base, ".sql"), ".")
+		if !ok || (direction != "up" && direction != "down") {
+			return nil, fmt.Errorf("migration %s: expected NNNN_name.up.sql or NNNN_name.down.sql", base)
+		}
+		prefix, label, ok := strings.Cut(stem, "_")
+		if !ok {
+			return nil, fmt.Errorf("migration %s: missing name after version", base)
+		}
+		version, err := strconv.Atoi(prefix)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("migration %s: invalid version %q", base, prefix)
+		}
+
+		content, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+
+		m := byVersion[version]
+		if m == nil {
+			m = &Migration{Version: version, Name: label}
+			byVersion[version] = m
+		} else if m.Name != label {
+			return nil, fmt.Errorf("migration version %d used by %q and %q", version, m.Name, label)
+		}
+		if direction == "up" {
+			m.up = string(content)
+		} else {
+			m.down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.up == "" || m.down == "" {
+			return nil, fmt.Errorf("migration %04d_%s: both up and down files are required", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	slices.SortFunc(migrations, func(a, b Migration) int { return a.Version - b.Version })
+	return migrations, nil
+}
+
+// Latest is the highest version known to this binary
+func (m *Migrator) Latest() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+func (m *Migrator) find(version int) *Migration {
+	for i := range m.migrations {
+		if m.migrations[i].Version == version {
+			return &m.migrations[i]
+		}
+	}
+	return nil
+}
+
+// Status lists every known migration and every applied one, ordered by version
+func (m *Migrator) Status(ctx context.Context) ([]State, error) {
+	var applied []appliedMigration
+	err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		applied, err = m.applied(tx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	states := make([]State, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		states = append(states, State{Version: migration.Version, Name: migration.Name, Known: true})
+	}
+	for _, a := range applied {
+		i := slices.IndexFunc(states, func(s State) bool { return s.Version == a.Version })
+		if i < 0 {
+			states = append(states, State{Version: a.Version, Name: a.Name})
+			i = len(states) - 1
+		}
+		states[i].AppliedAt = &a.AppliedAt
+	}
+	slices.SortFunc(states, func(a, b State) int { return a.Version - b.Version })
+	return states, nil
+}
+
+// Check refuses databases migrated by a newer server, running against them
+// could write rows in a shape the newer schema no longer accepts
+func (m *Migrator) Check(ctx context.Context) error {
+	states, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+	return checkKnown(states)
+}
+
+func checkKnown(states []State) error {
+	var unknown []string
+	for _, s := range states {
+		if !s.Known {
+			unknown = append(unknown, fmt.Sprintf("%04d_%s", s.Version, s.Name))
+		}
+	}
+	if len(unknown) > 0 {
+		return fmt.Errorf("%w: unknown migrations %s applied", ErrSchemaTooNew, strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+// Up applies pending migrations up to and including target, a target of 0 means
+// the latest version. It returns the number of migrations applied.
+func (m *Migrator) Up(ctx context.Context, target int) (int, error) {
+	if target == 0 {
+		target = m.Latest()
+	}
+	if target > m.Latest() {
+		return 0, fmt.Errorf("unknown target version %d, latest is %d", target, m.Latest())
+	}
+
+	count := 0
+	for {
+		var next *Migration
+		err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			applied, err := m.locked(tx)
+			if err != nil {
+				return err
+			}
+
+			next = nil
+			for i := range m.migrations {
+				migration := &m.migrations[i]
+				if migration.Version > target {
+					break
+				}
+				if !slices.ContainsFunc(applied, func(a appliedMigration) bool { return a.Version == migration.Version }) {
+					next = migration
+					break
+				}
+			}
+			if next == nil {
+				return nil
+			}
+
+			if err := tx.Exec(next.up).Error; err != nil {
+				return fmt.Errorf("migration %04d_%s up: %w", next.Version, next.Name, err)
+			}
+			row := appliedMigration{Version: next.Version, Name: next.Name, AppliedAt: time.Now().UTC()}
+			return tx.Create(&row).Error
+		})
+		if err != nil {
+			return count, err
+		}
+		if next == nil {
+			return count, nil
+		}
+		m.logger.Info("applied migration", "version", next.Version, "name", next.Name)
+		count++
+	}
+}
+
+// Down rolls back the given number of most recently applied migrations and
+// returns how many were rolled back
+func (m *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	count := 0
+	for count < steps {
+		var last *Migration
+		err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			applied, err := m.locked(tx)
+			if err != nil {
+				return err
+			}
+
+			last = nil
+			if len(applied) == 0 {
+				return nil
+			}
+			last = m.find(applied[len(applied)-1].Version)
+
+			if err := tx.Exec(last.down).Error; err != nil {
+				return fmt.Errorf("migration %04d_%s down: %w", last.Version, last.Name, err)
+			}
+			return tx.Delete(&appliedMigration{Version: last.Version}).Error
+		})
+		if err != nil {
+			return count, err
+		}
+		if last == nil {
+			return count, nil
+		}
+		m.logger.Info("rolled back migration", "version", last.Version, "name", last.Name)
+		count++
+	}
+	return count, nil
+}
+
+// locked takes the migration lock for the rest of the transaction and returns
+// the applied migrations, refusing to touch a schema newer than this binary
+func (m *Migrator) locked(tx *gorm.DB) ([]appliedMigration, error) {
+	if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", lockKey).Error; err != nil {
+		return nil, fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	applied, err := m.applied(tx)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range applied {
+		if m.find(a.Version) == nil {
+			return nil, fmt.Errorf("%w: unknown migration %04d_%s applied", ErrSchemaTooNew, a.Version, a.Name)
+		}
+	}
+	return applied, nil
+}
+
+func (m *Migrator) applied(tx *gorm.DB) ([]appliedMigration, error) {
+	err := tx.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+    version    bigint PRIMARY KEY,
+    name       text NOT NULL,
+    applied_at timestamptz NOT NULL
+)`).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	var applied []appliedMigration
+	if err := tx.Order("version").Find(&applied).Error; err != nil {
+		return nil, err
+	}
+	return applied, nil
+}
@@ -0,0 +1,212 @@
+package migrate
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestLoad(t *testing.T) {
+	migrations, err := load(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			t.Errorf("migration %04d_%s: expected version %d, versions must be contiguous", m.Version, m.Name, i+1)
+		}
+	}
+}
+
+// testDB opens a fresh schema of the database named by PLATFORM_TEST_DSN, a
+// keyword/value DSN such as "host=localhost user=admin password=... dbname=test"
+func testDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv("PLATFORM_TEST_DSN")
+	if dsn == "" {
+		t.Skip("PLATFORM_TEST_DSN is not set")
+	}
+	config := &gorm.Config{Logger: logger.Discard}
+
+	admin, err := gorm.Open(postgres.Open(dsn), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema := "migrate_test_" + strings.ReplaceAll(uuid.NewString(), "-", "")
+	if err := admin.Exec("CREATE SCHEMA " + schema).Error; err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		admin.Exec("DROP SCHEMA " + schema + " CASCADE")
+		if sqlDB, err := admin.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	db, err := gorm.Open(postgres.Open(dsn+" search_path="+schema), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+func testMigrator(t *testing.T, db *gorm.DB) *Migrator {
+	t.Helper()
+	m, err := CreateMigrator(db, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func columnType(t *testing.T, db *gorm.DB, table, column string) string {
+	t.Helper()
+	var dataType string
+	err := db.Raw(`SELECT data_type FROM information_schema.columns
+WHERE table_schema = current_schema() AND table_name = ? AND column_name = ?`, table, column).Scan(&dataType).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dataType
+}
+
+// taskColumns are the columns of the tasks table at the latest version
+var taskColumns = map[string]string{
+	"priority":       "smallint",
+	"revision":       "bigint",
+	"campaign":       "text",
+	"schedule_id":    "uuid",
+	"result":         "jsonb",
+	"failure":        "jsonb",
+	"console_report": "jsonb",
+	"patch_summary":  "jsonb",
+}
+
+func checkSchema(t *testing.T, db *gorm.DB) {
+	t.Helper()
+	for column, want := range taskColumns {
+		if got := columnType(t, db, "tasks", column); got != want {
+			t.Errorf("tasks.%s has type %q, want %q", column, got, want)
+		}
+	}
+	for _, table := range []string{"workers", "schedules", "webhooks", "webhook_deliveries", "audit_entries", "task_events", "task_builds"} {
+		if !db.Migrator().HasTable(table) {
+			t.Errorf("table %s is missing", table)
+		}
+	}
+
+	err := db.Exec(`INSERT INTO audit_entries (id, actor_type, action, resource_type, created_at)
+VALUES (?, 'system', 'task.create', 'task', now())`, uuid.New()).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, statement := range []string{"UPDATE audit_entries SET action = 'changed'", "DELETE FROM audit_entries", "TRUNCATE audit_entries"} {
+		if err := db.Exec(statement).Error; err == nil {
+			t.Errorf("%s succeeded on the append-only audit log", statement)
+		}
+	}
+}
+
+func TestUpDownEmpty(t *testing.T) {
+	db := testDB(t)
+	m := testMigrator(t, db)
+	ctx := context.Background()
+
+	applied, err := m.Up(ctx, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if applied != m.Latest() {
+		t.Errorf("applied %d migrations, want %d", applied, m.Latest())
+	}
+	checkSchema(t, db)
+
+	// every down migration undoes its up migration
+	if _, err := m.Down(ctx, m.Latest()); err != nil {
+		t.Fatal(err)
+	}
+	for _, table := range []string{"tasks", "workers", "schedules", "audit_entries"} {
+		if db.Migrator().HasTable(table) {
+			t.Errorf("table %s is left after rolling back all migrations", table)
+		}
+	}
+	if _, err := m.Up(ctx, 0); err != nil {
+		t.Fatalf("migrating up again: %v", err)
+	}
+}
+
+// baselineTask and baselineWorker are the models of the last server that created
+// its schema with AutoMigrate
+type baselineTask struct {
+	ID           uuid.UUID `gorm:"type:uuid;primary_key;"`
+	Type         string
+	Status       string
+	Payload      string `gorm:"type:jsonb"`
+	WorkerID     string `gorm:"index"`
+	Result       string
+	ArtifactPath string
+	ArtifactName string
+	CreatedAt    time.Time
+	StartedAt    *time.Time
+	FinishedAt   *time.Time
+}
+
+func (baselineTask) TableName() string { return "tasks" }
+
+type baselineWorker struct {
+	gorm.Model
+
+	WorkerID string `gorm:"uniqueIndex;not null"`
+	APIKey   string `gorm:"not null"`
+	Hostname string
+	Status   string `gorm:"default:'offline';not null"`
+	LastSeen *time.Time
+}
+
+func (baselineWorker) TableName() string { return "workers" }
+
+func TestUpFromBaseline(t *testing.T) {
+	db := testDB(t)
+	if err := db.AutoMigrate(&baselineTask{}, &baselineWorker{}); err != nil {
+		t.Fatal(err)
+	}
+	taskID := uuid.New()
+	err := db.Exec(`INSERT INTO tasks (id, type, status, payload, result, created_at)
+VALUES (?, 'kernel-build', 'success', '{"id": "bug"}', 'vmcore captured', now())`, taskID).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := testMigrator(t, db)
+	if _, err := m.Up(context.Background(), 0); err != nil {
+		t.Fatal(err)
+	}
+	checkSchema(t, db)
+
+	var row struct {
+		Priority int
+		Revision int
+		Message  string
+	}
+	err = db.Raw("SELECT priority, revision, result->>'message' AS message FROM tasks WHERE id = ?", taskID).Scan(&row).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	if row.Priority != 0 || row.Revision != 0 || row.Message != "vmcore captured" {
+		t.Errorf("existing task migrated to %+v", row)
+	}
+}
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS workers;
+DROP TABLE IF EXISTS tasks;
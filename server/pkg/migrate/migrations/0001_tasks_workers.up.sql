@@ -0,0 +1,46 @@
+-- baseline of the schema previously created by AutoMigrate; IF NOT EXISTS lets
+-- databases created by older servers adopt the versioned migrations in place
+
+CREATE TABLE IF NOT EXISTS tasks (
+    id            uuid PRIMARY KEY,
+    type          text,
+    status        text,
+    priority      smallint NOT NULL DEFAULT 0,
+    revision      bigint NOT NULL DEFAULT 0,
+    campaign      text,
+    schedule_id   uuid,
+    payload       jsonb,
+    worker_id     text,
+    result        text,
+    artifact_path text,
+    artifact_name text,
+    created_at    timestamptz,
+    started_at    timestamptz,
+    finished_at   timestamptz
+);
+
+-- columns the tasks table of older servers lacks, CREATE TABLE IF NOT EXISTS leaves it as is
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS priority smallint NOT NULL DEFAULT 0;
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS revision bigint NOT NULL DEFAULT 0;
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS campaign text;
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS schedule_id uuid;
+
+CREATE INDEX IF NOT EXISTS idx_tasks_priority ON tasks (priority);
+CREATE INDEX IF NOT EXISTS idx_tasks_campaign ON tasks (campaign);
+CREATE INDEX IF NOT EXISTS idx_tasks_schedule_id ON tasks (schedule_id);
+CREATE INDEX IF NOT EXISTS idx_tasks_worker_id ON tasks (worker_id);
+
+CREATE TABLE IF NOT EXISTS workers (
+    id         bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    worker_id  text NOT NULL,
+    api_key    text NOT NULL,
+    hostname   text,
+    status     text NOT NULL DEFAULT 'offline',
+    last_seen  timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS idx_workers_deleted_at ON workers (deleted_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_workers_worker_id ON workers (worker_id);
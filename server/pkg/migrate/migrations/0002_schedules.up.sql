@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS schedules (
+    id              uuid PRIMARY KEY,
+    name            text NOT NULL,
+    cron            text NOT NULL,
+    enabled         boolean NOT NULL,
+    priority        smallint NOT NULL DEFAULT 0,
+    subsystem       text,
+    campaign        text,
+    bug_status      text,
+    kernel_commit   text,
+    last_run_at     timestamptz,
+    last_run_status text,
+    last_run_tasks  bigint,
+    next_run_at     timestamptz,
+    created_at      timestamptz,
+    updated_at      timestamptz
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_schedules_name ON schedules (name);
+CREATE INDEX IF NOT EXISTS idx_schedules_next_run_at ON schedules (next_run_at);
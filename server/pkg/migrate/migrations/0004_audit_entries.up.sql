@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS audit_entries (
+    id            uuid PRIMARY KEY,
+    actor_type    text NOT NULL,
+    actor_id      text,
+    ip            text,
+    action        text NOT NULL,
+    resource_type text NOT NULL,
+    resource_id   text,
+    before        jsonb,
+    after         jsonb,
+    diff          jsonb,
+    created_at    timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_entries_actor_type ON audit_entries (actor_type);
+CREATE INDEX IF NOT EXISTS idx_audit_entries_actor_id ON audit_entries (actor_id);
+CREATE INDEX IF NOT EXISTS idx_audit_entries_action ON audit_entries (action);
+CREATE INDEX IF NOT EXISTS idx_audit_entries_resource_id ON audit_entries (resource_id);
+CREATE INDEX IF NOT EXISTS idx_audit_entries_created_at ON audit_entries (created_at);
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS task_events (
+    id          uuid PRIMARY KEY,
+    task_id     uuid NOT NULL REFERENCES tasks (id) ON DELETE CASCADE,
+    kind        text NOT NULL,
+    from_status text,
+    to_status   text,
+    step        text,
+    duration_ms bigint,
+    error       text,
+    source      text,
+    occurred_at timestamptz NOT NULL,
+    created_at  timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_events_task_id ON task_events (task_id);
+CREATE INDEX IF NOT EXISTS idx_task_events_occurred_at ON task_events (occurred_at);
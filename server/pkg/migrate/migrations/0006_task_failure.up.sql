@@ -0,0 +1,3 @@
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS failure jsonb;
+
+CREATE INDEX IF NOT EXISTS idx_tasks_failure_category ON tasks ((failure->>'category'));
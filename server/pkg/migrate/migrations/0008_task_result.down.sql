@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_tasks_result_verdict;
+
+ALTER TABLE tasks ALTER COLUMN result TYPE text USING result->>'message';
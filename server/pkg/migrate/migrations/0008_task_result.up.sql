@@ -0,0 +1,4 @@
+ALTER TABLE tasks ALTER COLUMN result TYPE jsonb
+    USING CASE WHEN result IS NULL OR result = '' THEN NULL ELSE jsonb_build_object('message', result) END;
+
+CREATE INDEX IF NOT EXISTS idx_tasks_result_verdict ON tasks ((result->>'verdict'));
@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS task_builds (
+    task_id       uuid PRIMARY KEY REFERENCES tasks (id) ON DELETE CASCADE,
+    kernel_config text,
+    diagnostics   jsonb NOT NULL DEFAULT '[]',
+    created_at    timestamptz
+);
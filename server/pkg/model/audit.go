@@ -0,0 +1,64 @@
+package model
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	ActorAdmin     = "admin"
+	ActorWorker    = "worker"
+	ActorSystem    = "system"
+	ActorAnonymous = "anonymous"
+)
+
+// Actor identifies who performed an audited action
+type Actor struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+	IP   string `json:"ip"`
+}
+
+const (
+	AuditTaskCreate         = "task.create"
+	AuditTaskDelete         = "task.delete"
+	AuditTaskCancel         = "task.cancel"
+	AuditTaskAccept         = "task.accept"
+	AuditTaskStatusUpdate   = "task.status_update"
+	AuditTaskPriorityUpdate = "task.priority_update"
+	AuditWorkerRegister     = "worker.register"
+	AuditWorkerUnregister   = "worker.unregister"
+	AuditWorkerKeyRotate    = "worker.key_rotate"
+	AuditArtifactUpload     = "artifact.upload"
+	AuditArtifactDownload   = "artifact.download"
+)
+
+var ErrAuditImmutable = errors.New("audit entries are append-only")
+
+// AuditEntry is one record of the append-only audit log. Creations keep the new
+// state in After, deletions the removed state in Before, and updates only the
+// changed fields in Diff as {"field": {"from": ..., "to": ...}}.
+type AuditEntry struct {
+	ID           uuid.UUID `json:"id" gorm:"type:uuid;primary_key;"`
+	ActorType    string    `json:"actor_type" gorm:"index;not null"`
+	ActorID      string    `json:"actor_id" gorm:"index"`
+	IP           string    `json:"ip"`
+	Action       string    `json:"action" gorm:"index;not null"`
+	ResourceType string    `json:"resource_type" gorm:"not null"`
+	ResourceID   string    `json:"resource_id" gorm:"index"`
+	Before       RawJSON   `json:"before" gorm:"type:jsonb"`
+	After        RawJSON   `json:"after" gorm:"type:jsonb"`
+	Diff         RawJSON   `json:"diff" gorm:"type:jsonb"`
+	CreatedAt    time.Time `json:"created_at" gorm:"index"`
+}
+
+func (e *AuditEntry) BeforeUpdate(*gorm.DB) error {
+	return ErrAuditImmutable
+}
+
+func (e *AuditEntry) BeforeDelete(*gorm.DB) error {
+	return ErrAuditImmutable
+}
@@ -0,0 +1,43 @@
+package model
+
+// BisectRange is the commit range of a bisect task: the reproducer must not
+// crash the kernel at Good and must crash it at Bad
+type BisectRange struct {
+	Good string `json:"good"`
+	Bad  string `json:"bad"`
+}
+
+type BisectVerdict string
+
+// verdicts of a bisect step, named after the git bisect subcommands
+const (
+	BisectGood BisectVerdict = "good"
+	BisectBad  BisectVerdict = "bad"
+	BisectSkip BisectVerdict = "skip"
+)
+
+// BisectStep is one commit tested by a bisect task. Any kernel report counts
+// as bad; Reproduction tells whether it was the crash of the report. Reason
+// says why a skipped commit could not be built or booted.
+type BisectStep struct {
+	Commit         string         `json:"commit"`
+	Verdict        BisectVerdict  `json:"verdict"`
+	Reason         string         `json:"reason,omitempty"`
+	Crashed        bool           `json:"crashed"`
+	VmcoreCaptured bool           `json:"vmcore_captured"`
+	Console        *ConsoleReport `json:"console,omitempty"`
+	CrashTitle     string         `json:"crash_title,omitempty"`
+	Reproduction   Verdict        `json:"reproduction,omitempty"`
+}
+
+// BisectResult is the outcome of a bisect task. The first two steps test the
+// bad and the good commit. Culprit is the first bad commit; when skipped
+// commits leave git unable to tell, Suspects lists every commit it could be.
+type BisectResult struct {
+	Good         string       `json:"good"`
+	Bad          string       `json:"bad"`
+	Steps        []BisectStep `json:"steps"`
+	Culprit      string       `json:"culprit,omitempty"`
+	CulpritTitle string       `json:"culprit_title,omitempty"`
+	Suspects     []string     `json:"suspects,omitempty"`
+}
@@ -0,0 +1,17 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TaskBuild is what the worker observed while running a task: the effective
+// kernel .config and the warning and error lines of the output. It's kept apart
+// from the task since the config alone is a few hundred kilobytes.
+type TaskBuild struct {
+	TaskID       uuid.UUID  `json:"task_id" gorm:"type:uuid;primary_key;"`
+	KernelConfig string     `json:"kernel_config"`
+	Diagnostics  StringList `json:"diagnostics" gorm:"type:jsonb"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
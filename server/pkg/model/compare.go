@@ -0,0 +1,76 @@
+package model
+
+import "github.com/google/uuid"
+
+// TaskSummary identifies one side of a comparison
+type TaskSummary struct {
+	ID         uuid.UUID  `json:"id"`
+	Type       TaskType   `json:"type"`
+	Status     TaskStatus `json:"status"`
+	Campaign   string     `json:"campaign"`
+	BugID      string     `json:"bug_id"`
+	Title      string     `json:"title"`
+	Commit     string     `json:"commit"`
+	Compiler   string     `json:"compiler"`
+	Verdict    Verdict    `json:"verdict"`
+	CrashTitle string     `json:"crash_title"`
+}
+
+// ValueDiff is a value that differs between the two tasks, empty when unset
+type ValueDiff struct {
+	Name string `json:"name"`
+	A    string `json:"a"`
+	B    string `json:"b"`
+}
+
+// StepDelta compares the duration of a workflow step, DeltaMs is B minus A
+type StepDelta struct {
+	Step    string `json:"step"`
+	AMs     *int64 `json:"a_ms"`
+	BMs     *int64 `json:"b_ms"`
+	DeltaMs *int64 `json:"delta_ms"`
+	AError  string `json:"a_error,omitempty"`
+	BError  string `json:"b_error,omitempty"`
+}
+
+// StackDiff compares the functions of two stacks
+type StackDiff struct {
+	Same  bool     `json:"same"`
+	OnlyA []string `json:"only_a"`
+	OnlyB []string `json:"only_b"`
+}
+
+type CrashDiff struct {
+	ATitle     string    `json:"a_title"`
+	BTitle     string    `json:"b_title"`
+	SameTitle  bool      `json:"same_title"`
+	Frames     StackDiff `json:"frames"`
+	AllocStack StackDiff `json:"alloc_stack"`
+	FreeStack  StackDiff `json:"free_stack"`
+}
+
+// LineCount is a normalized warning or error line and how often it occurred
+type LineCount struct {
+	Line  string `json:"line"`
+	Count int    `json:"count"`
+}
+
+type LogDiff struct {
+	OnlyA  []LineCount `json:"only_a"`
+	OnlyB  []LineCount `json:"only_b"`
+	Common int         `json:"common"`
+}
+
+// TaskComparison is the side-by-side comparison of two task runs. Crash is nil
+// when neither guest crashed; Notes lists the data missing for either task.
+type TaskComparison struct {
+	A           TaskSummary `json:"a"`
+	B           TaskSummary `json:"b"`
+	Config      []ValueDiff `json:"config"`
+	Toolchain   []ValueDiff `json:"toolchain"`
+	Steps       []StepDelta `json:"steps"`
+	SameVerdict bool        `json:"same_verdict"`
+	Crash       *CrashDiff  `json:"crash"`
+	Log         LogDiff     `json:"log"`
+	Notes       []string    `json:"notes"`
+}
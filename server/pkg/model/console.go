@@ -0,0 +1,92 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"slices"
+)
+
+type ReportKind string
+
+// kinds of kernel reports recognized in the guest serial console
+const (
+	ReportKASAN      ReportKind = "kasan"
+	ReportKCSAN      ReportKind = "kcsan"
+	ReportKMSAN      ReportKind = "kmsan"
+	ReportUBSAN      ReportKind = "ubsan"
+	ReportBUG        ReportKind = "bug"
+	ReportWARNING    ReportKind = "warning"
+	ReportGPF        ReportKind = "gpf"
+	ReportLockdep    ReportKind = "lockdep"
+	ReportHungTask   ReportKind = "hung_task"
+	ReportRCUStall   ReportKind = "rcu_stall"
+	ReportSoftLockup ReportKind = "soft_lockup"
+	ReportPanic      ReportKind = "panic"
+)
+
+var ReportKinds = []ReportKind{
+	ReportKASAN,
+	ReportKCSAN,
+	ReportKMSAN,
+	ReportUBSAN,
+	ReportBUG,
+	ReportWARNING,
+	ReportGPF,
+	ReportLockdep,
+	ReportHungTask,
+	ReportRCUStall,
+	ReportSoftLockup,
+	ReportPanic,
+}
+
+func (k ReportKind) Valid() bool {
+	return slices.Contains(ReportKinds, k)
+}
+
+// StackFrame is one symbolized frame such as "kfree+0x8b/0x250 [ext4]"; File and
+// Line are only known for inlined frames or kernels with symbolized traces
+type StackFrame struct {
+	Function string `json:"function"`
+	Offset   uint64 `json:"offset"`
+	Size     uint64 `json:"size"`
+	Module   string `json:"module,omitempty"`
+	File     string `json:"file,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Inline   bool   `json:"inline,omitempty"`
+}
+
+// ConsoleReport is the first kernel report found in the serial console of the
+// reproducer VM. Access, Address and Size describe the bad access of memory
+// sanitizer reports, AllocStack and FreeStack are set for use-after-free style
+// reports. OtherTitles lists the reports that followed the first one.
+type ConsoleReport struct {
+	Kind        ReportKind   `json:"kind"`
+	Title       string       `json:"title"`
+	Access      string       `json:"access,omitempty"`
+	Address     string       `json:"address,omitempty"`
+	Size        int          `json:"size,omitempty"`
+	Frames      []StackFrame `json:"frames"`
+	AllocStack  []StackFrame `json:"alloc_stack,omitempty"`
+	FreeStack   []StackFrame `json:"free_stack,omitempty"`
+	Text        string       `json:"text"`
+	OtherTitles []string     `json:"other_titles,omitempty"`
+}
+
+func (r *ConsoleReport) Scan(value any) error {
+	bytes, ok := value.([]byte)
+	if !ok {
+		return fmt.Errorf("type assertion to []byte failed, got %T instead", value)
+	}
+	if bytes == nil {
+		return nil
+	}
+	return json.Unmarshal(bytes, r)
+}
+
+func (r *ConsoleReport) Value() (driver.Value, error) {
+	if r == nil {
+		return nil, nil
+	}
+	return json.Marshal(r)
+}
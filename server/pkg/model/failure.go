@@ -0,0 +1,79 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"slices"
+)
+
+type FailureCategory string
+
+// failure categories assigned by the worker from the failed workflow step and
+// the task output
+const (
+	FailureDownload       FailureCategory = "download_failed"
+	FailureConfig         FailureCategory = "config_error"
+	FailureCompiler       FailureCategory = "compiler_error"
+	FailureToolchain      FailureCategory = "missing_toolchain"
+	FailureHeadersInstall FailureCategory = "headers_install_failed"
+	FailureVMBootTimeout  FailureCategory = "vm_boot_timeout"
+	FailureKdumpNotLoaded FailureCategory = "kdump_not_loaded"
+	FailureNoVmcore       FailureCategory = "no_vmcore"
+	FailureArtifactUpload FailureCategory = "artifact_upload_failed"
+	FailurePatchApply     FailureCategory = "patch_apply_failed"
+	FailureUnknown        FailureCategory = "unknown"
+)
+
+var FailureCategories = []FailureCategory{
+	FailureDownload,
+	FailureConfig,
+	FailureCompiler,
+	FailureToolchain,
+	FailureHeadersInstall,
+	FailureVMBootTimeout,
+	FailureKdumpNotLoaded,
+	FailureNoVmcore,
+	FailureArtifactUpload,
+	FailurePatchApply,
+	FailureUnknown,
+}
+
+func (c FailureCategory) Valid() bool {
+	return slices.Contains(FailureCategories, c)
+}
+
+// TaskFailure explains why a task failed. File and Line are set for compiler
+// errors, Message holds the most specific line found in the output.
+type TaskFailure struct {
+	Category FailureCategory `json:"category"`
+	Step     string          `json:"step"`
+	Message  string          `json:"message"`
+	File     string          `json:"file,omitempty"`
+	Line     int             `json:"line,omitempty"`
+}
+
+func (f *TaskFailure) Scan(value any) error {
+	bytes, ok := value.([]byte)
+	if !ok {
+		return fmt.Errorf("type assertion to []byte failed, got %T instead", value)
+	}
+	if bytes == nil {
+		return nil
+	}
+	return json.Unmarshal(bytes, f)
+}
+
+func (f *TaskFailure) Value() (driver.Value, error) {
+	if f == nil {
+		return nil, nil
+	}
+	return json.Marshal(f)
+}
+
+// FailureStat is the number of failed tasks of one category within a campaign
+type FailureStat struct {
+	Campaign string          `json:"campaign"`
+	Category FailureCategory `json:"category"`
+	Count    int64           `json:"count"`
+}
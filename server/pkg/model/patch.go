@@ -0,0 +1,73 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+type PatchFileStatus string
+
+const (
+	PatchFileModified PatchFileStatus = "modified"
+	PatchFileAdded    PatchFileStatus = "added"
+	PatchFileDeleted  PatchFileStatus = "deleted"
+	PatchFileRenamed  PatchFileStatus = "renamed"
+)
+
+// PatchFile is one file touched by a patch. OldPath is only set for renames.
+type PatchFile struct {
+	Path      string          `json:"path"`
+	OldPath   string          `json:"old_path,omitempty"`
+	Status    PatchFileStatus `json:"status"`
+	Hunks     int             `json:"hunks"`
+	Additions int             `json:"additions"`
+	Deletions int             `json:"deletions"`
+}
+
+// PatchSummary describes the patch of a patch-apply task as parsed by the
+// server when the task was created. For a series the files and counts add up
+// all patches and Series holds the summary of each one.
+type PatchSummary struct {
+	Subject   string         `json:"subject,omitempty"`
+	Files     []PatchFile    `json:"files"`
+	Hunks     int            `json:"hunks"`
+	Additions int            `json:"additions"`
+	Deletions int            `json:"deletions"`
+	Series    []PatchSummary `json:"series,omitempty"`
+}
+
+type PatchApplyStatus string
+
+const (
+	PatchApplied PatchApplyStatus = "applied"
+	PatchFailed  PatchApplyStatus = "failed"
+	PatchSkipped PatchApplyStatus = "skipped" // after the first failed patch
+)
+
+// PatchApplyResult tells how one patch of a series applied on the worker;
+// Index counts from 1
+type PatchApplyResult struct {
+	Index   int              `json:"index"`
+	Subject string           `json:"subject"`
+	Status  PatchApplyStatus `json:"status"`
+	Error   string           `json:"error,omitempty"`
+}
+
+func (s *PatchSummary) Scan(value any) error {
+	bytes, ok := value.([]byte)
+	if !ok {
+		return fmt.Errorf("type assertion to []byte failed, got %T instead", value)
+	}
+	if bytes == nil {
+		return nil
+	}
+	return json.Unmarshal(bytes, s)
+}
+
+func (s *PatchSummary) Value() (driver.Value, error) {
+	if s == nil {
+		return nil, nil
+	}
+	return json.Marshal(s)
+}
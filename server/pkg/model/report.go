@@ -29,18 +29,38 @@ type Crash struct {
 }
 
 type CrashReport struct {
-	Version           int         `json:"version"`
-	Title             string      `json:"title"`
-	DisplayTitle      string      `json:"display-title"`
-	ID                string      `json:"id"`
-	Status            string      `json:"status"`
-	FixCommits        []FixCommit `json:"fix-commits"`
-	Discussions       []string    `json:"discussions"`
-	Crashes           []Crash     `json:"crashes"`
-	Subsystems        []string    `json:"subsystems"`
-	ParentOfFixCommit string      `json:"parent_of_fix_commit"`
-	Patch             string      `json:"patch"`
-	PatchModified     []string    `json:"patch_modified_files"`
+	Version           int           `json:"version"`
+	Title             string        `json:"title"`
+	DisplayTitle      string        `json:"display-title"`
+	ID                string        `json:"id"`
+	Status            string        `json:"status"`
+	FixCommits        []FixCommit   `json:"fix-commits"`
+	Discussions       []string      `json:"discussions"`
+	Crashes           []Crash       `json:"crashes"`
+	Subsystems        []string      `json:"subsystems"`
+	ParentOfFixCommit string        `json:"parent_of_fix_commit"`
+	Patch             string        `json:"patch"`
+	PatchModified     []string      `json:"patch_modified_files"`
+	Patches           []SeriesPatch `json:"patches,omitempty"` // the series Patch was assembled from, in order
+	Bisect            *BisectRange  `json:"bisect,omitempty"`
+}
+
+// SeriesPatch is one patch of a series with the subject of its mail, or the
+// name of the uploaded file when it has no mail headers
+type SeriesPatch struct {
+	Subject string `json:"subject"`
+	Diff    string `json:"diff"`
+}
+
+// FixCommit is the hash of the first fix commit that has one, or "" when the
+// bug has no known fix
+func (cr *CrashReport) FixCommit() string {
+	for _, fix := range cr.FixCommits {
+		if fix.Hash != "" {
+			return fix.Hash
+		}
+	}
+	return ""
 }
 
 func (cr *CrashReport) Scan(value any) error {
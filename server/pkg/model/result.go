@@ -0,0 +1,111 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+type Verdict string
+
+// verdicts of a task that ran the reproducer, comparing the crash in the VM
+// with the title of the syzbot report
+const (
+	VerdictReproduced     Verdict = "reproduced"
+	VerdictDifferentCrash Verdict = "different_crash"
+	VerdictNotReproduced  Verdict = "not_reproduced"
+)
+
+// TaskResult is the outcome of a task. Crashed and VmcoreCaptured are reported
+// by the worker; CrashTitle, ExpectedTitle and Verdict are filled in by the
+// server from the console report and the task payload. Verdict is empty when
+// the task failed before the guest could crash. CrashGone is only set for
+// patch-apply tasks that could tell whether the patch fixed the crash, Patches
+// by the worker for each patch of the series it tried to apply. FixVerify and
+// Bisect are only set for fix-verify and bisect tasks. Runs holds each run of
+// the reproducer when the worker ran it more than once or recorded the runs,
+// and CrashRate the share of runs that crashed among those that ran.
+type TaskResult struct {
+	Message        string             `json:"message"`
+	Crashed        bool               `json:"crashed"`
+	VmcoreCaptured bool               `json:"vmcore_captured"`
+	CrashTitle     string             `json:"crash_title,omitempty"`
+	ExpectedTitle  string             `json:"expected_title,omitempty"`
+	Verdict        Verdict            `json:"verdict,omitempty"`
+	CrashGone      *bool              `json:"crash_gone,omitempty"`
+	Patches        []PatchApplyResult `json:"patches,omitempty"`
+	FixVerify      *FixVerification   `json:"fix_verify,omitempty"`
+	Bisect         *BisectResult      `json:"bisect,omitempty"`
+	Runs           []ReproRun         `json:"runs,omitempty"`
+	CrashRate      *float64           `json:"crash_rate,omitempty"`
+	Accel          string             `json:"accel,omitempty"`
+}
+
+// ReproRun is one run of the reproducer in a freshly booted VM. Error is set
+// when the VM could not boot or the reproducer could not be started, and the
+// run then tells nothing. Only the first run that crashed has its vmcore
+// captured. CrashTitle and Verdict are filled in by the server.
+type ReproRun struct {
+	Index          int            `json:"index"`
+	Crashed        bool           `json:"crashed"`
+	VmcoreCaptured bool           `json:"vmcore_captured"`
+	DurationMs     int64          `json:"duration_ms"`
+	Error          string         `json:"error,omitempty"`
+	Console        *ConsoleReport `json:"console,omitempty"`
+	CrashTitle     string         `json:"crash_title,omitempty"`
+	Verdict        Verdict        `json:"verdict,omitempty"`
+}
+
+// FixVerification is the outcome of a fix-verify task, which runs the
+// reproducer on the parent of the fix commit and on the fix commit itself. A run
+// is nil when the task failed before the reproducer ran at that commit. Fixed is
+// only set when the crash reproduced at the parent and the fix run tells whether
+// it is gone.
+type FixVerification struct {
+	ParentCommit string  `json:"parent_commit"`
+	FixCommit    string  `json:"fix_commit"`
+	Parent       *FixRun `json:"parent,omitempty"`
+	Fix          *FixRun `json:"fix,omitempty"`
+	Fixed        *bool   `json:"fixed,omitempty"`
+}
+
+// FixRun is the reproducer run of a fix-verify task at one commit. Crashed,
+// VmcoreCaptured and Console are reported by the worker, CrashTitle and Verdict
+// are filled in by the server as for the task itself.
+type FixRun struct {
+	Crashed        bool           `json:"crashed"`
+	VmcoreCaptured bool           `json:"vmcore_captured"`
+	Console        *ConsoleReport `json:"console,omitempty"`
+	CrashTitle     string         `json:"crash_title,omitempty"`
+	Verdict        Verdict        `json:"verdict,omitempty"`
+}
+
+// UnmarshalJSON also accepts the plain string results of older workers
+func (r *TaskResult) UnmarshalJSON(data []byte) error {
+	var message string
+	if err := json.Unmarshal(data, &message); err == nil {
+		*r = TaskResult{Message: message}
+		return nil
+	}
+
+	type plain TaskResult
+	return json.Unmarshal(data, (*plain)(r))
+}
+
+func (r *TaskResult) Scan(value any) error {
+	bytes, ok := value.([]byte)
+	if !ok {
+		return fmt.Errorf("type assertion to []byte failed, got %T instead", value)
+	}
+	if bytes == nil {
+		return nil
+	}
+	return json.Unmarshal(bytes, r)
+}
+
+func (r *TaskResult) Value() (driver.Value, error) {
+	if r == nil {
+		return nil, nil
+	}
+	return json.Marshal(r)
+}
@@ -0,0 +1,51 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Schedule periodically materializes tasks from previously submitted reports.
+// The selector fields narrow down which bugs are rebuilt: every bug whose latest
+// kernel-build task matches all non-empty selectors gets a new task per run.
+type Schedule struct {
+	ID       uuid.UUID `json:"id" gorm:"type:uuid;primary_key;"`
+	Name     string    `json:"name" gorm:"uniqueIndex;not null"`
+	Cron     string    `json:"cron" gorm:"not null"`
+	Enabled  bool      `json:"enabled" gorm:"not null"`
+	Priority uint8     `json:"priority" gorm:"not null;default:0"`
+
+	// selectors
+	Subsystem string `json:"subsystem"`  // e.g. "net", matched against CrashReport.Subsystems
+	Campaign  string `json:"campaign"`   // rerun every bug of an existing campaign
+	BugStatus string `json:"bug_status"` // e.g. "open", matched against CrashReport.Status
+
+	// optional kernel commit the materialized tasks are built at instead of the reported one,
+	// KernelCommitLatest resolves the head of the upstream tree at every run
+	KernelCommit string `json:"kernel_commit"`
+
+	LastRunAt     *time.Time `json:"last_run_at"`
+	LastRunStatus string     `json:"last_run_status"`
+	LastRunTasks  int        `json:"last_run_tasks"`
+	NextRunAt     *time.Time `json:"next_run_at" gorm:"index"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// KernelCommitLatest as Schedule.KernelCommit rebuilds every bug at the current upstream head
+const KernelCommitLatest = "latest"
+
+const (
+	ScheduleRunSucceeded = "succeeded"
+	ScheduleRunSkipped   = "skipped"
+	ScheduleRunFailed    = "failed"
+)
+
+// TaskCampaign is the campaign label attached to tasks created by a schedule run
+func (s *Schedule) TaskCampaign() string {
+	if s.Campaign != "" {
+		return s.Campaign
+	}
+	return s.Name
+}
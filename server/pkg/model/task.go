@@ -11,36 +11,56 @@ type TaskType string
 const (
 	TaskTypeKernelBuild TaskType = "kernel-build"
 	TaskTypePatchApply  TaskType = "patch-apply"
+	TaskTypeFixVerify   TaskType = "fix-verify"
+	TaskTypeBisect      TaskType = "bisect"
+)
+
+// task priorities map directly onto RabbitMQ message priorities; the queue is
+// declared with x-max-priority = MaxTaskPriority
+const (
+	PriorityLow     uint8 = 0
+	PriorityNormal  uint8 = 5
+	PriorityUrgent  uint8 = 9
+	MaxTaskPriority uint8 = PriorityUrgent
 )
 
 type TaskStatus string
 
 const (
-	StatusPending TaskStatus = "pending"
-	StatusRunning TaskStatus = "running"
-	StatusSuccess TaskStatus = "success"
-	StatusFailed  TaskStatus = "failed"
+	StatusPending   TaskStatus = "pending"
+	StatusRunning   TaskStatus = "running"
+	StatusSuccess   TaskStatus = "success"
+	StatusFailed    TaskStatus = "failed"
+	StatusCancelled TaskStatus = "cancelled"
 )
 
 type Task struct {
-	ID           uuid.UUID   `json:"id" gorm:"type:uuid;primary_key;"`
-	Type         TaskType    `json:"type"`
-	Status       TaskStatus  `json:"status"`
-	Payload      CrashReport `json:"payload" gorm:"type:jsonb"`
-	WorkerID     string      `json:"worker_id" gorm:"index"`
-	Result       string      `json:"result"`
-	ArtifactPath string      `json:"artifact_path"`
-	ArtifactName string      `json:"artifact_name"`
-	CreatedAt    time.Time   `json:"created_at"`
-	StartedAt    *time.Time  `json:"started_at"`
-	FinishedAt   *time.Time  `json:"finished_at"`
+	ID           uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;"`
+	Type         TaskType       `json:"type"`
+	Status       TaskStatus     `json:"status"`
+	Priority     uint8          `json:"priority" gorm:"not null;default:0;index"`
+	Revision     int            `json:"revision" gorm:"not null;default:0"` // bumped on every re-dispatch, older queue messages become stale
+	Campaign     string         `json:"campaign" gorm:"index"`
+	ScheduleID   *uuid.UUID     `json:"schedule_id" gorm:"type:uuid;index"`
+	Payload      CrashReport    `json:"payload" gorm:"type:jsonb"`
+	PatchSummary *PatchSummary  `json:"patch_summary" gorm:"type:jsonb"` // parsed patch, patch-apply only
+	WorkerID     string         `json:"worker_id" gorm:"index"`
+	Result       *TaskResult    `json:"result" gorm:"type:jsonb"`
+	Failure      *TaskFailure   `json:"failure" gorm:"type:jsonb"`                              // set when the task failed
+	Console      *ConsoleReport `json:"console_report" gorm:"column:console_report;type:jsonb"` // kernel report found in the VM console
+	ArtifactPath string         `json:"artifact_path"`
+	ArtifactName string         `json:"artifact_name"`
+	CreatedAt    time.Time      `json:"created_at"`
+	StartedAt    *time.Time     `json:"started_at"`
+	FinishedAt   *time.Time     `json:"finished_at"`
 }
 
-func CreateTask(taskType TaskType, payload CrashReport) *Task {
+func CreateTask(taskType TaskType, payload CrashReport, priority uint8) *Task {
 	return &Task{
 		ID:        uuid.New(),
 		Type:      taskType,
 		Status:    StatusPending,
+		Priority:  priority,
 		Payload:   payload,
 		CreatedAt: time.Now().UTC(),
 	}
@@ -0,0 +1,74 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type TaskEventKind string
+
+const (
+	TaskEventStatus     TaskEventKind = "status"      // status transition recorded by the server
+	TaskEventStepStart  TaskEventKind = "step_start"  // workflow step reported by the worker
+	TaskEventStepFinish TaskEventKind = "step_finish" // carries the step duration and its error, if any
+)
+
+// TaskEvent is one entry of a task's timeline. Status events fill FromStatus and
+// ToStatus, step events fill Step and, when finished, DurationMs and Error.
+type TaskEvent struct {
+	ID         uuid.UUID     `json:"id" gorm:"type:uuid;primary_key;"`
+	TaskID     uuid.UUID     `json:"task_id" gorm:"type:uuid;index;not null"`
+	Kind       TaskEventKind `json:"kind" gorm:"not null"`
+	FromStatus TaskStatus    `json:"from_status"`
+	ToStatus   TaskStatus    `json:"to_status"`
+	Step       string        `json:"step"`
+	DurationMs *int64        `json:"duration_ms"`
+	Error      string        `json:"error"`
+	Source     string        `json:"source"` // worker ID for step events, actor for status events
+	OccurredAt time.Time     `json:"occurred_at" gorm:"index;not null"`
+	CreatedAt  time.Time     `json:"created_at"`
+}
+
+// StepSpan pairs the start and finish events of one workflow step; FinishedAt is
+// nil while the step is running or when the worker died inside it
+type StepSpan struct {
+	Step       string     `json:"step"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+	DurationMs *int64     `json:"duration_ms"`
+	Error      string     `json:"error"`
+}
+
+type TaskTimeline struct {
+	TaskID uuid.UUID   `json:"task_id"`
+	Status TaskStatus  `json:"status"`
+	Events []TaskEvent `json:"events"`
+	Steps  []StepSpan  `json:"steps"`
+}
+
+// TaskProgress is the live progress of a task as last reported by its worker.
+// It is kept in memory only; the persisted history is the timeline.
+type TaskProgress struct {
+	TaskID       string            `json:"task_id"`
+	Step         string            `json:"step"`
+	StepState    string            `json:"step_state"` // running, finished or failed
+	Percent      *float64          `json:"percent"`    // nil while the step's total is unknown
+	Current      int64             `json:"current"`
+	Total        int64             `json:"total"`
+	Warnings     []ProgressWarning `json:"warnings"` // the most recent ones
+	WarningCount int               `json:"warning_count"`
+	UpdatedAt    *time.Time        `json:"updated_at"`
+}
+
+type ProgressWarning struct {
+	Step       string    `json:"step"`
+	Message    string    `json:"message"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+const (
+	StepRunning  = "running"
+	StepFinished = "finished"
+	StepFailed   = "failed"
+)
@@ -0,0 +1,125 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	EventTaskCreated      = "task.created"
+	EventTaskStarted      = "task.started"
+	EventTaskSucceeded    = "task.succeeded"
+	EventTaskFailed       = "task.failed"
+	EventTaskCancelled    = "task.cancelled"
+	EventArtifactUploaded = "artifact.uploaded"
+
+	// EventAll subscribes a webhook to every event
+	EventAll = "*"
+)
+
+var WebhookEvents = []string{
+	EventTaskCreated,
+	EventTaskStarted,
+	EventTaskSucceeded,
+	EventTaskFailed,
+	EventTaskCancelled,
+	EventArtifactUploaded,
+}
+
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliverySucceeded DeliveryStatus = "succeeded"
+	DeliveryFailed    DeliveryStatus = "failed"
+)
+
+// StringList is stored as a jsonb array
+type StringList []string
+
+func (l *StringList) Scan(value any) error {
+	bytes, ok := value.([]byte)
+	if !ok {
+		return fmt.Errorf("type assertion to []byte failed, got %T instead", value)
+	}
+	return json.Unmarshal(bytes, l)
+}
+
+func (l StringList) Value() (driver.Value, error) {
+	if l == nil {
+		return "[]", nil
+	}
+	bytes, err := json.Marshal(l)
+	return string(bytes), err
+}
+
+// RawJSON keeps an already serialized document as is, in the database and in API responses
+type RawJSON json.RawMessage
+
+func (r *RawJSON) Scan(value any) error {
+	switch v := value.(type) {
+	case []byte:
+		*r = append((*r)[:0], v...)
+	case string:
+		*r = RawJSON(v)
+	default:
+		return fmt.Errorf("type assertion to []byte failed, got %T instead", value)
+	}
+	return nil
+}
+
+func (r RawJSON) Value() (driver.Value, error) {
+	if len(r) == 0 {
+		return "null", nil
+	}
+	return string(r), nil
+}
+
+func (r RawJSON) MarshalJSON() ([]byte, error) {
+	if len(r) == 0 {
+		return []byte("null"), nil
+	}
+	return r, nil
+}
+
+func (r *RawJSON) UnmarshalJSON(data []byte) error {
+	*r = append((*r)[:0], data...)
+	return nil
+}
+
+type Webhook struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;"`
+	URL       string     `json:"url" gorm:"not null"`
+	Secret    string     `json:"-" gorm:"not null"`
+	Events    StringList `json:"events" gorm:"type:jsonb;not null"`
+	Active    bool       `json:"active" gorm:"not null"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+func (w *Webhook) Subscribed(event string) bool {
+	return slices.Contains(w.Events, EventAll) || slices.Contains(w.Events, event)
+}
+
+// WebhookDelivery is one attempt sequence of sending an event to a webhook; the
+// rows double as the delivery log
+type WebhookDelivery struct {
+	ID            uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;"`
+	WebhookID     uuid.UUID      `json:"webhook_id" gorm:"type:uuid;index;not null"`
+	Event         string         `json:"event" gorm:"not null"`
+	Payload       RawJSON        `json:"payload" gorm:"type:jsonb;not null"`
+	Status        DeliveryStatus `json:"status" gorm:"index;not null"`
+	Attempts      int            `json:"attempts" gorm:"not null;default:0"`
+	ResponseCode  int            `json:"response_code"`
+	LastError     string         `json:"last_error"`
+	RedeliveryOf  *uuid.UUID     `json:"redelivery_of" gorm:"type:uuid"`
+	NextAttemptAt *time.Time     `json:"next_attempt_at" gorm:"index"`
+	DeliveredAt   *time.Time     `json:"delivered_at"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+}
@@ -10,8 +10,18 @@ type Worker struct {
 	gorm.Model
 
 	WorkerID string `gorm:"uniqueIndex;not null"`
-	APIKey   string `gorm:"not null"`
+	APIKey   string `json:"-" gorm:"not null"`
 	Hostname string
 	Status   string `gorm:"default:'offline';not null"`
 	LastSeen *time.Time
 }
+
+// WorkerInfo is the public view of a worker, without its API key hash
+type WorkerInfo struct {
+	WorkerID  string     `json:"worker_id"`
+	Hostname  string     `json:"hostname"`
+	Status    string     `json:"status"`
+	Online    bool       `json:"online"`
+	LastSeen  *time.Time `json:"last_seen"`
+	CreatedAt time.Time  `json:"created_at"`
+}
@@ -0,0 +1,161 @@
+// Package openapi serves the OpenAPI document of the REST API and checks it
+// against the registered routes and the models, so that the document, the
+// handlers and the client SDK built from it cannot silently drift apart.
+package openapi
+
+import (
+	"Server/pkg/model"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+//go:embed openapi.json
+var spec []byte
+
+type document struct {
+	Paths      map[string]map[string]json.RawMessage `json:"paths"`
+	Components struct {
+		Schemas map[string]struct {
+			Properties map[string]json.RawMessage `json:"properties"`
+		} `json:"schemas"`
+	} `json:"components"`
+}
+
+// models maps schema names of the document onto the types the handlers serialize
+var models = map[string]any{
+	"Task":             model.Task{},
+	"CrashReport":      model.CrashReport{},
+	"Crash":            model.Crash{},
+	"FixCommit":        model.FixCommit{},
+	"Schedule":         model.Schedule{},
+	"Webhook":          model.Webhook{},
+	"WebhookDelivery":  model.WebhookDelivery{},
+	"AuditEntry":       model.AuditEntry{},
+	"WorkerInfo":       model.WorkerInfo{},
+	"TaskEvent":        model.TaskEvent{},
+	"StepSpan":         model.StepSpan{},
+	"TaskTimeline":     model.TaskTimeline{},
+	"TaskProgress":     model.TaskProgress{},
+	"ProgressWarning":  model.ProgressWarning{},
+	"TaskFailure":      model.TaskFailure{},
+	"FailureStat":      model.FailureStat{},
+	"ConsoleReport":    model.ConsoleReport{},
+	"StackFrame":       model.StackFrame{},
+	"TaskResult":       model.TaskResult{},
+	"FixVerification":  model.FixVerification{},
+	"FixRun":           model.FixRun{},
+	"BisectRange":      model.BisectRange{},
+	"BisectStep":       model.BisectStep{},
+	"BisectResult":     model.BisectResult{},
+	"ReproRun":         model.ReproRun{},
+	"TaskComparison":   model.TaskComparison{},
+	"TaskSummary":      model.TaskSummary{},
+	"ValueDiff":        model.ValueDiff{},
+	"StepDelta":        model.StepDelta{},
+	"StackDiff":        model.StackDiff{},
+	"CrashDiff":        model.CrashDiff{},
+	"LineCount":        model.LineCount{},
+	"LogDiff":          model.LogDiff{},
+	"PatchSummary":     model.PatchSummary{},
+	"PatchFile":        model.PatchFile{},
+	"SeriesPatch":      model.SeriesPatch{},
+	"PatchApplyResult": model.PatchApplyResult{},
+}
+
+var ginParam = regexp.MustCompile(`[:*]([A-Za-z0-9_]+)`)
+
+func Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/json", spec)
+	}
+}
+
+// Verify reports every route missing from the document, every documented
+// operation without a route, and every model field missing from its schema
+func Verify(routes gin.RoutesInfo) error {
+	var doc document
+	if err := json.Unmarshal(spec, &doc); err != nil {
+		return fmt.Errorf("invalid openapi document: %w", err)
+	}
+
+	var errs []error
+
+	registered := make(map[string]bool)
+	for _, route := range routes {
+		if !strings.HasPrefix(route.Path, "/api/") {
+			continue
+		}
+		path := ginParam.ReplaceAllString(route.Path, "{$1}")
+		method := strings.ToLower(route.Method)
+		registered[method+" "+path] = true
+
+		if _, ok := doc.Paths[path][method]; !ok {
+			errs = append(errs, fmt.Errorf("route %s %s is not documented", route.Method, path))
+		}
+	}
+	for path, operations := range doc.Paths {
+		for method := range operations {
+			if method == "parameters" {
+				continue
+			}
+			if !registered[method+" "+path] {
+				errs = append(errs, fmt.Errorf("documented operation %s %s has no route", strings.ToUpper(method), path))
+			}
+		}
+	}
+
+	for name, value := range models {
+		schema, ok := doc.Components.Schemas[name]
+		if !ok {
+			errs = append(errs, fmt.Errorf("schema %s is missing", name))
+			continue
+		}
+		fields := jsonFields(reflect.TypeOf(value))
+		for _, field := range fields {
+			if _, ok := schema.Properties[field]; !ok {
+				errs = append(errs, fmt.Errorf("field %s.%s is not documented", name, field))
+			}
+		}
+		for property := range schema.Properties {
+			if !slices.Contains(fields, property) {
+				errs = append(errs, fmt.Errorf("documented property %s.%s does not exist", name, property))
+			}
+		}
+	}
+
+	slices.SortFunc(errs, func(a, b error) int { return strings.Compare(a.Error(), b.Error()) })
+	return errors.Join(errs...)
+}
+
+// jsonFields lists the JSON names encoding/json uses for a struct type
+func jsonFields(t reflect.Type) []string {
+	var fields []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			fields = append(fields, jsonFields(field.Type)...)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields = append(fields, name)
+	}
+	return fields
+}
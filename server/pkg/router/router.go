@@ -4,6 +4,7 @@ import (
 	"Server/pkg/handler"
 	"Server/pkg/manager"
 	"Server/pkg/middleware"
+	"Server/pkg/openapi"
 	"Server/pkg/websocket"
 	"net/http"
 	"time"
@@ -13,8 +14,9 @@ import (
 	"gorm.io/gorm"
 )
 
-func SetupRouter(rmqClient *manager.RabbitMQClient, db *gorm.DB, mgr *manager.WorkerManager, wsHub *websocket.Hub) *gin.Engine {
+func SetupRouter(rmqClient *manager.RabbitMQClient, db *gorm.DB, mgr *manager.WorkerManager, scheduler *manager.Scheduler, hooks *manager.WebhookDispatcher, progress *manager.ProgressTracker, wsHub *websocket.Hub, adminToken string) *gin.Engine {
 	router := gin.Default()
+	adminAuth := middleware.AdminAuthMiddleware(adminToken)
 
 	router.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"*"},
@@ -29,26 +31,56 @@ func SetupRouter(rmqClient *manager.RabbitMQClient, db *gorm.DB, mgr *manager.Wo
 		c.String(http.StatusOK, "pong")
 	})
 
-	apiV1 := router.Group("/api/v1")
+	apiV1 := router.Group("/api/v1", middleware.IdentifyActorMiddleware(adminToken))
 	{
+		apiV1.GET("/openapi.json", openapi.Handler())
+
 		tasks := apiV1.Group("/tasks")
 		{
-			tasks.POST("", handler.CreateTaskHandler(db, rmqClient))
+			tasks.POST("", handler.CreateTaskHandler(db, rmqClient, hooks))
 			tasks.GET("", handler.GetTasksHandler(db))
+			tasks.GET("/failures", handler.GetFailureStatsHandler(db))
+			tasks.GET("/compare", handler.CompareTasksHandler(db))
 			tasks.GET("/:id", handler.GetTaskByIDHandler(db))
-			tasks.DELETE("/:id", handler.DeleteTaskHandler(db))
-			tasks.POST("/accept", middleware.WorkerAuthMiddleware(db, mgr), handler.AcceptTaskHandler(db))
-			tasks.PATCH("/:id", handler.UpdateTaskStatusHandler(db))
-			tasks.POST("/:id/artifact", middleware.WorkerAuthMiddleware(db, mgr), handler.UploadTaskArtifactHandler(db))
+			tasks.DELETE("/:id", adminAuth, handler.DeleteTaskHandler(db))
+			tasks.POST("/accept", middleware.WorkerAuthMiddleware(db, mgr), handler.AcceptTaskHandler(db, hooks))
+			tasks.PATCH("/:id", middleware.WorkerAuthMiddleware(db, mgr), handler.UpdateTaskStatusHandler(db, hooks))
+			tasks.POST("/:id/cancel", adminAuth, handler.CancelTaskHandler(db, hooks))
+			tasks.GET("/:id/timeline", handler.GetTaskTimelineHandler(db))
+			tasks.GET("/:id/progress", handler.GetTaskProgressHandler(db, progress))
+			tasks.POST("/:id/events", middleware.WorkerAuthMiddleware(db, mgr), handler.ReportTaskEventHandler(db))
+			tasks.POST("/:id/artifact", middleware.WorkerAuthMiddleware(db, mgr), handler.UploadTaskArtifactHandler(db, hooks))
 		}
 
 		workers := apiV1.Group("/workers")
 		{
+			workers.GET("", handler.GetWorkersHandler(db, mgr))
 			workers.POST("/register", handler.RegisterWorkerHandler(db, mgr))
 			workers.POST("/unregister", handler.UnregisterWorkerHandler(db, mgr))
 			workers.POST("/ping", middleware.WorkerAuthMiddleware(db, mgr), handler.PingHandler(mgr))
 		}
 
+		schedules := apiV1.Group("/schedules")
+		{
+			schedules.GET("", handler.GetSchedulesHandler(db))
+			schedules.GET("/:id", handler.GetScheduleByIDHandler(db))
+			schedules.POST("", adminAuth, handler.CreateScheduleHandler(db))
+			schedules.PUT("/:id", adminAuth, handler.UpdateScheduleHandler(db))
+			schedules.DELETE("/:id", adminAuth, handler.DeleteScheduleHandler(db))
+			schedules.POST("/:id/run", adminAuth, handler.RunScheduleHandler(db, scheduler))
+		}
+
+		webhooks := apiV1.Group("/webhooks", adminAuth)
+		{
+			webhooks.POST("", handler.CreateWebhookHandler(db))
+			webhooks.GET("", handler.GetWebhooksHandler(db))
+			webhooks.GET("/:id", handler.GetWebhookByIDHandler(db))
+			webhooks.PUT("/:id", handler.UpdateWebhookHandler(db))
+			webhooks.DELETE("/:id", handler.DeleteWebhookHandler(db))
+			webhooks.GET("/:id/deliveries", handler.GetWebhookDeliveriesHandler(db))
+			webhooks.POST("/:id/deliveries/:delivery_id/redeliver", handler.RedeliverWebhookHandler(hooks))
+		}
+
 		logs := apiV1.Group("/logs")
 		{
 			logs.GET("/ws", func(c *gin.Context) {
@@ -57,6 +89,13 @@ func SetupRouter(rmqClient *manager.RabbitMQClient, db *gorm.DB, mgr *manager.Wo
 		}
 
 		apiV1.GET("/artifacts/:id", handler.DownloadTaskArtifactHandler(db))
+
+		admin := apiV1.Group("/admin", adminAuth)
+		{
+			admin.PATCH("/tasks/:id/priority", handler.UpdateTaskPriorityHandler(db, rmqClient))
+			admin.POST("/workers/:worker_id/rotate-key", handler.RotateWorkerKeyHandler(db, mgr))
+			admin.GET("/audit", handler.GetAuditLogHandler(db))
+		}
 	}
 
 	return router
@@ -0,0 +1,18 @@
+package router
+
+import (
+	"Server/pkg/openapi"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestRoutesMatchOpenAPI checks the registered routes and the models against the
+// OpenAPI document; the handlers are only constructed, so no database or broker is needed
+func TestRoutesMatchOpenAPI(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := SetupRouter(nil, nil, nil, nil, nil, nil, nil, "")
+	if err := openapi.Verify(r.Routes()); err != nil {
+		t.Errorf("API does not match the OpenAPI document:\n%v", err)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"strings"
 	"sync"
 	"syscall"
 	"time"
@@ -17,6 +18,8 @@ import (
 	"worker/internal/parse"
 	pb "worker/internal/proto"
 
+	"sdk/client"
+
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/keepalive"
@@ -36,36 +39,16 @@ const (
 var amqpURI string
 var worker config.Worker
 
-// RegisterMsg 注册响应消息
-type RegisterMsg struct {
-	APIKey   string `json:"api_key"`
-	Message  string `json:"message"`
-	Status   string `json:"status"`
-	WorkerID string `json:"worker_id"`
-}
-
 // Worker 工作节点配置
 type Worker = config.Worker
 
-// Message 任务消息
-type Message struct {
-	TaskID       string            `json:"id"`
-	Type         string            `json:"type"`
-	Status       string            `json:"status"`
-	Payload      parse.CrashReport `json:"payload"`
-	WorkerID     string            `json:"worker_id"`
-	Result       string            `json:"result"`
-	ArtifactPath string            `json:"artifact_path"`
-	ArtifactName string            `json:"artifact_name"`
-	CreatedAt    string            `json:"created_at"`
-	StartedAt    string            `json:"started_at"`
-	FinishedAt   string            `json:"finished_at"`
-}
+// Message 任务消息，即服务器发布到队列中的任务
+type Message = client.Task
 
 // WorkerService 工作节点服务
 type WorkerService struct {
 	worker      Worker
-	client      *network.HttpClient
+	client      *client.Client
 	rmqClient   *queue.RabbitMQClient // 修正：使用正确的类型名
 	cancel      context.CancelFunc
 	rmqMutex    sync.RWMutex
@@ -84,16 +67,11 @@ func init() {
 func NewWorkerService() (*WorkerService, error) {
 	amqpURI = fmt.Sprintf("amqp://guest:123456@%s:5672/", config.GlobalWorker.IPAddress)
 
-	config := network.Config{
-		BaseURL: fmt.Sprintf("http://%s:8080", worker.IPAddress),
-		Timeout: 30 * time.Second,
-		Headers: map[string]string{},
-	}
-	client := network.NewClient(&config)
+	apiClient := client.New(fmt.Sprintf("http://%s:8080", worker.IPAddress))
 
 	return &WorkerService{
 		worker: worker,
-		client: client,
+		client: apiClient,
 	}, nil
 }
 
@@ -113,35 +91,35 @@ func (ws *WorkerService) saveWorkerConfig() error {
 
 // register 注册工作节点
 func (ws *WorkerService) register(ctx context.Context) error {
-	resp, err := ws.client.PostWithContext(ctx, "/api/v1/workers/register", ws.worker)
+	resp, err := ws.client.RegisterWorker(ctx, ws.registerRequest())
 	if err != nil {
 		return fmt.Errorf("failed to register worker: %w", err)
 	}
 
-	if !resp.IsSuccess() {
-		return fmt.Errorf("register failed: %s", resp.String())
-	}
-
-	var registerMsg RegisterMsg
-	if err := json.Unmarshal([]byte(resp.String()), &registerMsg); err != nil {
-		return fmt.Errorf("failed to parse register response: %w", err)
-	}
-
-	log.Info(resp.String())
-	ws.worker.APIKey = registerMsg.APIKey
+	log.Infof("%s: %s", resp.Status, resp.Message)
+	ws.worker.APIKey = resp.APIKey
 
 	return ws.saveWorkerConfig()
 }
 
 // unregister 注销工作节点
 func (ws *WorkerService) unregister(ctx context.Context) {
-	if _, err := ws.client.PostWithContext(ctx, "/api/v1/workers/unregister", ws.worker); err != nil {
+	if err := ws.client.UnregisterWorker(ctx, ws.registerRequest()); err != nil {
 		log.Errorf("failed to unregister worker: %v", err)
 	} else {
 		log.Info("worker unregistered successfully")
 	}
 }
 
+// registerRequest 注册与注销使用的请求体
+func (ws *WorkerService) registerRequest() client.RegisterWorkerRequest {
+	return client.RegisterWorkerRequest{
+		WorkerID: ws.worker.WorkerID,
+		APIKey:   ws.worker.APIKey,
+		Hostname: ws.worker.Hostname,
+	}
+}
+
 // setupRabbitMQ 初始化RabbitMQ连接
 func (ws *WorkerService) setupRabbitMQ() error {
 	rmqClient, err := queue.NewClient(amqpURI, queueName) // 修正：使用正确的函数名
@@ -166,7 +144,7 @@ func (ws *WorkerService) handleMessage(ctx context.Context, messageBody string)
 		return errors.New("worker busy")
 	}
 
-	if err := ws.acceptTask(ctx, msg.TaskID); err != nil {
+	if err := ws.acceptTask(ctx, msg.ID, msg.Revision); err != nil {
 		return fmt.Errorf("failed to accept task: %w", err)
 	}
 
@@ -175,23 +153,14 @@ func (ws *WorkerService) handleMessage(ctx context.Context, messageBody string)
 	return ws.processTask(ctx, msg)
 }
 
-// acceptTask 接受任务
-func (ws *WorkerService) acceptTask(ctx context.Context, taskID string) error {
-	requestBody := map[string]string{
-		"id":        taskID,
-		"worker_id": ws.worker.WorkerID,
-	}
-
-	resp, err := ws.client.PostForm("/api/v1/tasks/accept", requestBody)
-	if err != nil {
-		return err
-	}
-
-	if resp.StatusCode != 200 {
-		return fmt.Errorf("accept task failed with status %d", resp.StatusCode)
-	}
-
-	return nil
+// acceptTask 接受任务；revision 用于识别任务重新调度后遗留在队列中的旧消息
+func (ws *WorkerService) acceptTask(ctx context.Context, taskID string, revision int) error {
+	_, err := ws.client.AcceptTask(ctx, client.AcceptTaskRequest{
+		ID:       taskID,
+		WorkerID: ws.worker.WorkerID,
+		Revision: revision,
+	})
+	return err
 }
 
 // processTask 处理任务
@@ -222,17 +191,45 @@ func (ws *WorkerService) processTask(ctx context.Context, msg Message) error {
 	}
 	defer ws.cleanupTempFile(tempFile)
 
+	// kernel-builder 把本次执行产生的文件记录在结果文件中，文件名随任务文件唯一
+	resultPath := strings.TrimSuffix(tempFile.Name(), ".json") + "-result.json"
+	defer os.Remove(resultPath)
+
 	report := parse.Parse(tempFile.Name())
-	taskCtx := context.WithValue(ctx, "taskCommit", report.Crashes[0].KernelSourceCommit)
-	taskCtx = context.WithValue(taskCtx, "taskID", msg.TaskID)
+	taskCommit := report.Crashes[0].KernelSourceCommit
+	switch {
+	case msg.Type == client.TaskTypeFixVerify:
+		// fix-verify 先在父提交上运行，任务本身的串口日志与 .config 取自父提交
+		taskCommit = report.ParentOfFixCommit
+	case msg.Type == client.TaskTypeBisect && report.Bisect != nil:
+		// bisect 的结果保存在以 bad 提交命名的目录中
+		taskCommit = report.Bisect.Bad
+	}
+	taskCtx := context.WithValue(ctx, "taskCommit", taskCommit)
+	taskCtx = context.WithValue(taskCtx, "taskID", msg.ID)
+	taskCtx = context.WithValue(taskCtx, "taskType", string(msg.Type))
 	taskCtx = context.WithValue(taskCtx, "workerID", ws.worker.WorkerID)
+	taskCtx = context.WithValue(taskCtx, "resultPath", resultPath)
+	if msg.Type == client.TaskTypeFixVerify {
+		taskCtx = context.WithValue(taskCtx, "fixCommit", report.FixCommit())
+	}
 
 	logServiceClient := pb.NewLogStreamServiceClient(conn)
-	command := fmt.Sprintf("../build-vmcore/kernel-builder -t %s -f %s -c -g -z", msg.Type, tempFile.Name())
+	command := builderCommand(msg.Type, tempFile.Name(), resultPath)
 
 	return network.ExecuteAndStreamLogs(taskCtx, logServiceClient, command, ws.client)
 }
 
+// builderCommand 按任务类型构造 kernel-builder 命令；patch-apply 的补丁与 fix-verify、bisect 的提交在任务载荷中
+func builderCommand(taskType client.TaskType, reportPath, resultPath string) string {
+	switch taskType {
+	case client.TaskTypePatchApply, client.TaskTypeFixVerify, client.TaskTypeBisect:
+		return fmt.Sprintf("../build-vmcore/kernel-builder -t %s -f %s -r %s", taskType, reportPath, resultPath)
+	default:
+		return fmt.Sprintf("../build-vmcore/kernel-builder -t %s -f %s -r %s -c -g -z", taskType, reportPath, resultPath)
+	}
+}
+
 // createTempFile 创建临时文件
 func (ws *WorkerService) createTempFile(payload parse.CrashReport) (*os.File, error) {
 	payloadJSON, err := json.MarshalIndent(payload, "", "  ")
@@ -346,16 +343,10 @@ func (ws *WorkerService) startHealthChecker(ctx context.Context, wg *sync.WaitGr
 func (ws *WorkerService) ping(ctx context.Context) {
 	log.Debug("sending ping request")
 
-	resp, err := ws.client.PostWithContext(ctx, "/api/v1/workers/ping", nil)
-	if err != nil {
+	if err := ws.client.Ping(ctx); err != nil {
 		if !errors.Is(ctx.Err(), context.Canceled) {
 			log.Errorf("ping failed: %v", err)
 		}
-		return
-	}
-
-	if !resp.IsSuccess() {
-		log.Errorf("ping failed with status %d: %s", resp.StatusCode, resp.String())
 	}
 }
 
@@ -373,8 +364,7 @@ func (ws *WorkerService) Run() error {
 	}
 
 	// 设置认证头
-	authHeader := fmt.Sprintf("Bearer %s:%s", ws.worker.WorkerID, ws.worker.APIKey)
-	ws.client.SetHeader("Authorization", authHeader)
+	ws.client.SetWorkerCredentials(ws.worker.WorkerID, ws.worker.APIKey)
 
 	// 初始化RabbitMQ
 	if err := ws.setupRabbitMQ(); err != nil {
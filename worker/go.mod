@@ -7,11 +7,15 @@ require (
 	github.com/sirupsen/logrus v1.9.3
 	google.golang.org/grpc v1.73.0
 	google.golang.org/protobuf v1.36.6
+	sdk v0.0.0-00010101000000-000000000000
 )
 
 require (
+	github.com/gorilla/websocket v1.5.3 // indirect
 	golang.org/x/net v0.38.0 // indirect
 	golang.org/x/sys v0.31.0 // indirect
 	golang.org/x/text v0.23.0 // indirect
 	google.golang.org/genproto/googleapis/rpc v0.0.0-20250324211829-b45e905df463 // indirect
 )
+
+replace sdk => ../sdk
@@ -0,0 +1,236 @@
+package classify
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"sdk/client"
+)
+
+// Warning kernel-builder 在某一步骤上报的警告
+type Warning struct {
+	Step    string
+	Message string
+}
+
+// Input 一次任务执行中可用于分类的信息
+type Input struct {
+	FailedStep string    // 第一个以错误结束的步骤
+	StepError  string    // 该步骤的错误
+	Warnings   []Warning // 各步骤上报的警告
+	Lines      []string  // 任务输出，按时间顺序
+}
+
+var (
+	// ansiPattern 终端颜色控制序列
+	ansiPattern = regexp.MustCompile(`\x1b\[[0-9;]*[A-Za-z]`)
+	// msgPattern logrus 文本格式中的 msg="..." 字段
+	msgPattern = regexp.MustCompile(`\bmsg=("(?:[^"\\]|\\.)*")`)
+	// compilerPattern gcc/clang/rustc 风格的编译错误，如 drivers/net/foo.c:12:5: error: ...
+	compilerPattern = regexp.MustCompile(`([\w./+-]+\.(?:c|h|S|rs|lds)):(\d+)(?::\d+)?: (?:fatal )?error: (.+)`)
+)
+
+var toolchainMarkers = []string{
+	"toolchain not initialized",
+	"no toolchain found",
+	"executable file not found",
+	"command not found",
+}
+
+var headersMarkers = []string{
+	"error installing header",
+	"linux header not generated",
+}
+
+var configMarkers = []string{
+	"config file not found",
+	"make olddefconfig",
+	"invalid config",
+	"failed to write config file",
+}
+
+// patchMarkers git apply 说明补丁无法应用的输出
+var patchMarkers = []string{
+	"patch failed:",
+	"patch does not apply",
+	"corrupt patch",
+	"no such file or directory",
+}
+
+// sshMarkers 虚拟机未能启动到可以 SSH 登录的状态
+var sshMarkers = []string{
+	"ssh client has not been initialized",
+	"fail to dial",
+	"ssh: handshake failed",
+	"i/o timeout",
+}
+
+// noVmcoreMarker build-vmcore/script/get.sh 未找到 vmcore 时的输出
+const noVmcoreMarker = "未找到 vmcore 文件"
+
+// downloadSteps 下载类步骤
+var downloadSteps = map[string]bool{
+	"DownloadKernel": true,
+	"DownloadConfig": true,
+	"DownloadBug":    true,
+}
+
+// Clean 去除颜色控制序列，并还原 logrus 文本格式中被转义的 msg 字段
+func Clean(line string) string {
+	line = ansiPattern.ReplaceAllString(line, "")
+	if m := msgPattern.FindStringSubmatch(line); m != nil {
+		if msg, err := strconv.Unquote(m[1]); err == nil {
+			return msg
+		}
+	}
+	return strings.TrimSpace(line)
+}
+
+// Notable 判断输出行是否可能决定分类，调用方应优先保留这些行
+func Notable(line string) bool {
+	line = Clean(line)
+	lower := strings.ToLower(line)
+	return compilerPattern.MatchString(line) ||
+		strings.Contains(line, noVmcoreMarker) ||
+		containsAny(lower, toolchainMarkers) ||
+		containsAny(lower, headersMarkers) ||
+		containsAny(lower, configMarkers)
+}
+
+// Classify 根据失败步骤、步骤错误、警告和输出判断失败原因，无法判断时为 unknown。
+// 具体的证据优先于步骤：编译错误即使出现在其他步骤的错误之前也会被识别
+func Classify(in Input) client.TaskFailure {
+	lines := make([]string, 0, len(in.Lines)+1)
+	for _, line := range in.Lines {
+		if line = Clean(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	stepError := Clean(in.StepError)
+	failure := client.TaskFailure{Step: in.FailedStep, Message: stepError}
+
+	// 步骤错误最具体，排在输出之前查找
+	texts := append([]string{stepError}, lines...)
+
+	if line, ok := find(texts, toolchainMarkers); ok {
+		failure.Category, failure.Message = client.FailureToolchain, line
+		return failure
+	}
+	if line, ok := find(texts, headersMarkers); ok {
+		failure.Category, failure.Message = client.FailureHeadersInstall, line
+		return failure
+	}
+	// git apply 的输出也包含源文件名与行号，按失败步骤判断
+	// 步骤错误已指出是系列中的第几个补丁及 git apply 的错误
+	if in.FailedStep == "ApplyPatch" {
+		failure.Category = client.FailurePatchApply
+		if line, ok := find(lines, patchMarkers); ok && stepError == "" {
+			failure.Message = line
+		}
+		return failure
+	}
+	for _, line := range lines {
+		if m := compilerPattern.FindStringSubmatch(line); m != nil {
+			failure.Category = client.FailureCompiler
+			failure.Message = m[0]
+			failure.File = m[1]
+			failure.Line, _ = strconv.Atoi(m[2])
+			if failure.Step == "" {
+				failure.Step = "MakeKernel"
+			}
+			return failure
+		}
+	}
+	if line, ok := find(texts, configMarkers); ok {
+		failure.Category, failure.Message = client.FailureConfig, line
+		return failure
+	}
+
+	// get.sh 找不到 vmcore 时仍以 0 退出，失败会出现在之后的步骤
+	if in.FailedStep == "GetVmcore" || contains(lines, noVmcoreMarker) {
+		failure.Category = client.FailureNoVmcore
+		failure.Step = "GetVmcore"
+		if failure.Message == "" || in.FailedStep != "GetVmcore" {
+			failure.Message = noVmcoreMarker
+		}
+		if warning, ok := kexecWarning(in.Warnings); ok {
+			failure.Category = client.FailureKdumpNotLoaded
+			failure.Message = warning.Message
+		}
+		return failure
+	}
+
+	if in.FailedStep == "BootVM" {
+		failure.Category = client.FailureVMBootTimeout
+		return failure
+	}
+	for _, warning := range in.Warnings {
+		if warning.Step == "RunReproducer" && containsAny(strings.ToLower(warning.Message), sshMarkers) {
+			failure.Category = client.FailureVMBootTimeout
+			failure.Step = warning.Step
+			failure.Message = warning.Message
+			return failure
+		}
+	}
+
+	if downloadSteps[in.FailedStep] {
+		failure.Category = client.FailureDownload
+		return failure
+	}
+
+	failure.Category = client.FailureUnknown
+	if failure.Message == "" {
+		failure.Message = lastError(lines)
+	}
+	return failure
+}
+
+// kexecWarning 找到 kexec 加载崩溃内核失败的警告，此时 kdump 未就绪
+func kexecWarning(warnings []Warning) (Warning, bool) {
+	for _, warning := range warnings {
+		if strings.HasPrefix(warning.Message, "kexec:") {
+			return warning, true
+		}
+	}
+	return Warning{}, false
+}
+
+// find 返回第一条包含任一标记的文本
+func find(texts []string, markers []string) (string, bool) {
+	for _, text := range texts {
+		if containsAny(strings.ToLower(text), markers) {
+			return text, true
+		}
+	}
+	return "", false
+}
+
+func contains(lines []string, marker string) bool {
+	for _, line := range lines {
+		if strings.Contains(line, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsAny(text string, markers []string) bool {
+	for _, marker := range markers {
+		if strings.Contains(text, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// lastError 返回最后一条看起来是错误的输出
+func lastError(lines []string) string {
+	for i := len(lines) - 1; i >= 0; i-- {
+		lower := strings.ToLower(lines[i])
+		if strings.Contains(lower, "error") || strings.Contains(lower, "fail") {
+			return lines[i]
+		}
+	}
+	return ""
+}
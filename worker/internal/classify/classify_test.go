@@ -0,0 +1,182 @@
+package classify
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"sdk/client"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string // 任务输出，为空时没有输出
+		step     string
+		err      string
+		warnings []Warning
+
+		category client.FailureCategory
+		wantStep string
+		message  string // 消息包含的内容
+		source   string
+		line     int
+	}{
+		{
+			name:     "compiler error",
+			file:     "compiler-error.log",
+			step:     "MakeKernel",
+			err:      "exit status 2",
+			category: client.FailureCompiler,
+			wantStep: "MakeKernel",
+			message:  "'RX_STAT_LEN' undeclared",
+			source:   "drivers/net/wireless/ath/ath9k/hif_usb.c",
+			line:     618,
+		},
+		{
+			// 编译错误早于之后其他步骤的失败
+			name:     "compiler error before a later failure",
+			file:     "compiler-error.log",
+			step:     "Compress",
+			err:      "no such kernel image",
+			category: client.FailureCompiler,
+			wantStep: "Compress",
+			source:   "drivers/net/wireless/ath/ath9k/hif_usb.c",
+			line:     618,
+		},
+		{
+			name:     "missing toolchain",
+			file:     "missing-toolchain.log",
+			step:     "MakeKernel",
+			err:      `exec: "gcc-10": executable file not found in $PATH`,
+			category: client.FailureToolchain,
+			wantStep: "MakeKernel",
+			message:  "gcc-10",
+		},
+		{
+			// get.sh 找不到 vmcore 时以 0 退出，任务在打包时才失败
+			name:     "no vmcore",
+			file:     "no-vmcore.log",
+			step:     "Compress",
+			err:      "open build/8b817fded42d/linux-8b817fded42d/vmcore: no such file or directory",
+			category: client.FailureNoVmcore,
+			wantStep: "GetVmcore",
+			message:  noVmcoreMarker,
+		},
+		{
+			name:     "kdump not loaded",
+			file:     "no-vmcore.log",
+			step:     "Compress",
+			warnings: []Warning{{Step: "RunReproducer", Message: "kexec: Process exited with status 1"}},
+			category: client.FailureKdumpNotLoaded,
+			wantStep: "GetVmcore",
+			message:  "kexec: Process exited with status 1",
+		},
+		{
+			name:     "patch does not apply",
+			file:     "patch-apply.log",
+			step:     "ApplyPatch",
+			category: client.FailurePatchApply,
+			wantStep: "ApplyPatch",
+			message:  "patch failed: net/bluetooth/hci_conn.c:2440",
+		},
+		{
+			// 步骤错误已指出失败的补丁，不被 git apply 的输出替换
+			name:     "patch error from the step",
+			file:     "patch-apply.log",
+			step:     "ApplyPatch",
+			err:      "patch 2/3 does not apply: patch failed: net/bluetooth/hci_conn.c:2440",
+			category: client.FailurePatchApply,
+			wantStep: "ApplyPatch",
+			message:  "patch 2/3",
+		},
+		{
+			name:     "download",
+			step:     "DownloadKernel",
+			err:      `Get "https://github.com/torvalds/linux/archive/8b817fded42d.tar.gz": dial tcp: lookup github.com: no such host`,
+			category: client.FailureDownload,
+			wantStep: "DownloadKernel",
+			message:  "no such host",
+		},
+		{
+			name:     "vm boot",
+			step:     "BootVM",
+			err:      "qemu exited before the QMP connection",
+			category: client.FailureVMBootTimeout,
+			wantStep: "BootVM",
+		},
+		{
+			name:     "ssh never came up",
+			warnings: []Warning{{Step: "RunReproducer", Message: "ssh: handshake failed: read tcp 127.0.0.1:51234->127.0.0.1:2222: i/o timeout"}},
+			category: client.FailureVMBootTimeout,
+			wantStep: "RunReproducer",
+			message:  "i/o timeout",
+		},
+		{
+			name:     "unknown",
+			step:     "UploadArtifact",
+			category: client.FailureUnknown,
+			wantStep: "UploadArtifact",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := Input{FailedStep: tt.step, StepError: tt.err, Warnings: tt.warnings}
+			if tt.file != "" {
+				in.Lines = readLines(t, tt.file)
+			}
+			failure := Classify(in)
+
+			if failure.Category != tt.category || failure.Step != tt.wantStep {
+				t.Errorf("got %s at %q, want %s at %q (message %q)", failure.Category, failure.Step, tt.category, tt.wantStep, failure.Message)
+			}
+			if !strings.Contains(failure.Message, tt.message) {
+				t.Errorf("message %q does not contain %q", failure.Message, tt.message)
+			}
+			if failure.File != tt.source || failure.Line != tt.line {
+				t.Errorf("location %s:%d, want %s:%d", failure.File, failure.Line, tt.source, tt.line)
+			}
+		})
+	}
+}
+
+func TestClean(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+	}{
+		{`time="2024-05-01T10:31:53+08:00" level=info msg="未找到 vmcore 文件: /root/build-vmcore/work/vm0/mnt/var/crash/vmcore"`, "未找到 vmcore 文件: /root/build-vmcore/work/vm0/mnt/var/crash/vmcore"},
+		{`time="2024-05-01T10:31:53+08:00" level=error msg="exec: \"gcc-10\": executable file not found"`, `exec: "gcc-10": executable file not found`},
+		{"\x1b[31mERRO\x1b[0m[2024-05-01 10:12:03] exit status 2", "ERRO[2024-05-01 10:12:03] exit status 2"},
+		{"  CC      kernel/fork.o  ", "CC      kernel/fork.o"},
+	}
+	for _, tt := range tests {
+		if got := Clean(tt.line); got != tt.want {
+			t.Errorf("Clean(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestNotable(t *testing.T) {
+	lines := readLines(t, "compiler-error.log")
+	var notable []string
+	for _, line := range lines {
+		if Notable(line) {
+			notable = append(notable, line)
+		}
+	}
+	if len(notable) != 1 || !strings.Contains(notable[0], "hif_usb.c:618:25: error:") {
+		t.Errorf("notable lines %q, want only the compiler error", notable)
+	}
+}
+
+func readLines(t *testing.T, name string) []string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+}
@@ -0,0 +1,389 @@
+package console
+
+// 解析虚拟机串口输出中的内核报告（KASAN、KCSAN、KMSAN、UBSAN、BUG、WARNING、
+// general protection fault、lockdep、hung task、RCU stall、soft lockup、panic），
+// 标题格式与 syzbot 保持一致，便于与漏洞报告的标题比较
+
+import (
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"sdk/client"
+)
+
+const (
+	// maxReportLines 单个报告最多包含的行数
+	maxReportLines = 400
+	// maxReportText 报告原文的最大长度
+	maxReportText = 32 * 1024
+	// maxFrames 每个调用栈最多保留的帧数
+	maxFrames = 64
+	// maxOtherTitles 最多记录的后续报告标题数
+	maxOtherTitles = 10
+)
+
+// oops 一类报告的起始行及其标题的生成方式
+type oops struct {
+	kind  client.ReportKind
+	start *regexp.Regexp
+	title func(m []string, r *client.ConsoleReport, rip string) string
+}
+
+var oopses = []oops{
+	{
+		kind:  client.ReportKASAN,
+		start: regexp.MustCompile(`BUG: KASAN: ([\w-]+) in ([\w.$]+)`),
+		title: func(m []string, r *client.ConsoleReport, _ string) string {
+			fn := cleanFunc(m[2])
+			if skipFrame.MatchString(fn) {
+				fn = frameOr(r.Frames, fn)
+			}
+			if r.Access != "" {
+				return "KASAN: " + m[1] + " " + capitalize(r.Access) + " in " + fn
+			}
+			return "KASAN: " + m[1] + " in " + fn
+		},
+	},
+	{
+		kind:  client.ReportKCSAN,
+		start: regexp.MustCompile(`BUG: KCSAN: ([\w-]+) in ([\w.$]+)(?: / ([\w.$]+))?`),
+		title: func(m []string, _ *client.ConsoleReport, _ string) string {
+			title := "KCSAN: " + m[1] + " in " + cleanFunc(m[2])
+			if m[3] != "" {
+				title += " / " + cleanFunc(m[3])
+			}
+			return title
+		},
+	},
+	{
+		kind:  client.ReportKMSAN,
+		start: regexp.MustCompile(`BUG: KMSAN: ([\w-]+) in ([\w.$]+)`),
+		title: func(m []string, _ *client.ConsoleReport, _ string) string {
+			return "KMSAN: " + m[1] + " in " + cleanFunc(m[2])
+		},
+	},
+	{
+		kind:  client.ReportUBSAN,
+		start: regexp.MustCompile(`UBSAN: ([\w-]+) in (\S+?)(?::\d+)*$`),
+		title: func(m []string, r *client.ConsoleReport, _ string) string {
+			return "UBSAN: " + m[1] + " in " + frameOr(r.Frames, m[2])
+		},
+	},
+	{
+		kind:  client.ReportGPF,
+		start: regexp.MustCompile(`general protection fault`),
+		title: func(_ []string, r *client.ConsoleReport, rip string) string {
+			return withFunc("general protection fault", rip, r.Frames)
+		},
+	},
+	{
+		kind:  client.ReportBUG,
+		start: regexp.MustCompile(`BUG: unable to handle (?:kernel )?(page fault|paging request|NULL pointer dereference)`),
+		title: func(m []string, r *client.ConsoleReport, rip string) string {
+			what := "kernel paging request"
+			if m[1] == "NULL pointer dereference" {
+				what = "kernel NULL pointer dereference"
+			}
+			return withFunc("BUG: unable to handle "+what, rip, r.Frames)
+		},
+	},
+	{
+		kind:  client.ReportBUG,
+		start: regexp.MustCompile(`kernel BUG at \S+!`),
+		title: func(_ []string, r *client.ConsoleReport, rip string) string {
+			return withFunc("kernel BUG", rip, r.Frames)
+		},
+	},
+	{
+		kind:  client.ReportLockdep,
+		start: regexp.MustCompile(`WARNING: (possible circular locking dependency detected|possible recursive locking detected|possible irq lock inversion dependency detected|inconsistent lock state|suspicious RCU usage|held lock freed!|bad unlock balance detected!|lock held when returning to user space!)`),
+		title: func(m []string, r *client.ConsoleReport, _ string) string {
+			var title string
+			switch m[1] {
+			case "possible circular locking dependency detected", "possible recursive locking detected", "possible irq lock inversion dependency detected":
+				title = "possible deadlock"
+			case "inconsistent lock state":
+				title = "inconsistent lock state"
+			default:
+				title = "WARNING: " + strings.TrimSuffix(strings.TrimSuffix(m[1], "!"), " detected")
+			}
+			return withFunc(title, "", r.Frames)
+		},
+	},
+	{
+		kind:  client.ReportWARNING,
+		start: regexp.MustCompile(`WARNING: CPU: \d+ PID: \d+ at \S+ ([\w.$]+)\+0x`),
+		title: func(m []string, r *client.ConsoleReport, _ string) string {
+			return withFunc("WARNING", cleanFunc(m[1]), r.Frames)
+		},
+	},
+	{
+		kind:  client.ReportHungTask,
+		start: regexp.MustCompile(`INFO: task \S+ blocked for more than \d+ seconds`),
+		title: func(_ []string, r *client.ConsoleReport, _ string) string {
+			return withFunc("INFO: task hung", "", r.Frames)
+		},
+	},
+	{
+		kind:  client.ReportRCUStall,
+		start: regexp.MustCompile(`INFO: rcu_\w+ (?:self-)?detected (?:expedited )?stalls?`),
+		title: func(_ []string, r *client.ConsoleReport, rip string) string {
+			return withFunc("INFO: rcu detected stall", rip, r.Frames)
+		},
+	},
+	{
+		kind:  client.ReportSoftLockup,
+		start: regexp.MustCompile(`BUG: soft lockup - CPU#\d+ stuck`),
+		title: func(_ []string, r *client.ConsoleReport, rip string) string {
+			return withFunc("BUG: soft lockup", rip, r.Frames)
+		},
+	},
+	{
+		kind:  client.ReportBUG,
+		start: regexp.MustCompile(`BUG: ([^:]+?)(?: at | in |: |$)`),
+		title: func(m []string, r *client.ConsoleReport, rip string) string {
+			return withFunc("BUG: "+m[1], rip, r.Frames)
+		},
+	},
+	{
+		kind:  client.ReportPanic,
+		start: regexp.MustCompile(`Kernel panic - not syncing: (.+)`),
+		title: func(m []string, _ *client.ConsoleReport, _ string) string {
+			return "kernel panic: " + strings.TrimSpace(m[1])
+		},
+	},
+}
+
+var (
+	// prefixPattern printk 时间戳及调用者，如 "[   12.345678][  T123] "
+	prefixPattern = regexp.MustCompile(`^(?:\[\s*\d+\.\d+\])?(?:\[\s*[TC]\d+\])?\s?`)
+	ansiPattern   = regexp.MustCompile(`\x1b\[[0-9;]*[A-Za-z]`)
+	// framePattern 调用栈中的一帧，如 "kfree+0x8b/0x250 [ext4] mm/slub.c:4380"
+	framePattern = regexp.MustCompile(`^(?:\[<[0-9a-f]+>\]\s*)?(\? )?([\w.$]+)\+0x([0-9a-f]+)/0x([0-9a-f]+)(?: \[([\w-]+)\])?(?: ([\w./+-]+):(\d+))?`)
+	// inlinePattern 符号化调用栈中的内联帧，如 "__dump_stack lib/dump_stack.c:88 [inline]"
+	inlinePattern = regexp.MustCompile(`^([\w.$]+) ([\w./+-]+):(\d+) \[inline\]`)
+	ripPattern    = regexp.MustCompile(`RIP: 0010:(?:\[<[0-9a-f]+>\]\s*)?([\w.$]+)\+0x`)
+	endPattern    = regexp.MustCompile(`^(?:---\[ end trace|---\[ end Kernel panic|Kernel Offset:|Rebooting in|={20,})`)
+	// sectionEnd 结束当前调用栈的行
+	sectionEnd = regexp.MustCompile(`^(?:$|</TASK>|Allocated by task|Freed by task|The buggy address|Last potentially related|Second to last potentially related|Modules linked in|---\[ end)`)
+
+	kasanAccess = regexp.MustCompile(`^(Read|Write) of size (\d+) at addr ([0-9a-f]+)`)
+	kcsanAccess = regexp.MustCompile(`^(read|write)(?:-write)?(?: \(marked\))? to (0x[0-9a-f]+) of (\d+) bytes`)
+	faultAccess = regexp.MustCompile(`#PF: supervisor (read|write) access`)
+	faultAddr   = regexp.MustCompile(`(?:for address: |non-canonical address |at virtual address )(0x)?([0-9a-f]+)`)
+	// funcSuffix 编译器生成的函数名后缀，如 .constprop.0、.isra.0、.cold
+	funcSuffix = regexp.MustCompile(`(?:\.(?:constprop|isra|part|cold|llvm|lto_priv)(?:\.\d+)*)+$`)
+)
+
+// skipFrame 报告机制、锁、调度以及通用的内存函数，不能代表出错的位置
+var skipFrame = regexp.MustCompile(`^(?:` + strings.Join([]string{
+	`dump_stack\w*`, `__dump_stack`, `show_stack`, `sched_show_task`, `show_regs`, `__show_regs`,
+	`print_\w+`, `__print_\w+`, `panic`, `__warn\w*`, `warn_\w+`, `report_bug`, `handle_bug`,
+	`exc_\w+`, `asm_exc_\w+`, `do_error_trap`, `do_trap`, `do_invalid_op`, `invalid_op`,
+	`kasan\w*`, `__kasan\w*`, `__asan\w*`, `asan\w*`, `check_memory_region\w*`,
+	`kcsan\w*`, `__kcsan\w*`, `__tsan\w*`, `kmsan\w*`, `__msan\w*`, `__ubsan\w*`, `ubsan\w*`, `handle_overflow`,
+	`__might_\w+`, `___might_\w+`, `might_\w+`,
+	`lock_acquire\w*`, `__lock_acquire`, `lock_release`, `lockdep\w*`, `__lockdep\w*`, `mark_lock\w*`, `validate_chain`, `check_\w+`,
+	`_raw_\w+`, `__raw_\w+`, `do_raw_\w+`, `queued_\w+`, `mutex_\w+`, `__mutex_\w+`, `down_\w+`, `up_\w+`, `rwsem_\w+`,
+	`schedule\w*`, `__schedule\w*`, `io_schedule\w*`, `context_switch`, `__switch_to\w*`, `finish_task_switch\w*`, `wait_for_\w+`, `__wait_for_\w+`, `rcu_\w+`, `__rcu_\w+`,
+	`nmi_\w+`, `__nmi\w*`, `debug_\w+`, `refcount_warn_saturate`, `fortify_panic`, `__fortify\w*`,
+	`mem(?:cpy|set|move|cmp)`, `__mem(?:cpy|set|move)`, `str\w*cpy`, `strlen`,
+	`kfree`, `kmem_cache_free`, `__kmem_cache_free`, `slab_free\w*`, `__slab_free`,
+	`kmalloc\w*`, `__kmalloc\w*`, `kmem_cache_alloc\w*`,
+}, "|") + `)$`)
+
+// Parse 返回控制台输出中的第一个内核报告，后续报告只记录标题；没有报告时返回 nil
+func Parse(data []byte) *client.ConsoleReport {
+	lines := clean(string(data))
+
+	var first *client.ConsoleReport
+	for i := 0; i < len(lines); {
+		o, m := match(lines[i])
+		if o == nil {
+			i++
+			continue
+		}
+		// panic 通常是前一个报告的结果（如 panic_on_warn），只有它是第一个报告时才记录
+		if o.kind == client.ReportPanic && first != nil {
+			i++
+			continue
+		}
+
+		end := reportEnd(lines, i)
+		report := parseReport(o, m, lines[i:end])
+		if first == nil {
+			first = report
+		} else if len(first.OtherTitles) < maxOtherTitles && !slices.Contains(first.OtherTitles, report.Title) && report.Title != first.Title {
+			first.OtherTitles = append(first.OtherTitles, report.Title)
+		}
+		i = end
+	}
+	return first
+}
+
+// clean 拆分行并去除时间戳、颜色控制序列和回车
+func clean(text string) []string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		line = ansiPattern.ReplaceAllString(strings.TrimRight(line, "\r"), "")
+		lines[i] = strings.TrimRight(prefixPattern.ReplaceAllString(line, ""), " \t")
+	}
+	return lines
+}
+
+func match(line string) (*oops, []string) {
+	for i := range oopses {
+		if m := oopses[i].start.FindStringSubmatch(line); m != nil {
+			return &oopses[i], m
+		}
+	}
+	return nil, nil
+}
+
+// reportEnd 报告在下一个报告开始、结束标记或达到行数上限处结束
+func reportEnd(lines []string, start int) int {
+	for i := start + 1; i < len(lines); i++ {
+		if i-start >= maxReportLines || endPattern.MatchString(lines[i]) {
+			return i
+		}
+		if o, _ := match(lines[i]); o != nil {
+			return i
+		}
+	}
+	return len(lines)
+}
+
+func parseReport(o *oops, m []string, lines []string) *client.ConsoleReport {
+	report := &client.ConsoleReport{Kind: o.kind, Frames: []client.StackFrame{}}
+
+	var rip string
+	var section *[]client.StackFrame
+	mainDone := false
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+
+		if rip == "" {
+			if rm := ripPattern.FindStringSubmatch(trimmed); rm != nil {
+				rip = cleanFunc(rm[1])
+			}
+		}
+		parseAccess(report, trimmed)
+
+		switch {
+		case strings.HasPrefix(trimmed, "Call Trace:") || trimmed == "Backtrace:":
+			section = nil
+			if !mainDone {
+				section = &report.Frames
+				mainDone = true
+			}
+			continue
+		case strings.HasPrefix(trimmed, "Allocated by task"):
+			section = &report.AllocStack
+			continue
+		case strings.HasPrefix(trimmed, "Freed by task"):
+			section = &report.FreeStack
+			continue
+		case section != nil && sectionEnd.MatchString(trimmed):
+			section = nil
+			continue
+		}
+
+		if section != nil && len(*section) < maxFrames {
+			if frame, ok := parseFrame(trimmed); ok {
+				*section = append(*section, frame)
+			}
+		}
+	}
+
+	report.Text = strings.Join(lines, "\n")
+	if len(report.Text) > maxReportText {
+		report.Text = report.Text[:maxReportText]
+	}
+	report.Title = o.title(m, report, rip)
+	return report
+}
+
+// parseAccess 提取内存错误的访问类型、地址和大小，只取第一次出现的
+func parseAccess(report *client.ConsoleReport, line string) {
+	if report.Access != "" {
+		return
+	}
+	if m := kasanAccess.FindStringSubmatch(line); m != nil {
+		report.Access = strings.ToLower(m[1])
+		report.Size, _ = strconv.Atoi(m[2])
+		report.Address = m[3]
+		return
+	}
+	if m := kcsanAccess.FindStringSubmatch(line); m != nil {
+		report.Access = m[1]
+		if strings.HasPrefix(line, "read-write") {
+			report.Access = "write"
+		}
+		report.Address = strings.TrimPrefix(m[2], "0x")
+		report.Size, _ = strconv.Atoi(m[3])
+		return
+	}
+	if report.Address == "" {
+		if m := faultAddr.FindStringSubmatch(line); m != nil {
+			report.Address = m[2]
+		}
+	}
+	if m := faultAccess.FindStringSubmatch(line); m != nil {
+		report.Access = m[1]
+	}
+}
+
+// parseFrame 解析调用栈中的一帧，不可靠的 "? " 帧被忽略
+func parseFrame(line string) (client.StackFrame, bool) {
+	if m := framePattern.FindStringSubmatch(line); m != nil {
+		if m[1] != "" {
+			return client.StackFrame{}, false
+		}
+		frame := client.StackFrame{Function: m[2], Module: m[5], File: m[6]}
+		frame.Offset, _ = strconv.ParseUint(m[3], 16, 64)
+		frame.Size, _ = strconv.ParseUint(m[4], 16, 64)
+		frame.Line, _ = strconv.Atoi(m[7])
+		return frame, true
+	}
+	if m := inlinePattern.FindStringSubmatch(line); m != nil {
+		frame := client.StackFrame{Function: m[1], File: m[2], Inline: true}
+		frame.Line, _ = strconv.Atoi(m[3])
+		return frame, true
+	}
+	return client.StackFrame{}, false
+}
+
+// withFunc 为标题加上出错的函数：优先使用 RIP，其次是调用栈中第一个有意义的帧
+func withFunc(title, rip string, frames []client.StackFrame) string {
+	fn := rip
+	if fn == "" || skipFrame.MatchString(fn) {
+		fn = frameOr(frames, fn)
+	}
+	if fn == "" {
+		return title
+	}
+	return title + " in " + fn
+}
+
+// frameOr 返回调用栈中第一个有意义的函数，没有时返回 fallback
+func frameOr(frames []client.StackFrame, fallback string) string {
+	for _, frame := range frames {
+		if fn := cleanFunc(frame.Function); !skipFrame.MatchString(fn) {
+			return fn
+		}
+	}
+	return fallback
+}
+
+func cleanFunc(fn string) string {
+	return funcSuffix.ReplaceAllString(fn, "")
+}
+
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
@@ -0,0 +1,140 @@
+package console
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"sdk/client"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		file    string
+		kind    client.ReportKind
+		title   string
+		access  string
+		address string
+		size    int
+		frame   string // 调用栈中第一个非内联帧
+		alloc   int
+		free    int
+	}{
+		{
+			file:    "kasan-slab-use-after-free.log",
+			kind:    client.ReportKASAN,
+			title:   "KASAN: slab-use-after-free Read in hci_conn_hash_flush",
+			access:  "read",
+			address: "ffff88807a1e6000",
+			size:    8,
+			frame:   "dump_stack_lvl",
+			alloc:   12,
+			free:    17,
+		},
+		{
+			file:    "general-protection-fault.log",
+			kind:    client.ReportGPF,
+			title:   "general protection fault in ext4_xattr_inode_dec_ref_all",
+			address: "dffffc0000000003",
+			frame:   "ext4_xattr_delete_inode",
+		},
+		{
+			file:  "warning.log",
+			kind:  client.ReportWARNING,
+			title: "WARNING in rate_control_rate_init",
+			frame: "sta_apply_auth_flags.constprop.0",
+		},
+		{
+			file:  "hung-task.log",
+			kind:  client.ReportHungTask,
+			title: "INFO: task hung in rtnl_lock",
+			frame: "__schedule",
+		},
+		{
+			file:    "kcsan-data-race.log",
+			kind:    client.ReportKCSAN,
+			title:   "KCSAN: data-race in __dentry_kill / lookup_fast",
+			access:  "write",
+			address: "ffff888107a2fcc0",
+			size:    4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			report := parseFile(t, tt.file)
+			if report == nil {
+				t.Fatal("no report found")
+			}
+			if report.Kind != tt.kind || report.Title != tt.title {
+				t.Errorf("got %s %q, want %s %q", report.Kind, report.Title, tt.kind, tt.title)
+			}
+			if report.Access != tt.access || report.Address != tt.address || report.Size != tt.size {
+				t.Errorf("access %q at %q size %d, want %q at %q size %d",
+					report.Access, report.Address, report.Size, tt.access, tt.address, tt.size)
+			}
+			if frame := firstFrame(report.Frames); frame != tt.frame {
+				t.Errorf("first frame %q, want %q", frame, tt.frame)
+			}
+			if len(report.AllocStack) != tt.alloc || len(report.FreeStack) != tt.free {
+				t.Errorf("alloc stack %d frames, free stack %d, want %d and %d",
+					len(report.AllocStack), len(report.FreeStack), tt.alloc, tt.free)
+			}
+			// 报告之后由 panic_on_warn 引起的 panic 不算作另一个报告
+			if len(report.OtherTitles) != 0 {
+				t.Errorf("other titles %q", report.OtherTitles)
+			}
+		})
+	}
+}
+
+func TestParseBootLog(t *testing.T) {
+	if report := parseFile(t, "boot.log"); report != nil {
+		t.Errorf("report %q found in a clean boot log", report.Title)
+	}
+}
+
+func TestParseFrames(t *testing.T) {
+	report := parseFile(t, "kasan-slab-use-after-free.log")
+	if report == nil {
+		t.Fatal("no report found")
+	}
+
+	// "? " 开头的不可靠帧被忽略，同一函数只剩下可靠的一帧
+	count := 0
+	for _, frame := range report.Frames {
+		if frame.Function == "hci_conn_hash_flush" {
+			count++
+		}
+	}
+	if count != 1 {
+		t.Errorf("hci_conn_hash_flush appears %d times in the call trace, want 1", count)
+	}
+	want := client.StackFrame{Function: "hci_conn_hash_flush", Offset: 0x1d7, Size: 0x260, File: "net/bluetooth/hci_conn.c", Line: 2453}
+	if !slices.Contains(report.Frames, want) {
+		t.Errorf("frame %+v not found", want)
+	}
+	inline := client.StackFrame{Function: "kzalloc", File: "include/linux/slab.h", Line: 680, Inline: true}
+	if !slices.Contains(report.AllocStack, inline) {
+		t.Errorf("inline frame %+v not found in the allocation stack", inline)
+	}
+}
+
+func parseFile(t *testing.T, name string) *client.ConsoleReport {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Parse(data)
+}
+
+func firstFrame(frames []client.StackFrame) string {
+	for _, frame := range frames {
+		if !frame.Inline {
+			return frame.Function
+		}
+	}
+	return ""
+}
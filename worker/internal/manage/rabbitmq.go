@@ -6,6 +6,8 @@ import (
 	"sync"
 	"time"
 
+	"sdk/client"
+
 	amqp "github.com/rabbitmq/amqp091-go"
 	log "github.com/sirupsen/logrus"
 )
@@ -88,7 +90,9 @@ func (c *RabbitMQClient) connect() error {
 		false,
 		false,
 		false,
-		nil,
+		// must match the x-max-priority the server declares task_queue with,
+		// otherwise RabbitMQ refuses the redeclaration with PRECONDITION_FAILED
+		amqp.Table{"x-max-priority": int(client.MaxTaskPriority)},
 	)
 	if err != nil {
 		return fmt.Errorf("failed to declare a queue: %w", err)
@@ -0,0 +1,48 @@
+package network
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"worker/internal/console"
+
+	"sdk/client"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// readBisectResult 读取 bisect 任务记录的每一步结论并解析各步的串口日志，其他任务返回 nil。
+// 各步的串口日志与 result.json 在同一目录，以提交命名
+func readBisectResult(ctx context.Context, result *builderResult) *client.BisectResult {
+	if taskType, _ := ctx.Value("taskType").(string); taskType != string(client.TaskTypeBisect) {
+		return nil
+	}
+
+	path := result.Bisect
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		log.WithError(err).WithField("path", path).Warn("failed to read bisect result")
+		return nil
+	}
+	var bisect client.BisectResult
+	if err := json.Unmarshal(data, &bisect); err != nil {
+		log.WithError(err).WithField("path", path).Warn("failed to parse bisect result")
+		return nil
+	}
+
+	dir := filepath.Dir(path)
+	for i := range bisect.Steps {
+		step := &bisect.Steps[i]
+		data, err := os.ReadFile(filepath.Join(dir, step.Commit+".log"))
+		if err != nil {
+			continue
+		}
+		step.Console = console.Parse(data)
+	}
+	return &bisect
+}
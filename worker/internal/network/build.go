@@ -0,0 +1,91 @@
+package network
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"worker/internal/console"
+
+	"sdk/client"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// readKernelConfig 读取本次执行生效的内核 .config，没有走到 DownloadConfig 时为空
+func readKernelConfig(ctx context.Context, result *builderResult) string {
+	commit, ok := ctx.Value("taskCommit").(string)
+	if !ok {
+		return ""
+	}
+
+	path := result.commit(commit).Config
+	if path == "" {
+		return ""
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		log.WithError(err).WithField("path", path).Warn("failed to read kernel config")
+		return ""
+	}
+	return string(data)
+}
+
+// readPatchResults 读取 kernel-builder 记录的补丁系列中每个补丁的应用结果，只有 patch-apply 任务才有
+func readPatchResults(result *builderResult) []client.PatchApplyResult {
+	path := result.Patches
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		log.WithError(err).WithField("path", path).Warn("failed to read patch results")
+		return nil
+	}
+	var results []client.PatchApplyResult
+	if err := json.Unmarshal(data, &results); err != nil {
+		log.WithError(err).WithField("path", path).Warn("failed to parse patch results")
+		return nil
+	}
+	return results
+}
+
+// readRuns 读取 kernel-builder 记录的每次复现运行并解析各次运行的串口日志。
+// fix-verify 与 bisect 任务在多个提交上运行复现程序，各自上报运行结果，这里只处理单个提交的任务
+func readRuns(ctx context.Context, result *builderResult) []client.ReproRun {
+	switch taskType, _ := ctx.Value("taskType").(string); taskType {
+	case string(client.TaskTypeFixVerify), string(client.TaskTypeBisect):
+		return nil
+	}
+	commit, ok := ctx.Value("taskCommit").(string)
+	if !ok {
+		return nil
+	}
+
+	path := result.commit(commit).Runs
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		log.WithError(err).WithField("path", path).Warn("failed to read reproducer runs")
+		return nil
+	}
+	var runs []client.ReproRun
+	if err := json.Unmarshal(data, &runs); err != nil {
+		log.WithError(err).WithField("path", path).Warn("failed to parse reproducer runs")
+		return nil
+	}
+
+	dir := filepath.Dir(path)
+	for i := range runs {
+		data, err := os.ReadFile(filepath.Join(dir, fmt.Sprintf("%d.log", runs[i].Index)))
+		if err != nil {
+			continue
+		}
+		runs[i].Console = console.Parse(data)
+	}
+	return runs
+}
@@ -0,0 +1,47 @@
+package network
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"worker/internal/console"
+
+	"sdk/client"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// readConsoleReport 解析本次执行产生的串口日志中的内核报告；没有日志或没有报告时返回 nil
+func readConsoleReport(ctx context.Context, result *builderResult) *client.ConsoleReport {
+	commit, ok := ctx.Value("taskCommit").(string)
+	if !ok {
+		return nil
+	}
+	report, _ := readCommitConsole(result, commit)
+	return report
+}
+
+// readCommitConsole 解析 commit 的内核在本次执行中的串口日志；get.sh 会把虚拟机槽位工作目录中的
+// 串口日志移动到内核构建目录，found 表示本次执行是否取得了该内核的串口日志
+func readCommitConsole(result *builderResult, commit string) (report *client.ConsoleReport, found bool) {
+	path := result.commit(commit).Console
+	if path == "" {
+		return nil, false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		log.WithError(err).WithField("path", path).Warn("failed to read console log")
+		return nil, true
+	}
+
+	report = console.Parse(data)
+	if report != nil {
+		log.WithFields(log.Fields{
+			"path":  filepath.Base(path),
+			"kind":  report.Kind,
+			"title": report.Title,
+		}).Info("kernel report found in console log")
+	}
+	return report, true
+}
@@ -0,0 +1,99 @@
+package network
+
+import (
+	"regexp"
+	"sync"
+
+	"worker/internal/classify"
+
+	"sdk/client"
+)
+
+const (
+	// tailLines 保留的最近输出行数
+	tailLines = 400
+	// notableLines 保留的可能决定失败分类的输出行数，编译错误可能早于大量后续输出
+	notableLines = 50
+	// maxDiagnostics 保留的警告和错误行数，用于比较两次执行
+	maxDiagnostics = 1000
+)
+
+// diagnosticPattern 警告和错误行
+var diagnosticPattern = regexp.MustCompile(`(?i)\b(?:warning|error|fail(?:ed|ure)?|fatal|panic|oops|bug|cannot|unable|not found|denied|timed out|timeout)\b`)
+
+// runOutput 记录一次任务执行中用于失败分类和比较的信息，stdout 与 stderr 并发写入
+type runOutput struct {
+	mu         sync.Mutex
+	tail       []string
+	next       int
+	notable    []string
+	failedStep string
+	stepError  string
+	warnings   []classify.Warning
+	diagnosis  []string
+}
+
+// addLine 记录一行非进度输出
+func (o *runOutput) addLine(line string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if len(o.diagnosis) < maxDiagnostics {
+		if cleaned := classify.Clean(line); diagnosticPattern.MatchString(cleaned) {
+			o.diagnosis = append(o.diagnosis, cleaned)
+		}
+	}
+	if len(o.notable) < notableLines && classify.Notable(line) {
+		o.notable = append(o.notable, line)
+	}
+	if len(o.tail) < tailLines {
+		o.tail = append(o.tail, line)
+		return
+	}
+	o.tail[o.next] = line
+	o.next = (o.next + 1) % tailLines
+}
+
+// addRecord 记录第一个失败的步骤及所有警告
+func (o *runOutput) addRecord(record progressRecord) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	switch record.Kind {
+	case string(client.TaskEventStepFinish):
+		if record.Error != "" && o.failedStep == "" {
+			o.failedStep = record.Step
+			o.stepError = record.Error
+		}
+	case "warning":
+		o.warnings = append(o.warnings, classify.Warning{Step: record.Step, Message: record.Message})
+		if len(o.diagnosis) < maxDiagnostics {
+			o.diagnosis = append(o.diagnosis, "warning: "+record.Step+": "+record.Message)
+		}
+	}
+}
+
+// diagnostics 本次执行的警告和错误行
+func (o *runOutput) diagnostics() []string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return append([]string(nil), o.diagnosis...)
+}
+
+// classify 判断任务失败的原因
+func (o *runOutput) classify() client.TaskFailure {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	lines := make([]string, 0, len(o.notable)+len(o.tail))
+	lines = append(lines, o.notable...)
+	lines = append(lines, o.tail[o.next:]...)
+	lines = append(lines, o.tail[:o.next]...)
+
+	return classify.Classify(classify.Input{
+		FailedStep: o.failedStep,
+		StepError:  o.stepError,
+		Warnings:   o.warnings,
+		Lines:      lines,
+	})
+}
@@ -0,0 +1,39 @@
+package network
+
+import (
+	"context"
+
+	"sdk/client"
+)
+
+// readFixVerification 读取 fix-verify 任务在父提交和修复提交上的两次运行结果，其他任务返回 nil
+func readFixVerification(ctx context.Context, result *builderResult) *client.FixVerification {
+	parentCommit, ok := ctx.Value("taskCommit").(string)
+	if !ok {
+		return nil
+	}
+	fixCommit, ok := ctx.Value("fixCommit").(string)
+	if !ok {
+		return nil
+	}
+
+	return &client.FixVerification{
+		ParentCommit: parentCommit,
+		FixCommit:    fixCommit,
+		Parent:       readFixRun(result, parentCommit),
+		Fix:          readFixRun(result, fixCommit),
+	}
+}
+
+// readFixRun 读取 commit 的内核上复现程序的运行结果；本次执行没有取得该内核的串口日志时返回 nil
+func readFixRun(result *builderResult, commit string) *client.FixRun {
+	report, found := readCommitConsole(result, commit)
+	if !found {
+		return nil
+	}
+
+	run := &client.FixRun{Console: report}
+	run.VmcoreCaptured = result.commit(commit).Vmcore != ""
+	run.Crashed = run.Console != nil || run.VmcoreCaptured
+	return run
+}
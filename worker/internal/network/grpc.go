@@ -17,16 +17,9 @@ import (
 
 	pb "worker/internal/proto"
 
-	log "github.com/sirupsen/logrus"
-)
-
-type TaskStatus string
+	"sdk/client"
 
-const (
-	StatusPending TaskStatus = "pending"
-	StatusRunning TaskStatus = "running"
-	StatusSuccess TaskStatus = "success"
-	StatusFailed  TaskStatus = "failed"
+	log "github.com/sirupsen/logrus"
 )
 
 var (
@@ -36,32 +29,22 @@ var (
 )
 
 // uploadArtifact 上传任务产物到服务器
-func uploadArtifact(ctx context.Context, httpClient *HttpClient, taskID string, localFilePath string) error {
+func uploadArtifact(ctx context.Context, apiClient *client.Client, taskID string, localFilePath string) error {
 	log.WithFields(log.Fields{
 		"task_id":  taskID,
 		"filepath": localFilePath,
 	}).Info("preparing to upload artifact")
 
 	// 确保文件存在
-	if _, err := os.Stat(localFilePath); os.IsNotExist(err) {
-		return fmt.Errorf("file does not exist: %s", localFilePath)
-	}
-
-	// 准备上传内容
-	fileFields := map[string]string{
-		"artifact": localFilePath,
-	}
-
-	uploadPath := fmt.Sprintf("/api/v1/tasks/%s/artifact", taskID)
-
-	// 执行上传
-	resp, err := httpClient.PostMultipartStream(uploadPath, nil, fileFields)
+	file, err := os.Open(localFilePath)
 	if err != nil {
-		return fmt.Errorf("failed to upload artifact: %w", err)
+		return fmt.Errorf("failed to open artifact: %w", err)
 	}
+	defer file.Close()
 
-	if !resp.IsSuccess() {
-		return fmt.Errorf("failed to upload artifact, server response: %d - %s", resp.StatusCode, resp.String())
+	// 流式上传
+	if err := apiClient.UploadArtifact(ctx, taskID, filepath.Base(localFilePath), file); err != nil {
+		return fmt.Errorf("failed to upload artifact: %w", err)
 	}
 
 	log.WithField("filepath", localFilePath).Info("artifact uploaded successfully")
@@ -69,7 +52,7 @@ func uploadArtifact(ctx context.Context, httpClient *HttpClient, taskID string,
 }
 
 // ExecuteAndStreamLogs 执行命令并流式传输日志
-func ExecuteAndStreamLogs(ctx context.Context, client pb.LogStreamServiceClient, cmdStr string, httpClient *HttpClient) error {
+func ExecuteAndStreamLogs(ctx context.Context, logClient pb.LogStreamServiceClient, cmdStr string, apiClient *client.Client) error {
 	log.WithField("command", cmdStr).Info("starting to execute command")
 
 	// 解析命令
@@ -95,7 +78,7 @@ func ExecuteAndStreamLogs(ctx context.Context, client pb.LogStreamServiceClient,
 	}
 
 	// 创建日志流
-	stream, err := client.UploadLogs(ctx)
+	stream, err := logClient.UploadLogs(ctx)
 	if err != nil {
 		log.WithError(err).Error("failed to create log stream")
 		return fmt.Errorf("failed to create log stream: %w", err)
@@ -106,6 +89,12 @@ func ExecuteAndStreamLogs(ctx context.Context, client pb.LogStreamServiceClient,
 		}
 	}()
 
+	// 进度流，kernel-builder 的进度记录经此转发
+	taskID, _ := ctx.Value("taskID").(string)
+	workerID, _ := ctx.Value("workerID").(string)
+	progress := newProgressForwarder(ctx, logClient, apiClient, taskID, workerID)
+	defer progress.close()
+
 	// 启动命令
 	if err := cmd.Start(); err != nil {
 		log.WithError(err).Error("failed to start command")
@@ -119,14 +108,14 @@ func ExecuteAndStreamLogs(ctx context.Context, client pb.LogStreamServiceClient,
 	wg.Add(2)
 	go func() {
 		defer wg.Done()
-		if err := streamPipe(stream, stdoutPipe, "stdout", ctx); err != nil {
+		if err := streamPipe(stream, stdoutPipe, "stdout", ctx, progress); err != nil {
 			errChan <- fmt.Errorf("failed to process stdout: %w", err)
 		}
 	}()
 
 	go func() {
 		defer wg.Done()
-		if err := streamPipe(stream, stderrPipe, "stderr", ctx); err != nil {
+		if err := streamPipe(stream, stderrPipe, "stderr", ctx, progress); err != nil {
 			errChan <- fmt.Errorf("failed to process stderr: %w", err)
 		}
 	}()
@@ -151,9 +140,12 @@ func ExecuteAndStreamLogs(ctx context.Context, client pb.LogStreamServiceClient,
 		}).Info("log stream response received")
 	}
 
+	// 步骤事件须在任务状态之前记录
+	progress.close()
+
 	// 等待命令完成并处理结果
 	cmdErr := cmd.Wait()
-	if err := reportTaskResult(ctx, httpClient, cmdErr); err != nil {
+	if err := reportTaskResult(ctx, apiClient, cmdErr, progress.output); err != nil {
 		log.WithError(err).Error("failed to report task result")
 	}
 
@@ -161,14 +153,14 @@ func ExecuteAndStreamLogs(ctx context.Context, client pb.LogStreamServiceClient,
 	return cmdErr
 }
 
-// reportTaskResult 报告任务执行结果
-func reportTaskResult(ctx context.Context, httpClient *HttpClient, cmdErr error) error {
+// reportTaskResult 报告任务执行结果及串口日志中的内核报告，失败时附带根据 output 判断的失败原因
+func reportTaskResult(ctx context.Context, apiClient *client.Client, cmdErr error, output *runOutput) error {
 	taskID, ok := ctx.Value("taskID").(string)
 	if !ok {
 		return fmt.Errorf("cannot get taskID from context")
 	}
 
-	var payload map[string]interface{}
+	var payload client.UpdateTaskStatusRequest
 	if cmdErr != nil {
 		var resultMessage string
 		var exitErr *exec.ExitError
@@ -177,29 +169,71 @@ func reportTaskResult(ctx context.Context, httpClient *HttpClient, cmdErr error)
 		} else {
 			resultMessage = fmt.Sprintf("command startup failed: %v", cmdErr)
 		}
-		payload = map[string]interface{}{
-			"status": StatusFailed,
-			"result": resultMessage,
+		failure := output.classify()
+		payload = client.UpdateTaskStatusRequest{
+			Status:  client.StatusFailed,
+			Result:  client.TaskResult{Message: resultMessage},
+			Failure: &failure,
+		}
+		// 补丁未能应用或编译失败时，补丁结果与编译日志正是需要查看的产物
+		if taskType, _ := ctx.Value("taskType").(string); taskType == string(client.TaskTypePatchApply) {
+			if err := uploadTaskArtifact(ctx, apiClient); err != nil {
+				log.WithError(err).Warn("failed to upload patch result of failed task")
+			}
 		}
 	} else {
-		payload = map[string]interface{}{
-			"status": StatusSuccess,
-			"result": "task executed successfully",
+		payload = client.UpdateTaskStatusRequest{
+			Status: client.StatusSuccess,
+			Result: client.TaskResult{Message: "task executed successfully"},
 		}
-		if err := uploadTaskArtifact(ctx, httpClient); err != nil {
+		// fix-verify 与 bisect 只比较各次运行的结果，不打包产物
+		var err error
+		if taskType, _ := ctx.Value("taskType").(string); taskType != string(client.TaskTypeFixVerify) && taskType != string(client.TaskTypeBisect) {
+			err = reportStep(ctx, apiClient, taskID, "UploadArtifact", func() error {
+				return uploadTaskArtifact(ctx, apiClient)
+			})
+		}
+		if err != nil {
 			log.WithError(err).Error("failed to upload artifact")
-			payload = map[string]interface{}{
-				"status": StatusFailed,
-				"result": "failed to upload artifact",
+			payload = client.UpdateTaskStatusRequest{
+				Status: client.StatusFailed,
+				Result: client.TaskResult{Message: "failed to upload artifact"},
+				Failure: &client.TaskFailure{
+					Category: client.FailureArtifactUpload,
+					Step:     "UploadArtifact",
+					Message:  err.Error(),
+				},
 			}
 		}
 	}
 
-	taskUpdatePath := fmt.Sprintf("/api/v1/tasks/%s", taskID)
+	// 是否复现由服务器比较崩溃标题与漏洞报告标题得出
+	result := readBuilderResult(ctx)
+	taskCommit, _ := ctx.Value("taskCommit").(string)
+	payload.Console = readConsoleReport(ctx, result)
+	payload.Result.VmcoreCaptured = result.commit(taskCommit).Vmcore != ""
+	payload.Result.FixVerify = readFixVerification(ctx, result)
+	if verification := payload.Result.FixVerify; verification != nil {
+		// 任务本身的结果是父提交上的运行
+		payload.Result.VmcoreCaptured = verification.Parent != nil && verification.Parent.VmcoreCaptured
+	}
+	payload.Result.Bisect = readBisectResult(ctx, result)
+	if bisect := payload.Result.Bisect; bisect != nil && len(bisect.Steps) > 0 {
+		// 第一步测试 bad 提交，任务本身的结果是复现程序在 bad 上的运行
+		payload.Console = bisect.Steps[0].Console
+		payload.Result.VmcoreCaptured = bisect.Steps[0].VmcoreCaptured
+	}
+	payload.Result.Crashed = payload.Console != nil || payload.Result.VmcoreCaptured
+	payload.Result.Patches = readPatchResults(result)
+	payload.Result.Runs = readRuns(ctx, result)
+	payload.Result.Accel = result.Accel
+	payload.KernelConfig = readKernelConfig(ctx, result)
+	payload.Diagnostics = output.diagnostics()
+
 	reportCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	response, err := httpClient.PatchWithContext(reportCtx, taskUpdatePath, payload)
+	task, err := apiClient.UpdateTaskStatus(reportCtx, taskID, payload)
 	if err != nil {
 		slog.Error("failed to report status to server",
 			slog.String("task_id", taskID),
@@ -207,20 +241,17 @@ func reportTaskResult(ctx context.Context, httpClient *HttpClient, cmdErr error)
 		return err
 	}
 
-	if !response.IsSuccess() {
-		slog.Error("server failed to update status",
-			slog.String("task_id", taskID),
-			slog.Int("status_code", response.StatusCode),
-			slog.String("response", response.String()))
-		return fmt.Errorf("server failed to update status: %d", response.StatusCode)
+	verdict := ""
+	if task.Result != nil {
+		verdict = string(task.Result.Verdict)
 	}
-
-	slog.Info("server status updated successfully", slog.String("task_id", taskID))
+	slog.Info("server status updated successfully", slog.String("task_id", taskID), slog.String("verdict", verdict))
 	return nil
 }
 
-// uploadTaskArtifact 上传任务产物
-func uploadTaskArtifact(ctx context.Context, httpClient *HttpClient) error {
+// uploadTaskArtifact 上传任务产物：kernel-build 为打包的内核源码树与 vmcore，
+// patch-apply 为补丁、git apply 输出与增量编译日志
+func uploadTaskArtifact(ctx context.Context, apiClient *client.Client) error {
 	taskID, ok := ctx.Value("taskID").(string)
 	if !ok {
 		return fmt.Errorf("cannot get taskID from context")
@@ -238,12 +269,16 @@ func uploadTaskArtifact(ctx context.Context, httpClient *HttpClient) error {
 
 	artifactPath := filepath.Join(rootPath,
 		fmt.Sprintf("../build-vmcore/build/%s/linux-%s.tar.zst", taskCommit, taskCommit))
+	if taskType, _ := ctx.Value("taskType").(string); taskType == string(client.TaskTypePatchApply) {
+		artifactPath = filepath.Join(rootPath,
+			fmt.Sprintf("../build-vmcore/build/%s/patch-%s.tar.gz", taskCommit, taskCommit))
+	}
 
-	return uploadArtifact(ctx, httpClient, taskID, artifactPath)
+	return uploadArtifact(ctx, apiClient, taskID, artifactPath)
 }
 
-// streamPipe 处理管道流并发送到日志服务
-func streamPipe(stream pb.LogStreamService_UploadLogsClient, reader io.Reader, streamType string, ctx context.Context) error {
+// streamPipe 处理管道流并发送到日志服务，进度行交给 progress 转发
+func streamPipe(stream pb.LogStreamService_UploadLogsClient, reader io.Reader, streamType string, ctx context.Context, progress *progressForwarder) error {
 	taskID, ok := ctx.Value("taskID").(string)
 	workID, ok := ctx.Value("workerID").(string)
 	if !ok {
@@ -263,6 +298,10 @@ func streamPipe(stream pb.LogStreamService_UploadLogsClient, reader io.Reader, s
 
 		fmt.Printf("%s\n", scanner.Text())
 
+		if progress.handleLine(ctx, scanner.Text()) {
+			continue
+		}
+
 		// 根据proto文件，LogMessage只有client_id, task_id, timestamp, message字段
 		msg := &pb.LogMessage{
 			ClientId:  workID,
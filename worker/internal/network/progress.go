@@ -0,0 +1,218 @@
+package network
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	pb "worker/internal/proto"
+
+	"sdk/client"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// progressPrefix kernel-builder 输出进度记录时使用的行前缀，见 backend/pkg/progress
+const progressPrefix = "@@progress "
+
+// eventQueueSize 等待上报的步骤事件数上限，队列满时丢弃新事件而不是阻塞输出处理
+const eventQueueSize = 256
+
+// progressRecord kernel-builder 输出的进度记录
+type progressRecord struct {
+	Kind       string    `json:"kind"`
+	Step       string    `json:"step"`
+	OccurredAt time.Time `json:"occurred_at"`
+	DurationMs *int64    `json:"duration_ms"`
+	Error      string    `json:"error"`
+	Percent    *float64  `json:"percent"`
+	Current    int64     `json:"current"`
+	Total      int64     `json:"total"`
+	Message    string    `json:"message"`
+}
+
+// progressForwarder 转发进度记录：所有记录经 gRPC UploadProgress 流发送给服务器用于实时进度，
+// 步骤边界另外通过 REST 记录到任务时间线。REST 上报由单独的 goroutine 按顺序执行，
+// 输出处理不会因服务器响应慢而停顿，kernel-builder 也不会因管道写满而阻塞。
+// 上报失败只记录日志，不影响任务执行。经过的所有行同时记录到 output，用于任务失败时分类
+type progressForwarder struct {
+	apiClient *client.Client
+	taskID    string
+	workerID  string
+	output    *runOutput
+
+	events     chan client.ReportTaskEventRequest
+	eventsDone chan struct{}
+	closeOnce  sync.Once
+
+	mu     sync.Mutex
+	stream pb.LogStreamService_UploadProgressClient // 为 nil 时只上报时间线
+}
+
+// newProgressForwarder 打开进度流；服务器不支持或连接失败时退化为只上报时间线
+func newProgressForwarder(ctx context.Context, logClient pb.LogStreamServiceClient, apiClient *client.Client, taskID, workerID string) *progressForwarder {
+	f := &progressForwarder{
+		apiClient:  apiClient,
+		taskID:     taskID,
+		workerID:   workerID,
+		output:     &runOutput{},
+		events:     make(chan client.ReportTaskEventRequest, eventQueueSize),
+		eventsDone: make(chan struct{}),
+	}
+	go f.postEvents(ctx)
+
+	stream, err := logClient.UploadProgress(ctx)
+	if err != nil {
+		log.WithError(err).Warn("failed to open progress stream, live progress disabled")
+		return f
+	}
+	f.stream = stream
+	return f
+}
+
+// handleLine 若该行为进度记录则转发并返回 true
+func (f *progressForwarder) handleLine(ctx context.Context, line string) bool {
+	data, ok := strings.CutPrefix(line, progressPrefix)
+	if !ok {
+		f.output.addLine(line)
+		return false
+	}
+
+	var record progressRecord
+	if err := json.Unmarshal([]byte(data), &record); err != nil {
+		log.WithError(err).WithField("line", line).Warn("malformed progress line")
+		return true
+	}
+	if record.OccurredAt.IsZero() {
+		record.OccurredAt = time.Now().UTC()
+	}
+
+	f.output.addRecord(record)
+	f.send(record)
+
+	if record.Kind == string(client.TaskEventStepStart) || record.Kind == string(client.TaskEventStepFinish) {
+		f.queueEvent(client.ReportTaskEventRequest{
+			Kind:       client.TaskEventKind(record.Kind),
+			Step:       record.Step,
+			OccurredAt: &record.OccurredAt,
+			DurationMs: record.DurationMs,
+			Error:      record.Error,
+		})
+	}
+	return true
+}
+
+// queueEvent 把步骤事件交给 postEvents，不等待上报完成
+func (f *progressForwarder) queueEvent(event client.ReportTaskEventRequest) {
+	select {
+	case f.events <- event:
+	default:
+		log.WithFields(log.Fields{"kind": event.Kind, "step": event.Step}).Warn("task event queue is full, dropping event")
+	}
+}
+
+// postEvents 按顺序上报队列中的步骤事件，直到 close 关闭队列
+func (f *progressForwarder) postEvents(ctx context.Context) {
+	defer close(f.eventsDone)
+	for event := range f.events {
+		postTaskEvent(ctx, f.apiClient, f.taskID, event)
+	}
+}
+
+// send 发送到进度流；stdout 与 stderr 由不同的 goroutine 处理，发送需加锁
+func (f *progressForwarder) send(record progressRecord) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.stream == nil {
+		return
+	}
+
+	msg := &pb.ProgressRecord{
+		ClientId:  f.workerID,
+		TaskId:    f.taskID,
+		Timestamp: record.OccurredAt.Format(time.RFC3339Nano),
+		Kind:      record.Kind,
+		Step:      record.Step,
+		Current:   record.Current,
+		Total:     record.Total,
+		Message:   record.Message,
+	}
+	if record.Percent != nil {
+		msg.HasPercent = true
+		msg.Percent = *record.Percent
+	}
+	if record.Kind == string(client.TaskEventStepFinish) {
+		msg.Message = record.Error
+	}
+
+	if err := f.stream.Send(msg); err != nil {
+		log.WithError(err).Warn("progress stream failed, live progress disabled")
+		f.stream = nil
+	}
+}
+
+// close 等待排队的步骤事件上报完成并结束进度流；服务器只在任务运行时记录步骤事件，
+// 因此须在上报任务状态前调用。只能在输出处理结束后调用，可以重复调用
+func (f *progressForwarder) close() {
+	f.closeOnce.Do(func() {
+		close(f.events)
+	})
+	<-f.eventsDone
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.stream == nil {
+		return
+	}
+	if _, err := f.stream.CloseAndRecv(); err != nil {
+		log.WithError(err).Warn("failed to close progress stream")
+	}
+	f.stream = nil
+}
+
+// reportStep 执行 worker 自身的步骤（如上传产物）并上报其开始与结束
+func reportStep(ctx context.Context, apiClient *client.Client, taskID string, step string, fn func() error) error {
+	start := time.Now().UTC()
+	postTaskEvent(ctx, apiClient, taskID, client.ReportTaskEventRequest{
+		Kind:       client.TaskEventStepStart,
+		Step:       step,
+		OccurredAt: &start,
+	})
+
+	err := fn()
+
+	end := time.Now().UTC()
+	duration := end.Sub(start).Milliseconds()
+	finish := client.ReportTaskEventRequest{
+		Kind:       client.TaskEventStepFinish,
+		Step:       step,
+		OccurredAt: &end,
+		DurationMs: &duration,
+	}
+	if err != nil {
+		finish.Error = err.Error()
+	}
+	postTaskEvent(ctx, apiClient, taskID, finish)
+	return err
+}
+
+// postTaskEvent 上报一条步骤事件，失败只记录日志
+func postTaskEvent(ctx context.Context, apiClient *client.Client, taskID string, event client.ReportTaskEventRequest) {
+	reportCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if _, err := apiClient.ReportTaskEvent(reportCtx, taskID, event); err != nil {
+		log.WithError(err).WithFields(log.Fields{
+			"task_id": taskID,
+			"kind":    event.Kind,
+			"step":    event.Step,
+		}).Warn("failed to report task event")
+		return
+	}
+
+	log.WithFields(log.Fields{"kind": event.Kind, "step": event.Step}).Info("task event reported")
+}
@@ -0,0 +1,58 @@
+package network
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// builderResult kernel-builder 以 -r 写入的结果文件（backend/pkg/result），只列出本次执行产生的文件，
+// 路径均为绝对路径；之前执行留在构建目录中的文件不会出现在这里
+type builderResult struct {
+	Accel   string                    `json:"accel,omitempty"`
+	Patches string                    `json:"patches,omitempty"`
+	Bisect  string                    `json:"bisect,omitempty"`
+	Commits map[string]*builderCommit `json:"commits,omitempty"`
+}
+
+// builderCommit 本次执行在一个内核提交上产生的文件
+type builderCommit struct {
+	Config  string `json:"config,omitempty"`
+	Console string `json:"console,omitempty"`
+	Vmcore  string `json:"vmcore,omitempty"`
+	Runs    string `json:"runs,omitempty"`
+}
+
+// readBuilderResult 读取本次执行的结果文件；kernel-builder 没有写入结果（如启动前就失败）时返回空结果
+func readBuilderResult(ctx context.Context) *builderResult {
+	result := &builderResult{}
+	path, ok := ctx.Value("resultPath").(string)
+	if !ok {
+		return result
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			log.WithError(err).WithField("path", path).Warn("failed to read builder result")
+		}
+		return result
+	}
+	if err := json.Unmarshal(data, result); err != nil {
+		log.WithError(err).WithField("path", path).Warn("failed to parse builder result")
+		return &builderResult{}
+	}
+	return result
+}
+
+// commit 本次执行在 commit 上产生的文件，没有时为空
+func (r *builderResult) commit(commit string) builderCommit {
+	if c := r.Commits[commit]; c != nil {
+		return *c
+	}
+	return builderCommit{}
+}
@@ -23,6 +23,8 @@ const _ = grpc.SupportPackageIsVersion7
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type LogStreamServiceClient interface {
 	UploadLogs(ctx context.Context, opts ...grpc.CallOption) (LogStreamService_UploadLogsClient, error)
+	TailLogs(ctx context.Context, in *TailRequest, opts ...grpc.CallOption) (LogStreamService_TailLogsClient, error)
+	UploadProgress(ctx context.Context, opts ...grpc.CallOption) (LogStreamService_UploadProgressClient, error)
 }
 
 type logStreamServiceClient struct {
@@ -67,11 +69,79 @@ func (x *logStreamServiceUploadLogsClient) CloseAndRecv() (*UploadLogsResponse,
 	return m, nil
 }
 
+func (c *logStreamServiceClient) TailLogs(ctx context.Context, in *TailRequest, opts ...grpc.CallOption) (LogStreamService_TailLogsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &LogStreamService_ServiceDesc.Streams[1], "/grpc.LogStreamService/TailLogs", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &logStreamServiceTailLogsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type LogStreamService_TailLogsClient interface {
+	Recv() (*LogLine, error)
+	grpc.ClientStream
+}
+
+type logStreamServiceTailLogsClient struct {
+	grpc.ClientStream
+}
+
+func (x *logStreamServiceTailLogsClient) Recv() (*LogLine, error) {
+	m := new(LogLine)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *logStreamServiceClient) UploadProgress(ctx context.Context, opts ...grpc.CallOption) (LogStreamService_UploadProgressClient, error) {
+	stream, err := c.cc.NewStream(ctx, &LogStreamService_ServiceDesc.Streams[2], "/grpc.LogStreamService/UploadProgress", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &logStreamServiceUploadProgressClient{stream}
+	return x, nil
+}
+
+type LogStreamService_UploadProgressClient interface {
+	Send(*ProgressRecord) error
+	CloseAndRecv() (*UploadLogsResponse, error)
+	grpc.ClientStream
+}
+
+type logStreamServiceUploadProgressClient struct {
+	grpc.ClientStream
+}
+
+func (x *logStreamServiceUploadProgressClient) Send(m *ProgressRecord) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *logStreamServiceUploadProgressClient) CloseAndRecv() (*UploadLogsResponse, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(UploadLogsResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // LogStreamServiceServer is the server API for LogStreamService service.
 // All implementations must embed UnimplementedLogStreamServiceServer
 // for forward compatibility
 type LogStreamServiceServer interface {
 	UploadLogs(LogStreamService_UploadLogsServer) error
+	TailLogs(*TailRequest, LogStreamService_TailLogsServer) error
+	UploadProgress(LogStreamService_UploadProgressServer) error
 	mustEmbedUnimplementedLogStreamServiceServer()
 }
 
@@ -82,6 +152,12 @@ type UnimplementedLogStreamServiceServer struct {
 func (UnimplementedLogStreamServiceServer) UploadLogs(LogStreamService_UploadLogsServer) error {
 	return status.Errorf(codes.Unimplemented, "method UploadLogs not implemented")
 }
+func (UnimplementedLogStreamServiceServer) TailLogs(*TailRequest, LogStreamService_TailLogsServer) error {
+	return status.Errorf(codes.Unimplemented, "method TailLogs not implemented")
+}
+func (UnimplementedLogStreamServiceServer) UploadProgress(LogStreamService_UploadProgressServer) error {
+	return status.Errorf(codes.Unimplemented, "method UploadProgress not implemented")
+}
 func (UnimplementedLogStreamServiceServer) mustEmbedUnimplementedLogStreamServiceServer() {}
 
 // UnsafeLogStreamServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -121,6 +197,53 @@ func (x *logStreamServiceUploadLogsServer) Recv() (*LogMessage, error) {
 	return m, nil
 }
 
+func _LogStreamService_TailLogs_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(TailRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(LogStreamServiceServer).TailLogs(m, &logStreamServiceTailLogsServer{stream})
+}
+
+type LogStreamService_TailLogsServer interface {
+	Send(*LogLine) error
+	grpc.ServerStream
+}
+
+type logStreamServiceTailLogsServer struct {
+	grpc.ServerStream
+}
+
+func (x *logStreamServiceTailLogsServer) Send(m *LogLine) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _LogStreamService_UploadProgress_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(LogStreamServiceServer).UploadProgress(&logStreamServiceUploadProgressServer{stream})
+}
+
+type LogStreamService_UploadProgressServer interface {
+	SendAndClose(*UploadLogsResponse) error
+	Recv() (*ProgressRecord, error)
+	grpc.ServerStream
+}
+
+type logStreamServiceUploadProgressServer struct {
+	grpc.ServerStream
+}
+
+func (x *logStreamServiceUploadProgressServer) SendAndClose(m *UploadLogsResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *logStreamServiceUploadProgressServer) Recv() (*ProgressRecord, error) {
+	m := new(ProgressRecord)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // LogStreamService_ServiceDesc is the grpc.ServiceDesc for LogStreamService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -134,6 +257,16 @@ var LogStreamService_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _LogStreamService_UploadLogs_Handler,
 			ClientStreams: true,
 		},
+		{
+			StreamName:    "TailLogs",
+			Handler:       _LogStreamService_TailLogs_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "UploadProgress",
+			Handler:       _LogStreamService_UploadProgress_Handler,
+			ClientStreams: true,
+		},
 	},
 	Metadata: "proto/grpc.proto",
 }